@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/theadriann/vibeproxyplus/internal/errorlog"
+)
+
+func main() {
+	verbose := flag.Bool("v", false, "List the log files under each finding")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: errlog [flags] [dir|file]... (default: config/logs)\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"config/logs"}
+	}
+
+	var entries []*errorlog.Entry
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if info.IsDir() {
+			parsed, err := errorlog.ParseDir(arg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			entries = append(entries, parsed...)
+			continue
+		}
+		entry, err := errorlog.ParseFile(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		entries = append(entries, entry)
+	}
+
+	summary := errorlog.Summarize(entries)
+	fmt.Printf("Parsed %d error logs\n", summary.Total)
+	if summary.Total == 0 {
+		return
+	}
+
+	byStatus := make(map[string]int, len(summary.ByStatus))
+	for status, count := range summary.ByStatus {
+		byStatus[strconv.Itoa(status)] = count
+	}
+
+	printCounts("Endpoints", summary.ByEndpoint)
+	printCounts("Models", summary.ByModel)
+	printCounts("Status", byStatus)
+	printCounts("Errors", summary.ByError)
+
+	// Group findings so each distinct fix is printed once with its files.
+	type group struct {
+		finding errorlog.Finding
+		files   []string
+	}
+	groups := make(map[string]*group)
+	for _, e := range entries {
+		for _, f := range errorlog.Diagnose(e) {
+			key := f.Code + "\x00" + f.Message
+			g, ok := groups[key]
+			if !ok {
+				g = &group{finding: f}
+				groups[key] = g
+			}
+			g.files = append(g.files, e.File)
+		}
+	}
+	if len(groups) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Printf("\nLikely fixes:\n")
+	for _, k := range keys {
+		g := groups[k]
+		fmt.Printf("  [%s] %s (%d logs)\n", g.finding.Code, g.finding.Message, len(g.files))
+		fmt.Printf("    fix: %s\n", g.finding.Fix)
+		if *verbose {
+			for _, file := range g.files {
+				fmt.Printf("    - %s\n", file)
+			}
+		}
+	}
+}
+
+func printCounts(title string, counts map[string]int) {
+	fmt.Printf("\n%s:\n", title)
+	for _, c := range errorlog.Sorted(counts) {
+		fmt.Printf("  %5d  %s\n", c.Count, c.Key)
+	}
+}
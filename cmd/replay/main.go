@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/theadriann/vibeproxyplus/internal/replay"
+)
+
+func main() {
+	target := flag.String("target", "http://127.0.0.1:8317", "Base URL to replay against (ThinkingProxy, or CLIProxyAPIPlus directly)")
+	pathFilter := flag.String("path", "", "Only replay requests with this path (e.g. /v1/responses)")
+	modelFilter := flag.String("model", "", "Only replay requests whose model contains this string")
+	statusFilter := flag.Int("status", 0, "Only replay requests with this recorded status")
+	apiKey := flag.String("api-key", "", "API key to send instead of the recorded Authorization header")
+	timeout := flag.Duration("timeout", 10*time.Minute, "Timeout per replayed request")
+	dryRun := flag.Bool("dry-run", false, "List matching requests without sending them")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: replay [flags] <capture.jsonl|error.log|dir>...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	targetURL, err := url.Parse(*target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid target URL: %v\n", err)
+		os.Exit(2)
+	}
+
+	filter := replay.Filter{Path: *pathFilter, Model: *modelFilter, Status: *statusFilter}
+	var requests []replay.Request
+	for _, arg := range flag.Args() {
+		loaded, err := replay.Load(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", arg, err)
+			os.Exit(1)
+		}
+		for _, req := range loaded {
+			if filter.Match(req) {
+				requests = append(requests, req)
+			}
+		}
+	}
+	fmt.Printf("Matched %d requests\n", len(requests))
+
+	replayer := &replay.Replayer{
+		Target: targetURL,
+		Client: &http.Client{Timeout: *timeout},
+		APIKey: *apiKey,
+	}
+
+	changed := 0
+	for _, req := range requests {
+		fmt.Printf("\n%s\n  %s %s model=%s recorded=%d (%d bytes)\n", req.Source, req.Method, req.Path, req.Model, req.Status, len(req.Body))
+		if *dryRun {
+			continue
+		}
+
+		result, err := replayer.Replay(context.Background(), req)
+		if err != nil {
+			fmt.Printf("  error: %v\n", err)
+			changed++
+			continue
+		}
+
+		diffs := replay.DiffBodies(req.Response, result.Body)
+		if result.Status != req.Status {
+			fmt.Printf("  status: %d -> %d\n", req.Status, result.Status)
+		} else {
+			fmt.Printf("  status: %d (unchanged)\n", result.Status)
+		}
+		for _, d := range diffs {
+			fmt.Printf("  %s\n", d)
+		}
+		if result.Status != req.Status || len(diffs) > 0 {
+			changed++
+		}
+	}
+
+	if !*dryRun {
+		fmt.Printf("\n%d of %d responses differ from the recording\n", changed, len(requests))
+	}
+	if changed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"os"
+
+	"github.com/theadriann/vibeproxyplus/internal/cli"
+)
+
+// update-cliproxy is `vibeproxy update`, kept for existing scripts.
+func main() {
+	os.Exit(cli.Command("update-cliproxy", "update", os.Args[1:]))
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"os"
+
+	"github.com/theadriann/vibeproxyplus/internal/cli"
+)
+
+func main() {
+	os.Exit(cli.Main(os.Args[1:]))
+}
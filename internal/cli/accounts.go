@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/theadriann/vibeproxyplus/internal/credentials"
+	"github.com/theadriann/vibeproxyplus/internal/modelsync"
+)
+
+// runAccounts lists the credentials in the backend's auth-dir per provider.
+func runAccounts(env *Env, fs *flag.FlagSet, args []string) error {
+	authDir := fs.String("auth-dir", env.AuthDir, "Backend credential directory")
+	modelsFile := fs.String("models", env.Path("config", "models.json"), "Canonical models.json, for the models each account unlocks")
+	listModels := fs.Bool("list-models", false, "List the models of each account, not just the count")
+	asJSON := fs.Bool("json", false, "Print the accounts as JSON")
+	fs.Parse(args)
+
+	models, err := modelsync.LoadModelIDs(*modelsFile)
+	if err != nil {
+		models = nil
+	}
+	now := time.Now()
+	accounts, err := credentials.Inspect(*authDir, models, now)
+	if err != nil {
+		return fmt.Errorf("read auth-dir: %w", err)
+	}
+
+	if *asJSON {
+		if accounts == nil {
+			accounts = []credentials.Account{}
+		}
+		out, _ := json.MarshalIndent(accounts, "", "  ")
+		fmt.Println(string(out))
+		return nil
+	}
+
+	if len(accounts) == 0 {
+		fmt.Printf("No credentials in %s; log in with 'vibeproxy auth <provider>'\n", *authDir)
+		return nil
+	}
+	provider := ""
+	for _, a := range accounts {
+		if a.Provider != provider {
+			provider = a.Provider
+			fmt.Printf("%s\n", provider)
+		}
+		fmt.Printf("  %s: %s\n", a.Account, describeAccount(a, models != nil, now))
+		if *listModels && len(a.Models) > 0 {
+			fmt.Printf("    %s\n", strings.Join(a.Models, ", "))
+		}
+	}
+	return nil
+}
+
+func describeAccount(a credentials.Account, haveModels bool, now time.Time) string {
+	parts := []string{a.State}
+	if a.Error != "" {
+		parts = append(parts, a.Error)
+	}
+	if a.Expires != nil {
+		if a.Expires.After(now) {
+			parts = append(parts, fmt.Sprintf("expires %s (in %s)", a.Expires.Local().Format("2006-01-02 15:04"), a.Expires.Sub(now).Round(time.Minute)))
+		} else {
+			parts = append(parts, fmt.Sprintf("expired %s", a.Expires.Local().Format("2006-01-02 15:04")))
+		}
+	}
+	if a.Refreshable {
+		parts = append(parts, "refreshable")
+	} else if a.State != credentials.StateUnreadable {
+		parts = append(parts, "no refresh token")
+	}
+	if a.LastRefresh != nil {
+		parts = append(parts, "last refresh "+a.LastRefresh.Local().Format("2006-01-02 15:04"))
+	}
+	if haveModels {
+		parts = append(parts, fmt.Sprintf("%d models", len(a.Models)))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// loginFlags maps each provider to the backend flag that starts its login
+// flow.
+var loginFlags = map[string]string{
+	"claude":      "-claude-login",
+	"codex":       "-codex-login",
+	"gemini":      "-login",
+	"antigravity": "-antigravity-login",
+	"copilot":     "-github-copilot-login",
+}
+
+func providerNames() string {
+	names := make([]string, 0, len(loginFlags))
+	for name := range loginFlags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// runAuth runs the backend's login flow for a provider in the foreground.
+// Arguments after the provider go to the backend unchanged.
+func runAuth(env *Env, fs *flag.FlagSet, args []string) error {
+	usage := fs.Usage
+	fs.Usage = func() {
+		usage()
+		fmt.Fprintf(fs.Output(), "\nProviders: %s\n", providerNames())
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("missing provider")
+	}
+
+	provider := fs.Arg(0)
+	login, ok := loginFlags[provider]
+	if !ok {
+		return fmt.Errorf("unknown provider %q (want one of: %s)", provider, providerNames())
+	}
+	if _, err := os.Stat(env.BackendBinary()); err != nil {
+		return fmt.Errorf("%s not found; run 'vibeproxy update' first", env.BackendBinary())
+	}
+
+	cmd := exec.Command(env.BackendBinary(), append([]string{"-config", env.CLIProxyConfig(), login}, fs.Args()[1:]...)...)
+	cmd.Dir = env.Home
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
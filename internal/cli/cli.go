@@ -0,0 +1,99 @@
+// Package cli implements the vibeproxy command and its subcommands. The
+// standalone binaries (thinking-proxy, model-sync, update-cliproxy) run the
+// same subcommands.
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// command is a vibeproxy subcommand. run gets a flag set already named and
+// wired for usage; it registers its own flags and parses args.
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(env *Env, fs *flag.FlagSet, args []string) error
+}
+
+var commands = []command{
+	{"run", "[flags]", "Start CLIProxyAPIPlus and ThinkingProxy together", runRun},
+	{"proxy", "[flags]", "Start ThinkingProxy only, in front of a running backend", runProxy},
+	{"sync-models", "[flags]", "Regenerate config/models.json and the client configs", runSyncModels},
+	{"gen-config", "[flags]", "Generate config/cliproxy.yaml from config/vibeproxy.json and the model catalog", runGenConfig},
+	{"auth", "<provider> [backend flags]", "Log in to a provider through CLIProxyAPIPlus", runAuth},
+	{"accounts", "[flags]", "List logged-in accounts, token expiry and the models they unlock", runAccounts},
+	{"update", "[flags]", "Install or update CLIProxyAPIPlus in bin/", runUpdate},
+	{"status", "[flags]", "Show what is installed and running", runStatus},
+	{"doctor", "[flags]", "Check the setup for common problems", runDoctor},
+}
+
+// Main runs vibeproxy with the command line args (without the program
+// name) and returns the exit code.
+func Main(args []string) int {
+	global := flag.NewFlagSet("vibeproxy", flag.ExitOnError)
+	home := global.String("home", "", "Project directory holding bin/ and config/ (default: $"+HomeEnv+" or discovered)")
+	global.Usage = func() { usage(global.Output(), global) }
+	global.Parse(args)
+
+	if global.NArg() == 0 {
+		usage(os.Stderr, global)
+		return 2
+	}
+	name := global.Arg(0)
+	if name == "help" {
+		usage(os.Stdout, global)
+		return 0
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return execute(Discover(*home), "vibeproxy "+cmd.name, cmd, global.Args()[1:])
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+	usage(os.Stderr, global)
+	return 2
+}
+
+// Command runs a single subcommand as program prog, for the standalone
+// binaries.
+func Command(prog, name string, args []string) int {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return execute(Discover(""), prog, cmd, args)
+		}
+	}
+	panic("cli: unknown command " + name)
+}
+
+func execute(env *Env, prog string, cmd command, args []string) int {
+	fs := flag.NewFlagSet(prog, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s\n\n%s.\n", prog, cmd.args, cmd.summary)
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintf(fs.Output(), "\nFlags:\n")
+			fs.PrintDefaults()
+		}
+	}
+	if err := cmd.run(env, fs, args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer, global *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage: vibeproxy [-home dir] <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-12s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nGlobal flags:\n")
+	global.SetOutput(w)
+	global.PrintDefaults()
+	fmt.Fprintf(w, "\nRun 'vibeproxy <command> -h' for a command's flags.\n")
+}
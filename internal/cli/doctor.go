@@ -0,0 +1,357 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/theadriann/vibeproxyplus/internal/project"
+)
+
+type checkStatus string
+
+const (
+	checkPass checkStatus = "pass"
+	checkWarn checkStatus = "warn"
+	checkFail checkStatus = "fail"
+)
+
+// defaultModelsMaxAge is how old config/models.json may get before doctor
+// suggests a sync.
+const defaultModelsMaxAge = 14 * 24 * time.Hour
+
+// checkResult is one doctor finding. Hint says how to fix a warn or fail.
+type checkResult struct {
+	Name   string      `json:"name"`
+	Status checkStatus `json:"status"`
+	Detail string      `json:"detail"`
+	Hint   string      `json:"hint,omitempty"`
+}
+
+func pass(name, detail string) checkResult {
+	return checkResult{Name: name, Status: checkPass, Detail: detail}
+}
+
+func warn(name, detail, hint string) checkResult {
+	return checkResult{Name: name, Status: checkWarn, Detail: detail, Hint: hint}
+}
+
+func fail(name, detail, hint string) checkResult {
+	return checkResult{Name: name, Status: checkFail, Detail: detail, Hint: hint}
+}
+
+// doctor holds what the checks need beyond env.
+type doctor struct {
+	env       *Env
+	proxyPort int
+	// clientBaseURL and providerURLs are vibeproxy.json's "clients" URLs,
+	// which Factory may use besides localhost:proxyPort.
+	clientBaseURL   string
+	providerURLs    []string
+	factorySettings string
+	modelsMaxAge    time.Duration
+	now             time.Time
+}
+
+// checks runs every check in order.
+func (d *doctor) checks() []checkResult {
+	return []checkResult{
+		d.checkBackendBinary(),
+		d.checkCLIProxyConfig(),
+		d.checkCLIProxyDrift(),
+		d.checkPort("backend port", d.env.BackendPort, isBackend, "CLIProxyAPIPlus", "set port in config/cliproxy.yaml"),
+		d.checkPort("proxy port", d.proxyPort, isProxy, "ThinkingProxy", "pass -port to vibeproxy run"),
+		d.checkAuthDir(),
+		d.checkModelsJSON(),
+		d.checkFactorySettings(),
+		d.checkBackendModels(),
+		d.checkProxyHealth(),
+	}
+}
+
+func runDoctor(env *Env, fs *flag.FlagSet, args []string) error {
+	d := &doctor{env: env, now: time.Now()}
+	fs.IntVar(&d.proxyPort, "port", env.ProxyPort, "ThinkingProxy port")
+	fs.StringVar(&d.factorySettings, "factory-settings", defaultFactorySettings(), "Factory CLI settings.json to check")
+	fs.DurationVar(&d.modelsMaxAge, "models-max-age", defaultModelsMaxAge, "Warn when config/models.json is older than this")
+	asJSON := fs.Bool("json", false, "Print the results as JSON")
+	fs.Parse(args)
+	if cfg, err := project.Load(env.ProjectConfig()); err == nil {
+		d.clientBaseURL = cfg.Clients.BaseURL
+		for _, u := range cfg.Clients.ProviderURLs {
+			d.providerURLs = append(d.providerURLs, u)
+		}
+	}
+
+	results := d.checks()
+	failed := 0
+	for _, r := range results {
+		if r.Status == checkFail {
+			failed++
+		}
+	}
+
+	if *asJSON {
+		out, _ := json.MarshalIndent(struct {
+			OK     bool          `json:"ok"`
+			Checks []checkResult `json:"checks"`
+		}{failed == 0, results}, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		for _, r := range results {
+			fmt.Printf("[%s] %s: %s\n", r.Status, r.Name, r.Detail)
+			if r.Hint != "" {
+				fmt.Printf("       fix: %s\n", r.Hint)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+func (d *doctor) checkBackendBinary() checkResult {
+	const name = "backend binary"
+	info, err := os.Stat(d.env.BackendBinary())
+	if err != nil {
+		return fail(name, d.env.BackendBinary()+" is missing", "run 'vibeproxy update'")
+	}
+	if runtime.GOOS != "windows" && info.Mode()&0o111 == 0 {
+		return fail(name, d.env.BackendBinary()+" is not executable", "chmod +x "+d.env.BackendBinary())
+	}
+	return pass(name, d.env.BackendBinary())
+}
+
+func (d *doctor) checkCLIProxyConfig() checkResult {
+	const name = "backend config"
+	if _, err := os.Stat(d.env.CLIProxyConfig()); err != nil {
+		return fail(name, d.env.CLIProxyConfig()+" is missing", "run vibeproxy from the project directory or pass -home")
+	}
+	return pass(name, fmt.Sprintf("%s (port %d)", d.env.CLIProxyConfig(), d.env.BackendPort))
+}
+
+func (d *doctor) checkCLIProxyDrift() checkResult {
+	const name = "backend config drift"
+	drift, err := cliproxyDrift(d.env)
+	if err != nil {
+		return warn(name, fmt.Sprintf("cannot compare with the generated config: %v", err), "create config/vibeproxy.json and run 'vibeproxy gen-config'")
+	}
+	if len(drift) > 0 {
+		return warn(name, fmt.Sprintf("%s differs from the generated config in %d line(s)", d.env.CLIProxyConfig(), len(drift)), "move the change into config/vibeproxy.json, then run 'vibeproxy gen-config'")
+	}
+	return pass(name, "matches config/vibeproxy.json")
+}
+
+// checkPort passes when port is free or already served by the expected
+// service, recognised by owner.
+func (d *doctor) checkPort(name string, port int, owner func(baseURL string) bool, service, hint string) checkResult {
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+	if l, err := net.Listen("tcp", addr); err == nil {
+		l.Close()
+		return pass(name, fmt.Sprintf(":%d is free", port))
+	}
+	if owner("http://" + addr) {
+		return pass(name, fmt.Sprintf(":%d is in use by %s", port, service))
+	}
+	return fail(name, fmt.Sprintf(":%d is in use by another process", port), "stop that process or "+hint)
+}
+
+func isBackend(baseURL string) bool {
+	_, err := listModels(baseURL)
+	return err == nil
+}
+
+func isProxy(baseURL string) bool {
+	resp, err := probeClient.Get(baseURL + "/health")
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	var health struct {
+		Status string `json:"status"`
+	}
+	return json.NewDecoder(resp.Body).Decode(&health) == nil && health.Status != ""
+}
+
+func (d *doctor) checkAuthDir() checkResult {
+	const name = "auth-dir"
+	hint := "log in with 'vibeproxy auth <provider>' (" + providerNames() + ")"
+	entries, err := os.ReadDir(d.env.AuthDir)
+	if err != nil {
+		return fail(name, d.env.AuthDir+" does not exist", hint)
+	}
+	count := 0
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			count++
+		}
+	}
+	if count == 0 {
+		return fail(name, d.env.AuthDir+" holds no credentials", hint)
+	}
+	return pass(name, fmt.Sprintf("%s holds %d credential file(s)", d.env.AuthDir, count))
+}
+
+func (d *doctor) checkModelsJSON() checkResult {
+	const name = "models.json"
+	path := d.env.Path("config", "models.json")
+	info, err := os.Stat(path)
+	if err != nil {
+		return fail(name, path+" is missing", "run 'vibeproxy sync-models'")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !json.Valid(data) {
+		return fail(name, path+" is not valid JSON", "run 'vibeproxy sync-models'")
+	}
+	age := d.now.Sub(info.ModTime())
+	if age > d.modelsMaxAge {
+		return warn(name, fmt.Sprintf("%s was synced %d days ago", path, int(age.Hours()/24)), "run 'vibeproxy sync-models'")
+	}
+	return pass(name, fmt.Sprintf("%s synced %s", path, info.ModTime().Format("2006-01-02")))
+}
+
+func defaultFactorySettings() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".factory", "settings.json")
+}
+
+// checkFactorySettings checks that Factory's local custom models go
+// through ThinkingProxy rather than another port, such as the backend's.
+// Models on a host the project's client URLs name count as local too.
+func (d *doctor) checkFactorySettings() checkResult {
+	const name = "factory settings"
+	hint := "copy config/factory-config.json to " + d.factorySettings + " or merge its customModels"
+	data, err := os.ReadFile(d.factorySettings)
+	if err != nil {
+		return warn(name, d.factorySettings+" not found", hint)
+	}
+	var settings struct {
+		CustomModels []struct {
+			Model   string `json:"model"`
+			BaseURL string `json:"baseUrl"`
+		} `json:"customModels"`
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fail(name, fmt.Sprintf("%s is not valid JSON: %v", d.factorySettings, err), "fix the file or "+hint)
+	}
+
+	roots := map[string]bool{proxyRoot("http://localhost:" + strconv.Itoa(d.proxyPort)): true}
+	hosts := map[string]bool{"localhost": true}
+	for _, raw := range append([]string{d.clientBaseURL}, d.providerURLs...) {
+		if raw == "" {
+			continue
+		}
+		roots[proxyRoot(raw)] = true
+		if u, err := url.Parse(raw); err == nil {
+			hosts[localHost(u.Hostname())] = true
+		}
+	}
+
+	local, wrong := 0, map[string][]string{}
+	for _, m := range settings.CustomModels {
+		u, err := url.Parse(m.BaseURL)
+		if err != nil || !hosts[localHost(u.Hostname())] {
+			continue
+		}
+		local++
+		if roots[proxyRoot(m.BaseURL)] {
+			continue
+		}
+		where := u.Host
+		if localHost(u.Hostname()) == "localhost" {
+			where = u.Port()
+		}
+		wrong[where] = append(wrong[where], m.Model)
+	}
+	if local == 0 {
+		return warn(name, "no custom models point at ThinkingProxy", hint)
+	}
+	if len(wrong) > 0 {
+		places := make([]string, 0, len(wrong))
+		for where := range wrong {
+			places = append(places, where)
+		}
+		sort.Strings(places)
+		var parts []string
+		for _, where := range places {
+			models := wrong[where]
+			what := where
+			if _, err := strconv.Atoi(where); err == nil {
+				what = "port " + where
+			}
+			if where == strconv.Itoa(d.env.BackendPort) {
+				what += " (CLIProxyAPIPlus, bypassing ThinkingProxy)"
+			}
+			parts = append(parts, fmt.Sprintf("%d model(s) use %s, e.g. %s", len(models), what, models[0]))
+		}
+		return fail(name, strings.Join(parts, "; "), fmt.Sprintf("set baseUrl to %s/v1, or regenerate with 'vibeproxy sync-models'", d.factoryBaseURL()))
+	}
+	return pass(name, fmt.Sprintf("%d custom model(s) use ThinkingProxy", local))
+}
+
+// factoryBaseURL is where the generated Factory config points.
+func (d *doctor) factoryBaseURL() string {
+	cfg := project.Config{Proxy: project.ProxyConfig{Port: d.proxyPort}}
+	cfg.Clients.BaseURL = d.clientBaseURL
+	return strings.TrimRight(cfg.ClientBaseURL(), "/")
+}
+
+// proxyRoot normalizes a client base URL to the proxy root it reaches, so
+// "http://127.0.0.1:8317/v1/" and "http://localhost:8317" compare equal.
+func proxyRoot(raw string) string {
+	u, err := url.Parse(strings.TrimRight(raw, "/"))
+	if err != nil {
+		return raw
+	}
+	host := localHost(u.Hostname())
+	if port := u.Port(); port != "" {
+		host += ":" + port
+	}
+	return u.Scheme + "://" + host + strings.TrimSuffix(u.Path, "/v1")
+}
+
+func localHost(host string) string {
+	if host == "127.0.0.1" || host == "::1" {
+		return "localhost"
+	}
+	return host
+}
+
+func (d *doctor) checkBackendModels() checkResult {
+	const name = "backend /v1/models"
+	models, err := listModels(fmt.Sprintf("http://127.0.0.1:%d", d.env.BackendPort))
+	if err != nil {
+		return fail(name, fmt.Sprintf("CLIProxyAPIPlus on :%d: %v", d.env.BackendPort, err), "start it with 'vibeproxy run'")
+	}
+	if len(models) == 0 {
+		return warn(name, "CLIProxyAPIPlus lists no models", "log in with 'vibeproxy auth <provider>'")
+	}
+	return pass(name, fmt.Sprintf("%d models available", len(models)))
+}
+
+func (d *doctor) checkProxyHealth() checkResult {
+	const name = "proxy /health"
+	resp, err := probeClient.Get(fmt.Sprintf("http://127.0.0.1:%d/health", d.proxyPort))
+	if err != nil {
+		return fail(name, fmt.Sprintf("ThinkingProxy not reachable on :%d", d.proxyPort), "start it with 'vibeproxy run'")
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fail(name, "ThinkingProxy cannot reach CLIProxyAPIPlus", fmt.Sprintf("check that the backend runs on :%d and ThinkingProxy uses -target %d", d.env.BackendPort, d.env.BackendPort))
+	}
+	return pass(name, fmt.Sprintf("ThinkingProxy on :%d is healthy", d.proxyPort))
+}
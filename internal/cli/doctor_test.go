@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testDoctor(t *testing.T) *doctor {
+	t.Helper()
+	home := t.TempDir()
+	os.MkdirAll(filepath.Join(home, "config"), 0o755)
+	return &doctor{
+		env:             &Env{Home: home, BackendPort: DefaultBackendPort, AuthDir: filepath.Join(home, "auth")},
+		proxyPort:       DefaultProxyPort,
+		factorySettings: filepath.Join(home, "settings.json"),
+		modelsMaxAge:    defaultModelsMaxAge,
+		now:             time.Now(),
+	}
+}
+
+func serverPort(t *testing.T, srv *httptest.Server) int {
+	t.Helper()
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(srv.URL, "http://"))
+	n, _ := strconv.Atoi(port)
+	return n
+}
+
+func TestDoctor_FactorySettings(t *testing.T) {
+	tests := []struct {
+		name         string
+		settings     string
+		clientBase   string
+		providerURLs []string
+		wantStatus   checkStatus
+		wantDetail   string
+	}{
+		{"missing", "", "", nil, checkWarn, "not found"},
+		{"invalid", "{", "", nil, checkFail, "not valid JSON"},
+		{"no local models", `{"customModels":[{"model":"a","baseUrl":"https://api.example.com/v1"}]}`, "", nil, checkWarn, "no custom models"},
+		{"proxy port", `{"customModels":[{"model":"a","baseUrl":"http://localhost:8317/v1"},{"model":"b","baseUrl":"http://127.0.0.1:8317/v1"}]}`, "", nil, checkPass, "2 custom model(s)"},
+		{"backend port", `{"customModels":[{"model":"a","baseUrl":"http://localhost:8318/v1"}]}`, "", nil, checkFail, "bypassing ThinkingProxy"},
+		{"other port", `{"customModels":[{"model":"a","baseUrl":"http://localhost:8317/v1"},{"model":"b","baseUrl":"http://localhost:9000/v1"}]}`, "", nil, checkFail, "port 9000, e.g. b"},
+		{"client base URL", `{"customModels":[{"model":"a","baseUrl":"http://proxy.lan:8317/v1"}]}`, "http://proxy.lan:8317", nil, checkPass, "1 custom model(s)"},
+		{"provider URL", `{"customModels":[{"model":"a","baseUrl":"http://localhost:8317/v1"},{"model":"b","baseUrl":"https://gw.example.com/kiro/v1"}]}`, "", []string{"https://gw.example.com/kiro"}, checkPass, "2 custom model(s)"},
+		{"client host, wrong port", `{"customModels":[{"model":"a","baseUrl":"http://proxy.lan:8318/v1"}]}`, "http://proxy.lan:8317", nil, checkFail, "proxy.lan:8318, e.g. a"},
+		{"client base URL, hint", `{"customModels":[{"model":"a","baseUrl":"http://localhost:8318/v1"}]}`, "http://proxy.lan:8317/", nil, checkFail, "bypassing ThinkingProxy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := testDoctor(t)
+			d.clientBaseURL, d.providerURLs = tt.clientBase, tt.providerURLs
+			if tt.settings != "" {
+				if err := os.WriteFile(d.factorySettings, []byte(tt.settings), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			r := d.checkFactorySettings()
+			if r.Status != tt.wantStatus || !strings.Contains(r.Detail, tt.wantDetail) {
+				t.Errorf("got [%s] %s, want [%s] containing %q", r.Status, r.Detail, tt.wantStatus, tt.wantDetail)
+			}
+			if r.Status != checkPass && r.Hint == "" {
+				t.Error("missing fix hint")
+			}
+			if tt.clientBase != "" && r.Status == checkFail && !strings.Contains(r.Hint, "http://proxy.lan:8317/v1") {
+				t.Errorf("hint %q doesn't name the client base URL", r.Hint)
+			}
+		})
+	}
+}
+
+func TestDoctor_ModelsJSON(t *testing.T) {
+	d := testDoctor(t)
+	path := d.env.Path("config", "models.json")
+	if r := d.checkModelsJSON(); r.Status != checkFail {
+		t.Errorf("missing file: got %s", r.Status)
+	}
+
+	os.WriteFile(path, []byte(`{"models":{}}`), 0o644)
+	if r := d.checkModelsJSON(); r.Status != checkPass {
+		t.Errorf("fresh file: got [%s] %s", r.Status, r.Detail)
+	}
+
+	old := d.now.Add(-30 * 24 * time.Hour)
+	os.Chtimes(path, old, old)
+	if r := d.checkModelsJSON(); r.Status != checkWarn || !strings.Contains(r.Detail, "30 days") {
+		t.Errorf("stale file: got [%s] %s", r.Status, r.Detail)
+	}
+}
+
+func TestDoctor_AuthDir(t *testing.T) {
+	d := testDoctor(t)
+	if r := d.checkAuthDir(); r.Status != checkFail {
+		t.Errorf("missing dir: got %s", r.Status)
+	}
+	os.MkdirAll(d.env.AuthDir, 0o700)
+	if r := d.checkAuthDir(); r.Status != checkFail || !strings.Contains(r.Detail, "no credentials") {
+		t.Errorf("empty dir: got [%s] %s", r.Status, r.Detail)
+	}
+	os.WriteFile(filepath.Join(d.env.AuthDir, "claude-me@example.com.json"), []byte(`{}`), 0o600)
+	if r := d.checkAuthDir(); r.Status != checkPass {
+		t.Errorf("with credentials: got [%s] %s", r.Status, r.Detail)
+	}
+}
+
+func TestDoctor_PortsAndBackend(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"id":"claude-sonnet-4-5"},{"id":"gpt-5"}]}`))
+	}))
+	defer backend.Close()
+	other := httptest.NewServer(http.NotFoundHandler())
+	defer other.Close()
+
+	d := testDoctor(t)
+	d.env.BackendPort = serverPort(t, backend)
+
+	if r := d.checkPort("backend port", d.env.BackendPort, isBackend, "CLIProxyAPIPlus", ""); r.Status != checkPass || !strings.Contains(r.Detail, "CLIProxyAPIPlus") {
+		t.Errorf("backend port: got [%s] %s", r.Status, r.Detail)
+	}
+	if r := d.checkPort("proxy port", serverPort(t, other), isProxy, "ThinkingProxy", "pass -port"); r.Status != checkFail || !strings.Contains(r.Hint, "pass -port") {
+		t.Errorf("foreign port: got [%s] %s", r.Status, r.Detail)
+	}
+	if r := d.checkBackendModels(); r.Status != checkPass || !strings.Contains(r.Detail, "2 models") {
+		t.Errorf("backend models: got [%s] %s", r.Status, r.Detail)
+	}
+
+	other.Close()
+	if r := d.checkPort("proxy port", serverPort(t, other), isProxy, "ThinkingProxy", ""); r.Status != checkPass || !strings.Contains(r.Detail, "free") {
+		t.Errorf("free port: got [%s] %s", r.Status, r.Detail)
+	}
+}
@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/theadriann/vibeproxyplus/internal/project"
+)
+
+// HomeEnv names the environment variable that points at the project
+// directory, overriding discovery.
+const HomeEnv = "VIBEPROXY_HOME"
+
+const (
+	DefaultProxyPort   = 8317
+	DefaultBackendPort = 8318
+	defaultAuthDir     = "~/.cli-proxy-api"
+)
+
+// Env is the project layout every command works against: bin/ holds the
+// binaries and config/ the backend config and generated model configs.
+type Env struct {
+	Home string
+
+	// ProxyPort comes from config/vibeproxy.json.
+	ProxyPort int
+
+	// Settings read from config/cliproxy.yaml.
+	BackendPort int
+	AuthDir     string
+}
+
+// Discover locates the project directory: home if set, then $VIBEPROXY_HOME,
+// then the nearest parent of the working directory holding
+// config/cliproxy.yaml, then the parent of the bin/ directory this binary
+// runs from. It falls back to the working directory.
+func Discover(home string) *Env {
+	if home == "" {
+		home = os.Getenv(HomeEnv)
+	}
+	if home == "" {
+		home = findHome()
+	}
+	if abs, err := filepath.Abs(home); err == nil {
+		home = abs
+	}
+
+	env := &Env{Home: home, ProxyPort: DefaultProxyPort, BackendPort: DefaultBackendPort, AuthDir: expandHome(defaultAuthDir)}
+	if cfg, err := project.Load(env.ProjectConfig()); err == nil && cfg.Proxy.Port != 0 {
+		env.ProxyPort = cfg.Proxy.Port
+	}
+	env.readCLIProxyConfig()
+	return env
+}
+
+func findHome() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "."
+	}
+	for dir := wd; ; dir = filepath.Dir(dir) {
+		if isHome(dir) {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	if exe, err := os.Executable(); err == nil {
+		if dir := filepath.Dir(filepath.Dir(exe)); isHome(dir) {
+			return dir
+		}
+	}
+	return wd
+}
+
+func isHome(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, "config", "cliproxy.yaml"))
+	return err == nil
+}
+
+// Path joins elem onto the project directory.
+func (e *Env) Path(elem ...string) string {
+	return filepath.Join(append([]string{e.Home}, elem...)...)
+}
+
+// ProjectConfig is the file cliproxy.yaml is generated from.
+func (e *Env) ProjectConfig() string {
+	return e.Path("config", "vibeproxy.json")
+}
+
+// CLIProxyConfig is the backend's config file.
+func (e *Env) CLIProxyConfig() string {
+	return e.Path("config", "cliproxy.yaml")
+}
+
+func (e *Env) BinDir() string {
+	return e.Path("bin")
+}
+
+// BackendBinary is the installed cli-proxy-api-plus.
+func (e *Env) BackendBinary() string {
+	name := "cli-proxy-api-plus"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return e.Path("bin", name)
+}
+
+// readCLIProxyConfig picks the settings the commands need out of the
+// backend config. Only top-level scalar keys are read, which is all of
+// cliproxy.yaml that matters here.
+func (e *Env) readCLIProxyConfig() {
+	f, err := os.Open(e.CLIProxyConfig())
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := yamlScalar(scanner.Text())
+		if !ok {
+			continue
+		}
+		switch key {
+		case "port":
+			if port, err := strconv.Atoi(value); err == nil {
+				e.BackendPort = port
+			}
+		case "auth-dir":
+			e.AuthDir = expandHome(value)
+		}
+	}
+}
+
+// yamlScalar parses a top-level "key: value" line, unquoting the value and
+// dropping comments.
+func yamlScalar(line string) (key, value string, ok bool) {
+	if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' {
+		return "", "", false
+	}
+	key, value, ok = strings.Cut(line, ":")
+	if !ok {
+		return "", "", false
+	}
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return strings.TrimSpace(key), value[1 : end+1], true
+		}
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return strings.TrimSpace(key), value, true
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
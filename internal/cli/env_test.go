@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestYAMLScalar(t *testing.T) {
+	tests := []struct {
+		line      string
+		wantKey   string
+		wantValue string
+		wantOK    bool
+	}{
+		{"port: 8318", "port", "8318", true},
+		{`auth-dir: "~/.cli-proxy-api"`, "auth-dir", "~/.cli-proxy-api", true},
+		{"auth-dir: '/tmp/a # b' # comment", "auth-dir", "/tmp/a # b", true},
+		{"request-timeout: 10m # per request", "request-timeout", "10m", true},
+		{"  - name: nested", "", "", false},
+		{"# port: 1", "", "", false},
+		{"quota-exceeded:", "quota-exceeded", "", true},
+	}
+	for _, tt := range tests {
+		key, value, ok := yamlScalar(tt.line)
+		if key != tt.wantKey || value != tt.wantValue || ok != tt.wantOK {
+			t.Errorf("yamlScalar(%q) = %q, %q, %v; want %q, %q, %v", tt.line, key, value, ok, tt.wantKey, tt.wantValue, tt.wantOK)
+		}
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	home := t.TempDir()
+	os.MkdirAll(filepath.Join(home, "config"), 0o755)
+	os.WriteFile(filepath.Join(home, "config", "cliproxy.yaml"), []byte("host: 127.0.0.1\nport: 9318\nauth-dir: /var/auth\noauth-model-alias:\n  port: 1\n"), 0o644)
+	os.WriteFile(filepath.Join(home, "config", "vibeproxy.json"), []byte(`{"proxy":{"port":9317}}`), 0o644)
+	sub := filepath.Join(home, "a", "b")
+	os.MkdirAll(sub, 0o755)
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(sub); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(HomeEnv, "")
+
+	env := Discover("")
+	if want, _ := filepath.EvalSymlinks(home); env.Home != home && env.Home != want {
+		t.Errorf("Home = %s, want %s", env.Home, home)
+	}
+	if env.ProxyPort != 9317 || env.BackendPort != 9318 || env.AuthDir != "/var/auth" {
+		t.Errorf("settings = proxy %d, port %d, auth-dir %s", env.ProxyPort, env.BackendPort, env.AuthDir)
+	}
+
+	other := t.TempDir()
+	t.Setenv(HomeEnv, other)
+	if env := Discover(""); env.Home != other || env.ProxyPort != DefaultProxyPort || env.BackendPort != DefaultBackendPort {
+		t.Errorf("with $%s: Home = %s, port %d", HomeEnv, env.Home, env.BackendPort)
+	}
+	if env := Discover(home); env.Home != home {
+		t.Errorf("explicit home: Home = %s", env.Home)
+	}
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/theadriann/vibeproxyplus/internal/modelsync"
+	"github.com/theadriann/vibeproxyplus/internal/project"
+)
+
+// generateCLIProxyConfig renders cliproxy.yaml from the project config and
+// the model catalog. A missing catalog only costs the alias check, so it is
+// a warning.
+func generateCLIProxyConfig(projectFile, modelsFile string) ([]byte, []string, error) {
+	cfg, err := project.Load(projectFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	catalog, err := modelsync.LoadModelIDs(modelsFile)
+	var warnings []string
+	if err != nil {
+		catalog = nil
+		warnings = append(warnings, fmt.Sprintf("model catalog unavailable, aliases not checked: %v", err))
+	}
+	data, aliasWarnings := cfg.CLIProxyYAML(catalog)
+	return data, append(warnings, aliasWarnings...), nil
+}
+
+// cliproxyDrift compares the backend config on disk with what would be
+// generated.
+func cliproxyDrift(env *Env) ([]string, error) {
+	generated, _, err := generateCLIProxyConfig(env.ProjectConfig(), env.Path("config", "models.json"))
+	if err != nil {
+		return nil, err
+	}
+	existing, err := os.ReadFile(env.CLIProxyConfig())
+	if err != nil {
+		return nil, err
+	}
+	return project.Drift(existing, generated), nil
+}
+
+func runGenConfig(env *Env, fs *flag.FlagSet, args []string) error {
+	projectFile := fs.String("project", env.ProjectConfig(), "Project config to generate from")
+	modelsFile := fs.String("models", env.Path("config", "models.json"), "Canonical models.json, for model aliases")
+	output := fs.String("output", env.CLIProxyConfig(), "CLIProxyAPIPlus config file to write")
+	check := fs.Bool("check", false, "Only report drift; exit non-zero when the file differs")
+	fs.Parse(args)
+
+	generated, warnings, err := generateCLIProxyConfig(*projectFile, *modelsFile)
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+
+	existing, err := os.ReadFile(*output)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	drift := project.Drift(existing, generated)
+	if *check {
+		if existing == nil {
+			return fmt.Errorf("%s does not exist", *output)
+		}
+		if len(drift) > 0 {
+			printDrift(*output, drift)
+			return fmt.Errorf("%s has drifted from %s", *output, *projectFile)
+		}
+		fmt.Printf("%s is up to date\n", *output)
+		return nil
+	}
+
+	if existing != nil && len(drift) > 0 {
+		printDrift(*output, drift)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(*output), ".cliproxy-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(generated); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), *output); err != nil {
+		return err
+	}
+	fmt.Printf("Written %s\n", *output)
+	return nil
+}
+
+func printDrift(path string, drift []string) {
+	fmt.Fprintf(os.Stderr, "Warning: %s differs from the generated config:\n", path)
+	for _, line := range drift {
+		fmt.Fprintf(os.Stderr, "  %s\n", line)
+	}
+}
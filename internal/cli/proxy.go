@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/theadriann/vibeproxyplus/internal/project"
+	"github.com/theadriann/vibeproxyplus/internal/proxy"
+	"github.com/theadriann/vibeproxyplus/internal/supervisor"
+	"github.com/theadriann/vibeproxyplus/internal/thinking"
+	"github.com/theadriann/vibeproxyplus/internal/translate"
+)
+
+// runRun starts ThinkingProxy with the installed backend under supervision.
+func runRun(env *Env, fs *flag.FlagSet, args []string) error {
+	if _, err := os.Stat(env.BackendBinary()); err != nil {
+		return fmt.Errorf("%s not found; run 'vibeproxy update' first", env.BackendBinary())
+	}
+	return serveProxy(env, fs, args, env.BackendBinary())
+}
+
+// runProxy starts ThinkingProxy alone unless -backend is given.
+func runProxy(env *Env, fs *flag.FlagSet, args []string) error {
+	return serveProxy(env, fs, args, "")
+}
+
+func serveProxy(env *Env, fs *flag.FlagSet, args []string, defaultBackend string) error {
+	listenPort := fs.Int("port", env.ProxyPort, "Port to listen on")
+	targetPort := fs.Int("target", env.BackendPort, "CLIProxyAPIPlus port to forward to")
+	compactModel := fs.String("compact-model", "", "Model used to emulate /v1/responses/compact (default: request model)")
+	compactProviders := fs.String("compact-providers", strings.Join(proxy.DefaultCompactProviders, ","), "Providers (owned_by in /v1/models) with native /v1/responses/compact")
+	translateProtocols := fs.Bool("translate", false, "Translate between Anthropic, Chat Completions and Responses protocols")
+	reasoning := fs.String("reasoning", "passthrough", "How thinking reaches clients: passthrough, strip, reasoning_content or think-tags")
+	reasoningClients := fs.String("reasoning-clients", "", "Per-client reasoning modes by User-Agent substring, e.g. opencode=think-tags,factory-cli=strip")
+	keepAlive := fs.Duration("keepalive", 15*time.Second, "Inject a keep-alive into SSE streams idle this long (0 disables)")
+	backend := fs.String("backend", defaultBackend, "Path to cli-proxy-api-plus to launch and supervise (empty: expect it running)")
+	backendConfig := fs.String("backend-config", env.CLIProxyConfig(), "Config file passed to the supervised backend")
+	drain := fs.Duration("drain", 30*time.Second, "On shutdown, let in-flight requests and streams finish for up to this long")
+	fs.Parse(args)
+
+	reasoningMode, err := translate.ParseReasoningMode(*reasoning)
+	if err != nil {
+		return fmt.Errorf("invalid -reasoning: %w", err)
+	}
+	rules, err := proxy.ParseReasoningRules(*reasoningClients)
+	if err != nil {
+		return fmt.Errorf("invalid -reasoning-clients: %w", err)
+	}
+	// -thinking-<tier> suffixes use the tiers sync-models generated
+	cfg, err := project.Load(env.ProjectConfig())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := thinking.Validate(cfg.ThinkingTiers); err != nil {
+		return fmt.Errorf("%s: %w", env.ProjectConfig(), err)
+	}
+
+	handler := proxy.NewThinkingProxyWithOptions(*targetPort, proxy.Options{
+		CompactModel:     *compactModel,
+		CompactProviders: splitList(*compactProviders),
+		Translate:        *translateProtocols,
+		Reasoning:        reasoningMode,
+		ReasoningClients: rules,
+		KeepAlive:        *keepAlive,
+		AuthDir:          env.AuthDir,
+		ModelsFile:       env.Path("config", "models.json"),
+		ThinkingTiers:    cfg.ThinkingTiers,
+	})
+
+	sigChan := make(chan os.Signal, 2)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	// Launch the backend first so the proxy never forwards to a dead port
+	var sup *supervisor.Supervisor
+	if *backend != "" {
+		sup = supervisor.New(supervisor.Config{
+			Binary:   *backend,
+			Args:     []string{"-config", *backendConfig},
+			Dir:      env.Home,
+			ReadyURL: fmt.Sprintf("http://127.0.0.1:%d/", *targetPort),
+			Prefix:   "[cliproxy] ",
+		})
+		log.Printf("Starting %s on :%d...", *backend, *targetPort)
+		startCtx, stopStart := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		err := sup.Start(startCtx)
+		stopStart()
+		if err != nil {
+			return fmt.Errorf("backend failed to start: %w", err)
+		}
+	}
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf("127.0.0.1:%d", *listenPort),
+		Handler: handler,
+	}
+
+	// Start server in goroutine
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("ThinkingProxy listening on :%d -> :%d", *listenPort, *targetPort)
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			serveErr <- err
+		}
+	}()
+
+	// Wait for shutdown signal
+	select {
+	case <-sigChan:
+	case err := <-serveErr:
+		if sup != nil {
+			sup.Stop()
+		}
+		return fmt.Errorf("server error: %w", err)
+	}
+
+	// Drain in-flight requests; a second signal ends the drain early
+	log.Printf("Draining for up to %s (signal again to stop now)...", *drain)
+	ctx, cancel := context.WithTimeout(context.Background(), *drain)
+	defer cancel()
+	go func() {
+		select {
+		case <-sigChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	server.SetKeepAlivesEnabled(false)
+	if err := handler.Drain(ctx); err != nil {
+		log.Printf("Drain ended with streams still open: %v", err)
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Shutdown error: %v", err)
+	}
+	if sup != nil {
+		sup.Stop()
+	}
+	log.Println("Stopped")
+	return nil
+}
+
+// splitList parses a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	list := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
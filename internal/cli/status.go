@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/theadriann/vibeproxyplus/internal/updater"
+)
+
+// probeClient checks local services, which answer quickly or not at all.
+var probeClient = &http.Client{Timeout: 3 * time.Second}
+
+// listModels fetches a /v1/models listing and returns the model IDs.
+func listModels(baseURL string) ([]string, error) {
+	resp, err := probeClient.Get(baseURL + "/v1/models")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("/v1/models returned %s", resp.Status)
+	}
+	var listing struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return nil, fmt.Errorf("/v1/models: %w", err)
+	}
+	ids := make([]string, 0, len(listing.Data))
+	for _, m := range listing.Data {
+		ids = append(ids, m.ID)
+	}
+	return ids, nil
+}
+
+func runStatus(env *Env, fs *flag.FlagSet, args []string) error {
+	port := fs.Int("port", env.ProxyPort, "ThinkingProxy port")
+	fs.Parse(args)
+
+	version, err := installedVersion(updater.New(updater.Config{Dir: env.BinDir()}))
+	if err != nil {
+		version = err.Error()
+	}
+	fmt.Printf("Home:     %s\n", env.Home)
+	fmt.Printf("Binary:   %s (%s)\n", env.BackendBinary(), version)
+	fmt.Printf("Config:   %s (port %d, auth-dir %s)\n", env.CLIProxyConfig(), env.BackendPort, env.AuthDir)
+
+	backendURL := fmt.Sprintf("http://127.0.0.1:%d", env.BackendPort)
+	if models, err := listModels(backendURL); err != nil {
+		fmt.Printf("CLIProxyAPIPlus: unavailable on :%d (%v)\n", env.BackendPort, err)
+	} else {
+		fmt.Printf("CLIProxyAPIPlus: running on :%d, %d models\n", env.BackendPort, len(models))
+	}
+
+	resp, err := probeClient.Get(fmt.Sprintf("http://127.0.0.1:%d/health", *port))
+	if err != nil {
+		fmt.Printf("ThinkingProxy:   not reachable on :%d\n", *port)
+		return nil
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("ThinkingProxy:   running on :%d, backend unhealthy\n", *port)
+		return nil
+	}
+	fmt.Printf("ThinkingProxy:   running on :%d\n", *port)
+	return nil
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/theadriann/vibeproxyplus/internal/modelsync"
+	"github.com/theadriann/vibeproxyplus/internal/project"
+	"github.com/theadriann/vibeproxyplus/internal/updater"
+)
+
+func runSyncModels(env *Env, fs *flag.FlagSet, args []string) error {
+	// Describe the models the installed backend serves, not upstream main
+	ref := ""
+	u := updater.New(updater.Config{Dir: env.BinDir()})
+	if m, err := u.Installed(); err == nil && m.Current != nil {
+		ref = m.Current.Tag
+		fmt.Printf("Installed CLIProxyAPIPlus: %s\n", m.Current.Version)
+	}
+
+	// Client configs reach the proxy as vibeproxy.json's "clients" says,
+	// with its thinking tiers
+	cfg, err := project.Load(env.ProjectConfig())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	err = modelsync.Run(fs, args, modelsync.Options{
+		Ref:      ref,
+		Output:   env.Path("config", "models.json"),
+		Factory:  env.Path("config", "factory-config.json"),
+		OpenCode: env.Path("config", "opencode-config.json"),
+		// Optional: upstream function → provider key overrides
+		ProviderMap: env.Path("config", "model-providers.json"),
+		Overrides:   env.Path("config", "models.overrides.json"),
+		Endpoints: modelsync.Endpoints{
+			BaseURL:      cfg.ClientBaseURL(),
+			ProviderURLs: cfg.Clients.ProviderURLs,
+			APIKey:       cfg.Clients.APIKey,
+			APIKeyEnv:    cfg.Clients.APIKeyEnv,
+		},
+		Tiers: cfg.ThinkingTiers,
+	})
+	if err != nil {
+		return err
+	}
+
+	// New or removed models can change the backend's aliases
+	if drift, err := cliproxyDrift(env); err == nil && len(drift) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %s is out of date with the new catalog; run 'vibeproxy gen-config'\n", env.CLIProxyConfig())
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/theadriann/vibeproxyplus/internal/updater"
+)
+
+func runUpdate(env *Env, fs *flag.FlagSet, args []string) error {
+	version := fs.String("version", "latest", "Release to install, e.g. 6.6.1-0")
+	dir := fs.String("dir", env.BinDir(), "Directory the binary is installed into")
+	api := fs.String("api", updater.DefaultAPIBase, "GitHub API base URL")
+	repo := fs.String("repo", updater.DefaultRepo, "Repository publishing the releases")
+	force := fs.Bool("force", false, "Reinstall even when the release is already installed")
+	check := fs.Bool("check", false, "Only report whether an update is available")
+	rollback := fs.Bool("rollback", false, "Restore the version replaced by the last update")
+	skipChecksum := fs.Bool("skip-checksum", false, "Install releases that publish no checksums.txt")
+	fs.Parse(args)
+
+	u := updater.New(updater.Config{
+		APIBase:      *api,
+		Repo:         *repo,
+		Version:      *version,
+		Dir:          *dir,
+		SkipChecksum: *skipChecksum,
+	})
+
+	if *rollback {
+		restored, err := u.Rollback()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rolled back to %s\n", restored.Version)
+		return nil
+	}
+
+	current, err := installedVersion(u)
+	if err != nil {
+		return err
+	}
+	rel, err := u.Resolve()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Installed: %s, release: %s\n", current, rel.Tag)
+	if u.UpToDate(rel) && !*force {
+		fmt.Println("Already up to date.")
+		return nil
+	}
+	if *check {
+		fmt.Println("Update available.")
+		return nil
+	}
+
+	fmt.Printf("Downloading %s...\n", u.AssetName(rel.Tag))
+	installed, err := u.Install(rel)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Installed %s at %s (sha256 %s)\n", installed.Version, u.BinaryPath(), installed.SHA256)
+	return nil
+}
+
+// installedVersion describes the installed backend for humans: its
+// manifest version, "unknown" for a binary installed by hand, or "not
+// installed".
+func installedVersion(u *updater.Updater) (string, error) {
+	m, err := u.Installed()
+	if err != nil {
+		return "", err
+	}
+	if m.Current != nil {
+		return m.Current.Version, nil
+	}
+	if _, err := os.Stat(u.BinaryPath()); err == nil {
+		return "unknown", nil
+	}
+	return "not installed", nil
+}
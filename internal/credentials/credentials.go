@@ -0,0 +1,182 @@
+// Package credentials inspects the OAuth credential files CLIProxyAPIPlus
+// keeps in its auth-dir. Only identifying and timing fields are read; token
+// values never leave the files.
+package credentials
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Credential states, from the point of view of the next request.
+const (
+	StateValid      = "valid"
+	StateRefreshDue = "refresh due"
+	StateExpired    = "expired"
+	StateDisabled   = "disabled"
+	StateNoExpiry   = "no expiry"
+	StateUnreadable = "unreadable"
+)
+
+// Account describes one credential file. It deliberately has no field that
+// could hold a token.
+type Account struct {
+	File        string     `json:"file"`
+	Provider    string     `json:"provider"`
+	Account     string     `json:"account"`
+	State       string     `json:"state"`
+	Expires     *time.Time `json:"expires,omitempty"`
+	LastRefresh *time.Time `json:"last_refresh,omitempty"`
+	Refreshable bool       `json:"refreshable"`
+	Models      []string   `json:"models,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// modelProviders maps credential types to their provider key in
+// config/models.json where the two differ: a "gemini" login is the
+// Gemini CLI account.
+var modelProviders = map[string]string{
+	"gemini": "gemini-cli",
+}
+
+// ModelProvider returns the models.json provider key for a credential type.
+func ModelProvider(credentialType string) string {
+	if key, ok := modelProviders[credentialType]; ok {
+		return key
+	}
+	return credentialType
+}
+
+// Inspect reads every credential file in dir, sorted by provider and
+// account. models maps models.json provider keys to model IDs and may be
+// nil.
+func Inspect(dir string, models map[string][]string, now time.Time) ([]Account, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var accounts []Account
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		a := inspectFile(filepath.Join(dir, e.Name()), now)
+		a.Models = models[ModelProvider(a.Provider)]
+		accounts = append(accounts, a)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].Provider != accounts[j].Provider {
+			return accounts[i].Provider < accounts[j].Provider
+		}
+		return accounts[i].Account < accounts[j].Account
+	})
+	return accounts, nil
+}
+
+func inspectFile(path string, now time.Time) Account {
+	name := filepath.Base(path)
+	a := Account{File: name, Provider: providerFromName(name), Account: strings.TrimSuffix(name, ".json")}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		a.State, a.Error = StateUnreadable, err.Error()
+		return a
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		a.State, a.Error = StateUnreadable, "invalid JSON"
+		return a
+	}
+	// Gemini nests its OAuth token
+	token, _ := raw["token"].(map[string]interface{})
+
+	if t := stringField(raw, "type"); t != "" {
+		a.Provider = t
+	}
+	for _, key := range []string{"email", "username", "login", "account", "account_id"} {
+		if v := stringField(raw, key); v != "" {
+			a.Account = v
+			break
+		}
+	}
+	a.Refreshable = stringField(raw, "refresh_token") != "" || stringField(token, "refresh_token") != ""
+	a.LastRefresh = timeField(raw, "last_refresh")
+	a.Expires = expiry(raw, token)
+
+	switch {
+	case raw["disabled"] == true:
+		a.State = StateDisabled
+	case a.Expires == nil:
+		a.State = StateNoExpiry
+	case a.Expires.After(now):
+		a.State = StateValid
+	case a.Refreshable:
+		a.State = StateRefreshDue
+	default:
+		a.State = StateExpired
+	}
+	return a
+}
+
+// expiry finds the access token's expiry in the layouts the backend
+// writes: an "expired" or "expiry" timestamp, or a "timestamp" in
+// milliseconds plus "expires_in" seconds.
+func expiry(raw, token map[string]interface{}) *time.Time {
+	for _, key := range []string{"expired", "expiry", "expires_at"} {
+		if t := timeField(raw, key); t != nil {
+			return t
+		}
+		if t := timeField(token, key); t != nil {
+			return t
+		}
+	}
+	issued, ok1 := raw["timestamp"].(float64)
+	lifetime, ok2 := raw["expires_in"].(float64)
+	if ok1 && ok2 {
+		t := time.UnixMilli(int64(issued)).Add(time.Duration(lifetime) * time.Second)
+		return &t
+	}
+	return nil
+}
+
+// providerFromName guesses the provider from a file name like
+// "claude-me@example.com.json" when the file has no type.
+func providerFromName(name string) string {
+	provider, _, _ := strings.Cut(strings.TrimSuffix(name, ".json"), "-")
+	return provider
+}
+
+func stringField(m map[string]interface{}, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+// timeField parses an RFC 3339 string or a Unix time in seconds or
+// milliseconds.
+func timeField(m map[string]interface{}, key string) *time.Time {
+	var t time.Time
+	switch v := m[key].(type) {
+	case string:
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil
+		}
+		t = parsed
+	case float64:
+		if v > 1e12 {
+			t = time.UnixMilli(int64(v))
+		} else {
+			t = time.Unix(int64(v), 0)
+		}
+	default:
+		return nil
+	}
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,82 @@
+package credentials
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const secret = "SECRET-TOKEN-VALUE"
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.ReplaceAll(body, "$TOKEN", secret)), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestInspect(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	dir := writeFiles(t, map[string]string{
+		"claude-me@example.com.json":  `{"type":"claude","email":"me@example.com","access_token":"$TOKEN","refresh_token":"$TOKEN","last_refresh":"2026-10-18T08:00:00Z","expired":"2026-10-18T16:00:00Z"}`,
+		"codex-old@example.com.json":  `{"type":"codex","email":"old@example.com","access_token":"$TOKEN","refresh_token":"$TOKEN","expired":"2026-10-01T00:00:00Z"}`,
+		"gemini-me@example.com.json":  `{"type":"gemini","email":"me@example.com","token":{"access_token":"$TOKEN","refresh_token":"$TOKEN","expiry":"2026-10-18T13:00:00Z"}}`,
+		"antigravity-a.json":          `{"type":"antigravity","email":"ag@example.com","access_token":"$TOKEN","timestamp":1792317600000,"expires_in":3599}`,
+		"github-copilot-octocat.json": `{"type":"github-copilot","username":"octocat","access_token":"$TOKEN"}`,
+		"qwen-gone.json":              `{"type":"qwen","email":"q@example.com","access_token":"$TOKEN","expired":"2026-10-01T00:00:00Z"}`,
+		"kiro-off.json":               `{"type":"kiro","access_token":"$TOKEN","disabled":true}`,
+		"broken.json":                 `{"access_token":"$TOKEN"`,
+		"notes.txt":                   `ignored`,
+	})
+	models := map[string][]string{
+		"claude":     {"claude-sonnet-4-5"},
+		"gemini-cli": {"gemini-2.5-pro"},
+	}
+
+	accounts, err := Inspect(dir, models, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]struct {
+		provider string
+		account  string
+		state    string
+		models   int
+	}{
+		"claude-me@example.com.json":  {"claude", "me@example.com", StateValid, 1},
+		"codex-old@example.com.json":  {"codex", "old@example.com", StateRefreshDue, 0},
+		"gemini-me@example.com.json":  {"gemini", "me@example.com", StateValid, 1},
+		"antigravity-a.json":          {"antigravity", "ag@example.com", StateExpired, 0},
+		"github-copilot-octocat.json": {"github-copilot", "octocat", StateNoExpiry, 0},
+		"qwen-gone.json":              {"qwen", "q@example.com", StateExpired, 0},
+		"kiro-off.json":               {"kiro", "kiro-off", StateDisabled, 0},
+		"broken.json":                 {"broken", "broken", StateUnreadable, 0},
+	}
+	if len(accounts) != len(want) {
+		t.Fatalf("got %d accounts, want %d", len(accounts), len(want))
+	}
+	for _, a := range accounts {
+		w, ok := want[a.File]
+		if !ok {
+			t.Errorf("unexpected file %s", a.File)
+			continue
+		}
+		if a.Provider != w.provider || a.Account != w.account || a.State != w.state || len(a.Models) != w.models {
+			t.Errorf("%s = %s/%s %q (%d models), want %s/%s %q (%d models)",
+				a.File, a.Provider, a.Account, a.State, len(a.Models), w.provider, w.account, w.state, w.models)
+		}
+	}
+
+	out, _ := json.Marshal(accounts)
+	if strings.Contains(string(out), secret) {
+		t.Fatal("token value leaked into the inspection output")
+	}
+}
@@ -0,0 +1,175 @@
+package errorlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/theadriann/vibeproxyplus/internal/proxy"
+	"github.com/theadriann/vibeproxyplus/internal/translate"
+)
+
+// Summary aggregates error log entries.
+type Summary struct {
+	Total      int
+	ByEndpoint map[string]int
+	ByModel    map[string]int
+	ByStatus   map[int]int
+	ByError    map[string]int
+}
+
+// Count is a key with its number of occurrences.
+type Count struct {
+	Key   string
+	Count int
+}
+
+// Finding is a diagnosis for an entry with a pointer to the likely fix.
+type Finding struct {
+	Code    string
+	Message string
+	Fix     string
+}
+
+func Summarize(entries []*Entry) *Summary {
+	s := &Summary{
+		ByEndpoint: make(map[string]int),
+		ByModel:    make(map[string]int),
+		ByStatus:   make(map[int]int),
+		ByError:    make(map[string]int),
+	}
+	for _, e := range entries {
+		s.Total++
+		s.ByEndpoint[e.Method+" "+e.URL]++
+		model := e.Model()
+		if model == "" {
+			model = "(none)"
+		}
+		s.ByModel[model]++
+		s.ByStatus[e.Response.Status]++
+		s.ByError[ErrorMessage(e)]++
+	}
+	return s
+}
+
+// Sorted returns map counts ordered by count (descending), then key.
+func Sorted(counts map[string]int) []Count {
+	sorted := make([]Count, 0, len(counts))
+	for k, v := range counts {
+		sorted = append(sorted, Count{Key: k, Count: v})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Key < sorted[j].Key
+	})
+	return sorted
+}
+
+// ErrorMessage extracts the error message from the response body, falling
+// back to the upstream API response and finally to the raw body.
+func ErrorMessage(e *Entry) string {
+	for _, body := range [][]byte{e.Response.Body, e.APIResponse.Body} {
+		if msg, ok := translate.ExtractErrorMessage(body); ok {
+			return msg
+		}
+	}
+	raw := strings.TrimSpace(string(e.Response.Body))
+	if len(raw) > 120 {
+		raw = raw[:120] + "..."
+	}
+	if raw == "" {
+		return "(empty response)"
+	}
+	return raw
+}
+
+// Diagnose inspects an entry and points to the likely proxy-side fix.
+func Diagnose(e *Entry) []Finding {
+	var findings []Finding
+
+	var body map[string]interface{}
+	if len(e.RequestBody) > 0 && json.Unmarshal(e.RequestBody, &body) != nil {
+		body = nil
+		findings = append(findings, Finding{
+			Code:    "invalid-json",
+			Message: fmt.Sprintf("the request body sent to %s is not a JSON object", e.URL),
+			Fix:     "ThinkingProxy forwards such bodies unchanged and cannot normalise them; fix the client's request encoding",
+		})
+	}
+	model, _ := body["model"].(string)
+	isResponses := e.URL == "/v1/responses" || e.URL == "/v1/responses/compact"
+
+	if _, ok := body["input"].(string); ok && isResponses {
+		f := Finding{
+			Code:    "responses-string-input",
+			Message: fmt.Sprintf("%s sent a string `input` for %s; the backend requires a list of input items", e.URL, model),
+		}
+		if proxyNormalizesRequest(e.URL, e.RequestBody) {
+			f.Fix = "ThinkingProxy's normalizeResponsesRequest now wraps this input; make sure the client goes through :8317 and replay the request to confirm"
+		} else {
+			f.Fix = "ThinkingProxy's current transform leaves this input unchanged; replay the request and check normalizeResponsesRequest"
+		}
+		findings = append(findings, f)
+	}
+
+	if _, ok := body["messages"]; ok && isResponses {
+		f := Finding{
+			Code:    "responses-chat-messages",
+			Message: fmt.Sprintf("%s received Chat Completions-style `messages`", e.URL),
+			Fix:     "ThinkingProxy's current transform leaves `messages` in place; replay the request and check normalizeResponsesRequest",
+		}
+		if proxyNormalizesRequest(e.URL, e.RequestBody) {
+			f.Fix = "ThinkingProxy's normalizeResponsesRequest now converts `messages` to `input` items; make sure the client goes through :8317"
+		}
+		findings = append(findings, f)
+	}
+
+	msg := strings.ToLower(ErrorMessage(e))
+	switch {
+	case e.Response.Status == 401 || e.Response.Status == 403:
+		findings = append(findings, Finding{
+			Code:    "auth",
+			Message: fmt.Sprintf("upstream rejected credentials for %s", model),
+			Fix:     "re-run the provider login (make auth-<provider>) and check auth-dir in config/cliproxy.yaml",
+		})
+	case e.Response.Status == 429 || strings.Contains(msg, "quota"):
+		findings = append(findings, Finding{
+			Code:    "quota",
+			Message: fmt.Sprintf("rate limit or quota exhausted for %s", model),
+			Fix:     "add another account or adjust quota-exceeded in config/cliproxy.yaml",
+		})
+	case strings.Contains(msg, "model") && (strings.Contains(msg, "not found") || strings.Contains(msg, "unknown") || strings.Contains(msg, "not supported")):
+		findings = append(findings, Finding{
+			Code:    "unknown-model",
+			Message: fmt.Sprintf("backend does not know model %s", model),
+			Fix:     "run make sync-models and check the model is served by a logged-in provider",
+		})
+	case strings.Contains(msg, "budget_tokens") || strings.Contains(msg, "max_tokens"):
+		findings = append(findings, Finding{
+			Code:    "thinking-budget",
+			Message: "thinking budget and max_tokens are inconsistent",
+			Fix:     "check the -thinking-BUDGET suffix handling in TransformRequestBody",
+		})
+	}
+
+	return findings
+}
+
+// proxyNormalizesRequest reports whether the current ThinkingProxy transform
+// turns the request into one with list input and no `messages`.
+func proxyNormalizesRequest(path string, body []byte) bool {
+	out, _, err := proxy.TransformRequestBody(path, body)
+	if err != nil {
+		return false
+	}
+	var data map[string]interface{}
+	if json.Unmarshal(out, &data) != nil {
+		return false
+	}
+	_, isList := data["input"].([]interface{})
+	_, hasMessages := data["messages"]
+	return isList && !hasMessages
+}
@@ -0,0 +1,208 @@
+// Package errorlog parses the error-*.log files CLIProxyAPIPlus writes for
+// failed requests and summarises them.
+package errorlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Section headers in the order CLIProxyAPIPlus writes them.
+const (
+	SectionRequestInfo = "REQUEST INFO"
+	SectionHeaders     = "HEADERS"
+	SectionRequestBody = "REQUEST BODY"
+	SectionAPIResponse = "API RESPONSE"
+	SectionResponse    = "RESPONSE"
+)
+
+// Entry is one parsed error log file.
+type Entry struct {
+	File        string
+	Version     string
+	URL         string
+	Method      string
+	Timestamp   time.Time
+	Headers     http.Header
+	RequestBody []byte
+	APIResponse APIResponse
+	Response    Response
+}
+
+// APIResponse is what the upstream provider returned to CLIProxyAPIPlus.
+type APIResponse struct {
+	Timestamp time.Time
+	Body      []byte
+}
+
+// Response is what CLIProxyAPIPlus returned to the client.
+type Response struct {
+	Status  int
+	Headers http.Header
+	Body    []byte
+}
+
+// Model returns the model named in the request body, if any.
+func (e *Entry) Model() string {
+	var data struct {
+		Model string `json:"model"`
+	}
+	if json.Unmarshal(e.RequestBody, &data) != nil {
+		return ""
+	}
+	return data.Model
+}
+
+// IsErrorLog reports whether data looks like a CLIProxyAPIPlus error log.
+func IsErrorLog(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("=== "+SectionRequestInfo+" ==="))
+}
+
+// Parse parses the contents of a single error log file.
+func Parse(data []byte) (*Entry, error) {
+	if !IsErrorLog(data) {
+		return nil, fmt.Errorf("not an error log: missing %s section", SectionRequestInfo)
+	}
+	sections := splitSections(string(data))
+
+	entry := &Entry{Headers: make(http.Header)}
+	for _, line := range strings.Split(sections[SectionRequestInfo], "\n") {
+		key, value, ok := cutField(line)
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Version":
+			entry.Version = value
+		case "URL":
+			entry.URL = value
+		case "Method":
+			entry.Method = value
+		case "Timestamp":
+			ts, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid timestamp %q: %w", SectionRequestInfo, value, err)
+			}
+			entry.Timestamp = ts
+		}
+	}
+	if entry.URL == "" {
+		return nil, fmt.Errorf("%s: missing URL", SectionRequestInfo)
+	}
+
+	parseHeaders(sections[SectionHeaders], entry.Headers)
+	entry.RequestBody = []byte(strings.TrimSpace(sections[SectionRequestBody]))
+
+	// API RESPONSE starts with a Timestamp line followed by the raw body.
+	apiHead, apiBody, _ := strings.Cut(sections[SectionAPIResponse], "\n")
+	if key, value, ok := cutField(apiHead); ok && key == "Timestamp" {
+		entry.APIResponse.Timestamp, _ = time.Parse(time.RFC3339Nano, value)
+		entry.APIResponse.Body = []byte(strings.TrimSpace(apiBody))
+	} else {
+		entry.APIResponse.Body = []byte(strings.TrimSpace(sections[SectionAPIResponse]))
+	}
+
+	// RESPONSE holds the status line and headers, a blank line, then the body.
+	head, body, _ := strings.Cut(sections[SectionResponse], "\n\n")
+	entry.Response.Headers = make(http.Header)
+	for _, line := range strings.Split(head, "\n") {
+		key, value, ok := cutField(line)
+		if !ok {
+			continue
+		}
+		if key == "Status" {
+			status, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid status %q", SectionResponse, value)
+			}
+			entry.Response.Status = status
+			continue
+		}
+		entry.Response.Headers.Add(key, value)
+	}
+	entry.Response.Body = []byte(strings.TrimSpace(body))
+
+	return entry, nil
+}
+
+// ParseFile parses a single error log file.
+func ParseFile(path string) (*Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	entry.File = path
+	return entry, nil
+}
+
+// ParseDir parses every error-*.log file in dir, sorted by file name.
+func ParseDir(dir string) ([]*Entry, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "error-*.log"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	entries := make([]*Entry, 0, len(paths))
+	for _, path := range paths {
+		entry, err := ParseFile(path)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func splitSections(text string) map[string]string {
+	sections := make(map[string]string)
+	var name string
+	var buf strings.Builder
+
+	flush := func() {
+		if name != "" {
+			sections[name] = strings.Trim(buf.String(), "\n")
+		}
+		buf.Reset()
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(line, "=== ") && strings.HasSuffix(line, " ===") {
+			flush()
+			name = strings.TrimSuffix(strings.TrimPrefix(line, "=== "), " ===")
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	flush()
+	return sections
+}
+
+func parseHeaders(section string, headers http.Header) {
+	for _, line := range strings.Split(section, "\n") {
+		if key, value, ok := cutField(line); ok {
+			headers.Add(key, value)
+		}
+	}
+}
+
+func cutField(line string) (string, string, bool) {
+	key, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", "", false
+	}
+	return strings.TrimSpace(key), strings.TrimSpace(value), true
+}
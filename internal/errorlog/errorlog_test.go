@@ -0,0 +1,103 @@
+package errorlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sampleLogPath is a redacted error log shared with the replay tests.
+var sampleLogPath = filepath.Join("..", "..", "testdata", "error-v1-responses.log")
+
+func parseSample(t *testing.T, model string) *Entry {
+	t.Helper()
+	data, err := os.ReadFile(sampleLogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := Parse(bytes.Replace(data, []byte(`"model":"gpt-5.2-codex"`), []byte(`"model":"`+model+`"`), 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return entry
+}
+
+func TestParse(t *testing.T) {
+	entry := parseSample(t, "gpt-5.2-codex")
+
+	if entry.Version != "6.7.41-0-plus" || entry.URL != "/v1/responses" || entry.Method != "POST" {
+		t.Errorf("unexpected request info: %+v", entry)
+	}
+	if entry.Timestamp.IsZero() || entry.APIResponse.Timestamp.IsZero() {
+		t.Error("timestamps not parsed")
+	}
+	if entry.Headers.Get("Authorization") != "Bearer du...my" {
+		t.Errorf("unexpected headers: %v", entry.Headers)
+	}
+	if entry.Model() != "gpt-5.2-codex" {
+		t.Errorf("model = %q", entry.Model())
+	}
+	if string(entry.APIResponse.Body) != `{"detail":"Input must be a list"}` {
+		t.Errorf("unexpected API response body: %s", entry.APIResponse.Body)
+	}
+	if entry.Response.Status != 400 {
+		t.Errorf("status = %d, want 400", entry.Response.Status)
+	}
+	if entry.Response.Headers.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected response headers: %v", entry.Response.Headers)
+	}
+	if string(entry.Response.Body) != `{"detail":"Input must be a list"}` {
+		t.Errorf("unexpected response body: %s", entry.Response.Body)
+	}
+}
+
+func TestParse_RejectsOtherFormats(t *testing.T) {
+	if _, err := Parse([]byte(`{"model":"x"}`)); err == nil {
+		t.Fatal("expected error for non-log input")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	entries := []*Entry{parseSample(t, "gpt-5.2-codex"), parseSample(t, "gpt-5.2-codex"), parseSample(t, "gpt-5.2")}
+
+	s := Summarize(entries)
+	if s.Total != 3 {
+		t.Errorf("total = %d, want 3", s.Total)
+	}
+	if s.ByEndpoint["POST /v1/responses"] != 3 {
+		t.Errorf("unexpected endpoints: %v", s.ByEndpoint)
+	}
+	if s.ByStatus[400] != 3 || s.ByError["Input must be a list"] != 3 {
+		t.Errorf("unexpected status/errors: %v %v", s.ByStatus, s.ByError)
+	}
+
+	models := Sorted(s.ByModel)
+	if len(models) != 2 || models[0].Key != "gpt-5.2-codex" || models[0].Count != 2 {
+		t.Errorf("unexpected model counts: %v", models)
+	}
+}
+
+func TestDiagnose_StringInput(t *testing.T) {
+	findings := Diagnose(parseSample(t, "gpt-5.2-codex"))
+	if len(findings) != 1 || findings[0].Code != "responses-string-input" {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+	if !strings.Contains(findings[0].Fix, "now wraps") {
+		t.Errorf("expected fix to note the proxy already normalizes codex input: %q", findings[0].Fix)
+	}
+}
+
+func TestDiagnose_InvalidJSON(t *testing.T) {
+	entry := parseSample(t, "gpt-5.2-codex")
+	entry.RequestBody = []byte(`{"model":"gpt-5.2-codex","input":"hi"`)
+
+	findings := Diagnose(entry)
+	if len(findings) != 1 || findings[0].Code != "invalid-json" {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+	if !strings.Contains(findings[0].Fix, "request encoding") {
+		t.Errorf("fix = %q", findings[0].Fix)
+	}
+}
@@ -0,0 +1,675 @@
+package modelsync
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SourceFile is one upstream Go file holding model definitions.
+type SourceFile struct {
+	Name string
+	Data []byte
+}
+
+// definitions is the parsed registry package: its functions and the
+// package-level constants and variables their literals may refer to.
+type definitions struct {
+	fset   *token.FileSet
+	funcs  map[string]*ast.FuncDecl
+	values map[string]ast.Expr
+	// evaluating guards against constants and functions that refer to
+	// themselves.
+	evaluating map[string]bool
+}
+
+// parseDefinitions parses the upstream model definition files. Only syntax
+// is checked here; literals are evaluated as functions are read.
+func parseDefinitions(files []SourceFile) (*definitions, error) {
+	defs := &definitions{
+		fset:       token.NewFileSet(),
+		funcs:      make(map[string]*ast.FuncDecl),
+		values:     make(map[string]ast.Expr),
+		evaluating: make(map[string]bool),
+	}
+	for _, f := range files {
+		file, err := parser.ParseFile(defs.fset, f.Name, f.Data, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if d.Recv == nil && d.Body != nil {
+					defs.funcs[d.Name.Name] = d
+				}
+			case *ast.GenDecl:
+				if d.Tok != token.CONST && d.Tok != token.VAR {
+					continue
+				}
+				for _, spec := range d.Specs {
+					vs := spec.(*ast.ValueSpec)
+					for i, name := range vs.Names {
+						if i < len(vs.Values) {
+							defs.values[name.Name] = vs.Values[i]
+						} else {
+							// iota continuations and zero values
+							defs.values[name.Name] = nil
+						}
+					}
+				}
+			}
+		}
+	}
+	return defs, nil
+}
+
+func (d *definitions) errorf(node ast.Node, format string, args ...interface{}) error {
+	return fmt.Errorf("%s: %s", d.fset.Position(node.Pos()), fmt.Sprintf(format, args...))
+}
+
+// models evaluates a `func X() []*ModelInfo`.
+func (d *definitions) models(funcName, provider string) ([]Model, error) {
+	lits, err := d.modelLiterals(d.funcs[funcName])
+	if err != nil {
+		return nil, err
+	}
+	models := make([]Model, 0, len(lits))
+	for _, lit := range lits {
+		m, err := d.decodeModel(lit, provider, "upstream:"+funcName)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", funcName, err)
+		}
+		models = append(models, m)
+	}
+	return models, nil
+}
+
+// modelLiterals follows a model function's body to the ModelInfo literals
+// it returns. Bodies may build the slice in locals, append to it and call
+// other model functions; anything else is reported.
+func (d *definitions) modelLiterals(fn *ast.FuncDecl) ([]*ast.CompositeLit, error) {
+	name := fn.Name.Name
+	if d.evaluating[name] {
+		return nil, d.errorf(fn, "%s calls itself", name)
+	}
+	d.evaluating[name] = true
+	defer delete(d.evaluating, name)
+
+	locals := make(map[string][]*ast.CompositeLit)
+	for _, stmt := range fn.Body.List {
+		switch s := stmt.(type) {
+		case *ast.AssignStmt:
+			if len(s.Lhs) != 1 || len(s.Rhs) != 1 {
+				return nil, d.errorf(s, "%s: multiple assignment is not supported", name)
+			}
+			ident, ok := s.Lhs[0].(*ast.Ident)
+			if !ok {
+				return nil, d.errorf(s, "%s: assignment to %T is not supported", name, s.Lhs[0])
+			}
+			list, err := d.modelList(s.Rhs[0], locals)
+			if err != nil {
+				return nil, err
+			}
+			locals[ident.Name] = list
+		case *ast.DeclStmt:
+			gen, ok := s.Decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				return nil, d.errorf(s, "%s: unsupported declaration", name)
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, ident := range vs.Names {
+					locals[ident.Name] = nil
+					if i < len(vs.Values) {
+						list, err := d.modelList(vs.Values[i], locals)
+						if err != nil {
+							return nil, err
+						}
+						locals[ident.Name] = list
+					}
+				}
+			}
+		case *ast.ReturnStmt:
+			if len(s.Results) != 1 {
+				return nil, d.errorf(s, "%s: expected one return value, got %d", name, len(s.Results))
+			}
+			return d.modelList(s.Results[0], locals)
+		default:
+			return nil, d.errorf(s, "%s: unsupported statement %T", name, s)
+		}
+	}
+	return nil, d.errorf(fn, "%s: no return statement", name)
+}
+
+// modelList evaluates an expression of type []*ModelInfo.
+func (d *definitions) modelList(expr ast.Expr, locals map[string][]*ast.CompositeLit) ([]*ast.CompositeLit, error) {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return d.modelList(e.X, locals)
+	case *ast.CompositeLit:
+		if _, ok := e.Type.(*ast.ArrayType); !ok {
+			return nil, d.errorf(e, "expected a []*ModelInfo literal")
+		}
+		return d.structLiterals(e.Elts)
+	case *ast.Ident:
+		if e.Name == "nil" {
+			return nil, nil
+		}
+		list, ok := locals[e.Name]
+		if !ok {
+			return nil, d.errorf(e, "undefined model list %s", e.Name)
+		}
+		return list, nil
+	case *ast.CallExpr:
+		fun, ok := e.Fun.(*ast.Ident)
+		if !ok {
+			return nil, d.errorf(e, "unsupported call in model list")
+		}
+		if fun.Name == "append" {
+			if len(e.Args) == 0 {
+				return nil, d.errorf(e, "append without arguments")
+			}
+			list, err := d.modelList(e.Args[0], locals)
+			if err != nil {
+				return nil, err
+			}
+			// Copy so appends don't alias a local's backing array
+			list = append([]*ast.CompositeLit(nil), list...)
+			if e.Ellipsis.IsValid() {
+				if len(e.Args) != 2 {
+					return nil, d.errorf(e, "append with ... takes two arguments")
+				}
+				rest, err := d.modelList(e.Args[1], locals)
+				if err != nil {
+					return nil, err
+				}
+				return append(list, rest...), nil
+			}
+			rest, err := d.structLiterals(e.Args[1:])
+			if err != nil {
+				return nil, err
+			}
+			return append(list, rest...), nil
+		}
+		fn, ok := d.funcs[fun.Name]
+		if !ok {
+			return nil, d.errorf(e, "call to unknown function %s", fun.Name)
+		}
+		if len(e.Args) != 0 {
+			return nil, d.errorf(e, "call to %s with arguments is not supported", fun.Name)
+		}
+		return d.modelLiterals(fn)
+	}
+	return nil, d.errorf(expr, "unsupported model list expression %T", expr)
+}
+
+func (d *definitions) structLiterals(exprs []ast.Expr) ([]*ast.CompositeLit, error) {
+	lits := make([]*ast.CompositeLit, 0, len(exprs))
+	for _, expr := range exprs {
+		lit, err := d.structLiteral(expr)
+		if err != nil {
+			return nil, err
+		}
+		lits = append(lits, lit)
+	}
+	return lits, nil
+}
+
+// structLiteral unwraps &T{...} and elided {...} elements.
+func (d *definitions) structLiteral(expr ast.Expr) (*ast.CompositeLit, error) {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return d.structLiteral(e.X)
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			return d.structLiteral(e.X)
+		}
+	case *ast.CompositeLit:
+		return e, nil
+	}
+	return nil, d.errorf(expr, "expected a struct literal, got %T", expr)
+}
+
+// fields maps a keyed struct literal's field names to their values.
+func (d *definitions) fields(lit *ast.CompositeLit) (map[string]ast.Expr, error) {
+	fields := make(map[string]ast.Expr, len(lit.Elts))
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			return nil, d.errorf(elt, "positional struct fields are not supported")
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			return nil, d.errorf(kv.Key, "expected a field name")
+		}
+		if _, dup := fields[key.Name]; dup {
+			return nil, d.errorf(kv.Key, "duplicate field %s", key.Name)
+		}
+		fields[key.Name] = kv.Value
+	}
+	return fields, nil
+}
+
+// decodeModel reads the ModelInfo fields model-sync uses. Other fields are
+// not evaluated, so upstream can add fields of any shape.
+func (d *definitions) decodeModel(lit *ast.CompositeLit, provider, source string) (Model, error) {
+	fields, err := d.fields(lit)
+	if err != nil {
+		return Model{}, err
+	}
+	idExpr, ok := fields["ID"]
+	if !ok {
+		return Model{}, d.errorf(lit, "model has no ID")
+	}
+	model := Model{Provider: provider}
+	if model.ID, err = d.stringValue(idExpr); err != nil {
+		return Model{}, fmt.Errorf("ID: %w", err)
+	}
+
+	strs := map[string]struct {
+		dst  *string
+		name string
+	}{
+		"Type":        {&model.Type, "type"},
+		"OwnedBy":     {&model.OwnedBy, "owned_by"},
+		"DisplayName": {&model.DisplayName, "display_name"},
+		"Description": {&model.Description, "description"},
+	}
+	for field, s := range strs {
+		if expr, ok := fields[field]; ok {
+			if *s.dst, err = d.stringValue(expr); err != nil {
+				return Model{}, fmt.Errorf("%s %s: %w", model.ID, field, err)
+			}
+			model.setSource(s.name, source)
+		}
+	}
+
+	ints := make(map[string]int)
+	for _, field := range []string{"ContextLength", "InputTokenLimit", "MaxCompletionTokens", "OutputTokenLimit"} {
+		if expr, ok := fields[field]; ok {
+			if ints[field], err = d.intValue(expr); err != nil {
+				return Model{}, fmt.Errorf("%s %s: %w", model.ID, field, err)
+			}
+		}
+	}
+	model.ContextLength = ints["ContextLength"]
+	if model.ContextLength == 0 {
+		model.ContextLength = ints["InputTokenLimit"]
+	}
+	if model.ContextLength != 0 {
+		model.setSource("context_length", source)
+	}
+	model.MaxCompletionTokens = ints["MaxCompletionTokens"]
+	if model.MaxCompletionTokens == 0 {
+		model.MaxCompletionTokens = ints["OutputTokenLimit"]
+	}
+	if model.MaxCompletionTokens != 0 {
+		model.setSource("max_completion_tokens", source)
+	}
+
+	if expr, ok := fields["Thinking"]; ok {
+		if model.Thinking, err = d.thinking(expr); err != nil {
+			return Model{}, fmt.Errorf("%s Thinking: %w", model.ID, err)
+		}
+		model.setSource("thinking", source)
+	}
+
+	if model.DisplayName == "" {
+		model.DisplayName = formatDisplayName(model.ID)
+		model.setSource("display_name", sourceInferred)
+	}
+	return model, nil
+}
+
+// thinking decodes a *ThinkingSupport literal; nil means no thinking.
+func (d *definitions) thinking(expr ast.Expr) (*Thinking, error) {
+	if ident, ok := expr.(*ast.Ident); ok && ident.Name == "nil" {
+		return nil, nil
+	}
+	lit, err := d.structLiteral(expr)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := d.fields(lit)
+	if err != nil {
+		return nil, err
+	}
+	t := &Thinking{Supported: true}
+	for field, dst := range map[string]*int{"Min": &t.Min, "Max": &t.Max} {
+		if expr, ok := fields[field]; ok {
+			if *dst, err = d.intValue(expr); err != nil {
+				return nil, fmt.Errorf("%s: %w", field, err)
+			}
+		}
+	}
+	if expr, ok := fields["ZeroAllowed"]; ok {
+		if t.ZeroAllowed, err = d.boolValue(expr); err != nil {
+			return nil, fmt.Errorf("ZeroAllowed: %w", err)
+		}
+	}
+	if expr, ok := fields["Levels"]; ok {
+		if t.Levels, err = d.stringsValue(expr); err != nil {
+			return nil, fmt.Errorf("Levels: %w", err)
+		}
+	}
+	return t, nil
+}
+
+// modelSources lists the exported, parameterless functions that define
+// models: those returning []*ModelInfo and those returning a
+// map[string]*<Name>ModelConfig keyed by model ID.
+func (d *definitions) modelSources() []string {
+	var names []string
+	for name, fn := range d.funcs {
+		if !ast.IsExported(name) || fn.Type.Params.NumFields() != 0 || fn.Type.Results.NumFields() != 1 {
+			continue
+		}
+		result := fn.Type.Results.List[0].Type
+		if isModelList(result) || isModelConfigMap(result) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isModelList reports whether t is []*ModelInfo.
+func isModelList(t ast.Expr) bool {
+	arr, ok := t.(*ast.ArrayType)
+	if !ok || arr.Len != nil {
+		return false
+	}
+	star, ok := arr.Elt.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	ident, ok := star.X.(*ast.Ident)
+	return ok && ident.Name == "ModelInfo"
+}
+
+// isModelConfigMap reports whether t is map[string]*<Name>ModelConfig.
+func isModelConfigMap(t ast.Expr) bool {
+	m, ok := t.(*ast.MapType)
+	if !ok {
+		return false
+	}
+	if key, ok := m.Key.(*ast.Ident); !ok || key.Name != "string" {
+		return false
+	}
+	star, ok := m.Value.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	ident, ok := star.X.(*ast.Ident)
+	return ok && strings.HasSuffix(ident.Name, "ModelConfig")
+}
+
+// sourceModels evaluates a function found by modelSources.
+func (d *definitions) sourceModels(funcName, provider string) ([]Model, error) {
+	fn := d.funcs[funcName]
+	if isModelConfigMap(fn.Type.Results.List[0].Type) {
+		return d.configModels(funcName, provider)
+	}
+	return d.models(funcName, provider)
+}
+
+// configModels evaluates a map-based config such as
+// GetAntigravityModelConfig, whose keys are the model IDs.
+func (d *definitions) configModels(funcName, provider string) ([]Model, error) {
+	fn := d.funcs[funcName]
+	var ret *ast.ReturnStmt
+	for _, stmt := range fn.Body.List {
+		if r, ok := stmt.(*ast.ReturnStmt); ok {
+			ret = r
+		}
+	}
+	if ret == nil || len(ret.Results) != 1 {
+		return nil, d.errorf(fn, "%s: expected a single return value", funcName)
+	}
+	lit, ok := ret.Results[0].(*ast.CompositeLit)
+	if !ok {
+		return nil, d.errorf(ret.Results[0], "%s: expected a map literal", funcName)
+	}
+	if _, ok := lit.Type.(*ast.MapType); !ok {
+		return nil, d.errorf(lit, "%s: expected a map literal", funcName)
+	}
+
+	var models []Model
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			return nil, d.errorf(elt, "%s: expected a key: value entry", funcName)
+		}
+		id, err := d.stringValue(kv.Key)
+		if err != nil {
+			return nil, fmt.Errorf("%s key: %w", funcName, err)
+		}
+		model := Model{
+			ID:          id,
+			Provider:    provider,
+			DisplayName: formatDisplayName(id),
+			Type:        provider,
+			OwnedBy:     provider,
+		}
+		// A config map only has the model ID as key; the rest is ours
+		for _, field := range []string{"display_name", "type", "owned_by"} {
+			model.setSource(field, sourceInferred)
+		}
+		source := "upstream:" + funcName
+		if ident, ok := kv.Value.(*ast.Ident); !ok || ident.Name != "nil" {
+			cfg, err := d.structLiteral(kv.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", funcName, id, err)
+			}
+			fields, err := d.fields(cfg)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", funcName, id, err)
+			}
+			if expr, ok := fields["Thinking"]; ok {
+				if model.Thinking, err = d.thinking(expr); err != nil {
+					return nil, fmt.Errorf("%s %s Thinking: %w", funcName, id, err)
+				}
+				model.setSource("thinking", source)
+			}
+			if expr, ok := fields["MaxCompletionTokens"]; ok {
+				if model.MaxCompletionTokens, err = d.intValue(expr); err != nil {
+					return nil, fmt.Errorf("%s %s MaxCompletionTokens: %w", funcName, id, err)
+				}
+				model.setSource("max_completion_tokens", source)
+			}
+		}
+		models = append(models, model)
+	}
+	return models, nil
+}
+
+func (d *definitions) stringValue(expr ast.Expr) (string, error) {
+	v, err := d.constant(expr)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", d.errorf(expr, "expected a string, got %v", v)
+	}
+	return s, nil
+}
+
+func (d *definitions) intValue(expr ast.Expr) (int, error) {
+	v, err := d.constant(expr)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := v.(int64)
+	if !ok {
+		return 0, d.errorf(expr, "expected an integer, got %v", v)
+	}
+	return int(n), nil
+}
+
+func (d *definitions) boolValue(expr ast.Expr) (bool, error) {
+	v, err := d.constant(expr)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, d.errorf(expr, "expected a bool, got %v", v)
+	}
+	return b, nil
+}
+
+// stringsValue evaluates a []string literal.
+func (d *definitions) stringsValue(expr ast.Expr) ([]string, error) {
+	if ident, ok := expr.(*ast.Ident); ok {
+		if ident.Name == "nil" {
+			return nil, nil
+		}
+		if value, ok := d.values[ident.Name]; ok && value != nil {
+			return d.stringsValue(value)
+		}
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil, d.errorf(expr, "expected a []string literal")
+	}
+	out := make([]string, 0, len(lit.Elts))
+	for _, elt := range lit.Elts {
+		s, err := d.stringValue(elt)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// constant evaluates a constant expression to a string, int64, float64 or
+// bool: literals, package-level constants, conversions and arithmetic.
+func (d *definitions) constant(expr ast.Expr) (interface{}, error) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		switch e.Kind {
+		case token.STRING:
+			s, err := strconv.Unquote(e.Value)
+			if err != nil {
+				return nil, d.errorf(e, "invalid string %s", e.Value)
+			}
+			return s, nil
+		case token.INT:
+			n, err := strconv.ParseInt(e.Value, 0, 64)
+			if err != nil {
+				return nil, d.errorf(e, "invalid integer %s", e.Value)
+			}
+			return n, nil
+		case token.FLOAT:
+			f, err := strconv.ParseFloat(e.Value, 64)
+			if err != nil {
+				return nil, d.errorf(e, "invalid float %s", e.Value)
+			}
+			return f, nil
+		}
+		return nil, d.errorf(e, "unsupported literal %s", e.Value)
+	case *ast.ParenExpr:
+		return d.constant(e.X)
+	case *ast.Ident:
+		switch e.Name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		value, ok := d.values[e.Name]
+		if !ok {
+			return nil, d.errorf(e, "undefined: %s", e.Name)
+		}
+		if value == nil {
+			return nil, d.errorf(e, "%s has no explicit value", e.Name)
+		}
+		if d.evaluating[e.Name] {
+			return nil, d.errorf(e, "%s refers to itself", e.Name)
+		}
+		d.evaluating[e.Name] = true
+		defer delete(d.evaluating, e.Name)
+		return d.constant(value)
+	case *ast.UnaryExpr:
+		x, err := d.constant(e.X)
+		if err != nil {
+			return nil, err
+		}
+		switch v := x.(type) {
+		case int64:
+			switch e.Op {
+			case token.SUB:
+				return -v, nil
+			case token.ADD:
+				return v, nil
+			}
+		case float64:
+			switch e.Op {
+			case token.SUB:
+				return -v, nil
+			case token.ADD:
+				return v, nil
+			}
+		case bool:
+			if e.Op == token.NOT {
+				return !v, nil
+			}
+		}
+		return nil, d.errorf(e, "unsupported operator %s on %v", e.Op, x)
+	case *ast.BinaryExpr:
+		return d.binary(e)
+	case *ast.CallExpr:
+		// Conversions such as int64(1759104000)
+		if fun, ok := e.Fun.(*ast.Ident); ok && len(e.Args) == 1 {
+			switch fun.Name {
+			case "int", "int32", "int64", "string", "float64":
+				return d.constant(e.Args[0])
+			}
+		}
+		return nil, d.errorf(e, "unsupported call")
+	}
+	return nil, d.errorf(expr, "unsupported expression %T", expr)
+}
+
+func (d *definitions) binary(e *ast.BinaryExpr) (interface{}, error) {
+	x, err := d.constant(e.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := d.constant(e.Y)
+	if err != nil {
+		return nil, err
+	}
+	switch a := x.(type) {
+	case string:
+		if b, ok := y.(string); ok && e.Op == token.ADD {
+			return a + b, nil
+		}
+	case int64:
+		if b, ok := y.(int64); ok {
+			switch e.Op {
+			case token.ADD:
+				return a + b, nil
+			case token.SUB:
+				return a - b, nil
+			case token.MUL:
+				return a * b, nil
+			case token.QUO:
+				if b == 0 {
+					return nil, d.errorf(e, "division by zero")
+				}
+				return a / b, nil
+			case token.SHL:
+				return a << uint64(b), nil
+			}
+		}
+	}
+	return nil, d.errorf(e, "unsupported operation %v %s %v", x, e.Op, y)
+}
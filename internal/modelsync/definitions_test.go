@@ -0,0 +1,170 @@
+package modelsync
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const staticDefs = `package registry
+
+const (
+	claudeOwner = "anthropic"
+	defaultContext = 200 * 1000
+	maxThinking = 1 << 17
+)
+
+var codexLevels = []string{"low", "medium", "high"}
+
+func GetClaudeModels() []*ModelInfo {
+	return []*ModelInfo{
+		{
+			ID:          "claude-sonnet-4-5-20250929",
+			Object:      "model",
+			Created:     time.Now().Unix(), // not read, so not evaluated
+			OwnedBy:     claudeOwner,
+			Type:        "claude",
+			DisplayName: "Claude " + "Sonnet 4.5",
+			ContextLength:       defaultContext,
+			MaxCompletionTokens: 64000,
+			// Minimum budget; MinInput would have confused the old scraper
+			Thinking: &ThinkingSupport{Min: 1024, Max: maxThinking, ZeroAllowed: false, DynamicAllowed: true},
+		},
+		{ID: "claude-haiku", OwnedBy: claudeOwner, Type: "claude", Thinking: nil},
+	}
+}
+
+func GetOpenAIModels() []*ModelInfo {
+	models := []*ModelInfo{
+		{ID: "gpt-5", OwnedBy: "openai", Thinking: &ThinkingSupport{Levels: codexLevels}},
+	}
+	models = append(models, &ModelInfo{ID: "gpt-5-codex", OwnedBy: "openai", Thinking: &ThinkingSupport{Levels: []string{"low", "high"}}})
+	return models
+}
+`
+
+const dynamicDefs = `package registry
+
+func GetGeminiModels() []*ModelInfo {
+	return []*ModelInfo{
+		{ID: "gemini-2.5-pro", InputTokenLimit: 1048576, OutputTokenLimit: 65536, Thinking: &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false}},
+	}
+}
+
+func GetGeminiCLIModels() []*ModelInfo {
+	return append(GetGeminiModels(), &ModelInfo{ID: "gemini-3-pro-preview"})
+}
+
+func GetAntigravityModelConfig() map[string]*AntigravityModelConfig {
+	return map[string]*AntigravityModelConfig{
+		"gemini-2.5-flash":           {Thinking: &ThinkingSupport{Min: 0, Max: 24576, ZeroAllowed: true}},
+		"claude-sonnet-4-5-thinking": {Thinking: &ThinkingSupport{Min: 1024, Max: 200000}, MaxCompletionTokens: 64000},
+		"rev19-uic3-1p":              nil,
+	}
+}
+`
+
+func TestParseDefinitions(t *testing.T) {
+	models, unmapped, err := parseAndEnrichModels([]SourceFile{
+		{Name: "model_definitions_static_data.go", Data: []byte(staticDefs)},
+		{Name: "model_definitions.go", Data: []byte(dynamicDefs)},
+	}, defaultProviders, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unmapped) != 0 {
+		t.Errorf("unmapped = %v", unmapped)
+	}
+
+	byID := make(map[string]Model)
+	for provider, list := range models {
+		for _, m := range list {
+			byID[provider+"/"+m.ID] = m
+		}
+	}
+
+	sonnet := byID["claude/claude-sonnet-4-5-20250929"]
+	if sonnet.OwnedBy != "anthropic" || sonnet.DisplayName != "Claude Sonnet 4.5" || sonnet.ContextLength != 200000 || sonnet.MaxCompletionTokens != 64000 {
+		t.Errorf("sonnet = %+v", sonnet)
+	}
+	if want := (&Thinking{Supported: true, Min: 1024, Max: 131072}); !reflect.DeepEqual(sonnet.Thinking, want) {
+		t.Errorf("sonnet thinking = %+v, want %+v", sonnet.Thinking, want)
+	}
+	if haiku := byID["claude/claude-haiku"]; haiku.Thinking != nil || haiku.DisplayName != "Claude Haiku" {
+		t.Errorf("haiku = %+v", haiku)
+	}
+
+	if got := byID["codex/gpt-5"].Thinking; got == nil || !reflect.DeepEqual(got.Levels, []string{"low", "medium", "high"}) {
+		t.Errorf("gpt-5 thinking = %+v", got)
+	}
+	if got := byID["codex/gpt-5-codex"].Thinking; got == nil || !reflect.DeepEqual(got.Levels, []string{"low", "high"}) {
+		t.Errorf("gpt-5-codex thinking = %+v", got)
+	}
+
+	if pro := byID["gemini/gemini-2.5-pro"]; pro.ContextLength != 1048576 || pro.MaxCompletionTokens != 65536 {
+		t.Errorf("gemini-2.5-pro = %+v", pro)
+	}
+	if len(models["gemini-cli"]) != 2 {
+		t.Errorf("gemini-cli = %d models, want 2", len(models["gemini-cli"]))
+	}
+
+	if flash := byID["antigravity/gemini-2.5-flash"]; flash.Thinking == nil || !flash.Thinking.ZeroAllowed || flash.Thinking.Max != 24576 {
+		t.Errorf("antigravity flash thinking = %+v", flash.Thinking)
+	}
+	if ag := byID["antigravity/claude-sonnet-4-5-thinking"]; ag.MaxCompletionTokens != 64000 || ag.Thinking.Min != 1024 {
+		t.Errorf("antigravity sonnet = %+v", ag)
+	}
+	if ag, ok := byID["antigravity/rev19-uic3-1p"]; !ok || ag.Thinking != nil {
+		t.Errorf("antigravity rev19 = %+v, present %v", ag, ok)
+	}
+}
+
+func TestParseDefinitionsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			"syntax",
+			"package registry\nfunc GetClaudeModels() []*ModelInfo {\n\treturn []*ModelInfo{\n",
+			"defs.go:3:23: expected '}'",
+		},
+		{
+			"missing ID",
+			"package registry\nfunc GetClaudeModels() []*ModelInfo {\n\treturn []*ModelInfo{{OwnedBy: \"anthropic\"}}\n}\n",
+			"defs.go:3:22: model has no ID",
+		},
+		{
+			"wrong type",
+			"package registry\nfunc GetClaudeModels() []*ModelInfo {\n\treturn []*ModelInfo{{ID: \"a\", ContextLength: \"big\"}}\n}\n",
+			"a ContextLength: defs.go:3:47: expected an integer",
+		},
+		{
+			"undefined constant",
+			"package registry\nfunc GetClaudeModels() []*ModelInfo {\n\treturn []*ModelInfo{{ID: \"a\", Thinking: &ThinkingSupport{Max: maxBudget}}}\n}\n",
+			"a Thinking: Max: defs.go:3:64: undefined: maxBudget",
+		},
+		{
+			"positional",
+			"package registry\nfunc GetClaudeModels() []*ModelInfo {\n\treturn []*ModelInfo{{\"a\"}}\n}\n",
+			"defs.go:3:23: positional struct fields are not supported",
+		},
+		{
+			"unsupported statement",
+			"package registry\nfunc GetClaudeModels() []*ModelInfo {\n\tfor {}\n}\n",
+			"defs.go:3:2: GetClaudeModels: unsupported statement",
+		},
+		{
+			"recursion",
+			"package registry\nfunc GetClaudeModels() []*ModelInfo {\n\treturn GetClaudeModels()\n}\n",
+			"GetClaudeModels calls itself",
+		},
+	}
+	for _, tt := range tests {
+		_, _, err := parseAndEnrichModels([]SourceFile{{Name: "defs.go", Data: []byte(tt.src)}}, defaultProviders, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package modelsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// diffCatalogs lists models added (+), removed (-) and changed (~) between
+// two catalogs, as "provider/id" lines sorted by provider and ID.
+func diffCatalogs(old, new map[string][]Model) []string {
+	type key struct{ provider, id string }
+	index := func(models map[string][]Model) map[key]Model {
+		m := make(map[key]Model)
+		for provider, list := range models {
+			for _, model := range list {
+				m[key{provider, model.ID}] = model
+			}
+		}
+		return m
+	}
+	before, after := index(old), index(new)
+
+	keys := make([]key, 0, len(before)+len(after))
+	for k := range before {
+		keys = append(keys, k)
+	}
+	for k := range after {
+		if _, ok := before[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].provider != keys[j].provider {
+			return keys[i].provider < keys[j].provider
+		}
+		return keys[i].id < keys[j].id
+	})
+
+	var lines []string
+	for _, k := range keys {
+		was, hadIt := before[k]
+		is, hasIt := after[k]
+		switch {
+		case !hadIt:
+			lines = append(lines, fmt.Sprintf("+ %s/%s", k.provider, k.id))
+		case !hasIt:
+			lines = append(lines, fmt.Sprintf("- %s/%s", k.provider, k.id))
+		default:
+			if changes := modelChanges(was, is); len(changes) > 0 {
+				lines = append(lines, fmt.Sprintf("~ %s/%s: %s", k.provider, k.id, strings.Join(changes, "; ")))
+			}
+		}
+	}
+	return lines
+}
+
+// modelChanges describes the differences clients notice.
+func modelChanges(was, is Model) []string {
+	var changes []string
+	field := func(name, a, b string) {
+		if a != b {
+			changes = append(changes, fmt.Sprintf("%s %s → %s", name, a, b))
+		}
+	}
+	field("context", formatTokens(was.ContextLength), formatTokens(is.ContextLength))
+	field("max output", formatTokens(was.MaxCompletionTokens), formatTokens(is.MaxCompletionTokens))
+	field("cost", formatCost(was.Cost), formatCost(is.Cost))
+	field("thinking", formatThinking(was.Thinking), formatThinking(is.Thinking))
+	field("modalities", formatModalities(was.Modalities), formatModalities(is.Modalities))
+	field("available", formatAvailable(was.Available), formatAvailable(is.Available))
+	return changes
+}
+
+func formatTokens(n int) string {
+	if n == 0 {
+		return "unset"
+	}
+	return fmt.Sprint(n)
+}
+
+func formatCost(c *Cost) string {
+	if c == nil {
+		return "unset"
+	}
+	return fmt.Sprintf("in %g/out %g/cache read %g/cache write %g", c.Input, c.Output, c.CacheRead, c.CacheWrite)
+}
+
+func formatThinking(t *Thinking) string {
+	if t == nil || !t.Supported {
+		return "off"
+	}
+	s := fmt.Sprintf("%d-%d", t.Min, t.Max)
+	if t.ZeroAllowed {
+		s += " zero ok"
+	}
+	if len(t.Levels) > 0 {
+		s += " levels " + strings.Join(t.Levels, "/")
+	}
+	return s
+}
+
+func formatModalities(m *Modalities) string {
+	if m == nil {
+		return "unset"
+	}
+	return strings.Join(m.Input, ",") + " → " + strings.Join(m.Output, ",")
+}
+
+func formatAvailable(b *bool) string {
+	if b == nil {
+		return "unknown"
+	}
+	return fmt.Sprint(*b)
+}
+
+// diffEntries lists entries added and removed between two sets of client
+// config keys.
+func diffEntries(old, new []string) []string {
+	had := make(map[string]bool, len(old))
+	for _, k := range old {
+		had[k] = true
+	}
+	has := make(map[string]bool, len(new))
+	for _, k := range new {
+		has[k] = true
+	}
+	var lines []string
+	for _, k := range new {
+		if !had[k] {
+			lines = append(lines, "+ "+k)
+		}
+	}
+	for _, k := range old {
+		if !has[k] {
+			lines = append(lines, "- "+k)
+		}
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i][2:] < lines[j][2:] })
+	return lines
+}
+
+// factoryEntries are a Factory config's model IDs.
+func factoryEntries(data []byte) []string {
+	var fc FactoryConfig
+	json.Unmarshal(data, &fc)
+	var keys []string
+	for _, m := range fc.CustomModels {
+		keys = append(keys, m.Model)
+	}
+	return keys
+}
+
+// openCodeEntries are an OpenCode config's provider/model keys.
+func openCodeEntries(data []byte) []string {
+	var oc OpenCodeConfig
+	json.Unmarshal(data, &oc)
+	var keys []string
+	for provider, p := range oc.Provider {
+		if p == nil {
+			continue
+		}
+		for id := range p.Models {
+			keys = append(keys, provider+"/"+id)
+		}
+	}
+	return keys
+}
+
+// catalogModels reads the models of a catalog; an unreadable one has none.
+func catalogModels(data []byte) map[string][]Model {
+	var config CanonicalConfig
+	json.Unmarshal(data, &config)
+	return config.Models
+}
+
+// upToDate reports whether the file at path already holds data. Catalogs
+// compare by models alone: source hashes change with every models.dev
+// update.
+func (o output) upToDate() bool {
+	existing, err := os.ReadFile(o.path)
+	if err != nil {
+		return false
+	}
+	if o.kind != catalogOutput {
+		return bytes.Equal(bytes.TrimSpace(existing), bytes.TrimSpace(o.data))
+	}
+	return reflect.DeepEqual(catalogModels(existing), catalogModels(o.data))
+}
+
+// diff describes how writing o would change the file at path.
+func (o output) diff() []string {
+	existing, _ := os.ReadFile(o.path)
+	switch o.kind {
+	case catalogOutput:
+		return diffCatalogs(catalogModels(existing), catalogModels(o.data))
+	case factoryOutput:
+		return diffEntries(factoryEntries(existing), factoryEntries(o.data))
+	case openCodeOutput:
+		return diffEntries(openCodeEntries(existing), openCodeEntries(o.data))
+	}
+	return nil
+}
+
+// writeFileAtomic replaces path with data through a temporary file in the
+// same directory, so readers never see a partial file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
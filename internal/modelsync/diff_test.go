@@ -0,0 +1,100 @@
+package modelsync
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffCatalogs(t *testing.T) {
+	yes := true
+	old := map[string][]Model{
+		"claude": {
+			{ID: "claude-opus-4-1", ContextLength: 200000},
+			{ID: "claude-sonnet-4-5", ContextLength: 200000, Cost: &Cost{Input: 3, Output: 15}, Thinking: &Thinking{Supported: true, Min: 1024, Max: 64000}},
+			{ID: "claude-haiku-4-5", ContextLength: 200000},
+		},
+	}
+	new := map[string][]Model{
+		"claude": {
+			{ID: "claude-sonnet-4-5", ContextLength: 1000000, Cost: &Cost{Input: 3, Output: 15}, Thinking: &Thinking{Supported: true, Min: 1024, Max: 128000}},
+			{ID: "claude-haiku-4-5", ContextLength: 200000, Available: &yes},
+			{ID: "claude-opus-4-6", Modalities: &Modalities{Input: []string{"text", "image"}, Output: []string{"text"}}},
+		},
+	}
+
+	want := []string{
+		"~ claude/claude-haiku-4-5: available unknown → true",
+		"- claude/claude-opus-4-1",
+		"+ claude/claude-opus-4-6",
+		"~ claude/claude-sonnet-4-5: context 200000 → 1000000; thinking 1024-64000 → 1024-128000",
+	}
+	if got := diffCatalogs(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffCatalogs =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if got := diffCatalogs(new, new); len(got) != 0 {
+		t.Errorf("identical catalogs differ: %v", got)
+	}
+}
+
+func TestRunDiffAndCheck(t *testing.T) {
+	dir := t.TempDir()
+	defs := filepath.Join(dir, "defs.go")
+	writeDefs := func(ids ...string) {
+		src := "package registry\nfunc GetClaudeModels() []*ModelInfo {\n\treturn []*ModelInfo{"
+		for _, id := range ids {
+			src += `{ID: "` + id + `"}, `
+		}
+		os.WriteFile(defs, []byte(src+"}\n}\n"), 0o644)
+	}
+	api := filepath.Join(dir, "api.json")
+	os.WriteFile(api, []byte(`{}`), 0o644)
+	opts := Options{
+		Output:  filepath.Join(dir, "models.json"),
+		Factory: filepath.Join(dir, "factory.json"),
+	}
+	run := func(flags ...string) error {
+		return runSync(append([]string{"-local-modeldefs", defs, "-local-modelsdev", api}, flags...), opts)
+	}
+
+	writeDefs("claude-sonnet-4-5")
+	if err := run("-check"); err == nil {
+		t.Error("-check passed with no outputs")
+	}
+	if err := run("-diff"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(opts.Output); !os.IsNotExist(err) {
+		t.Error("-diff wrote the outputs")
+	}
+	report := filepath.Join(dir, "report.json")
+	for _, mode := range []string{"-diff", "-check"} {
+		run(mode, "-match-report", report)
+		if _, err := os.Stat(report); !os.IsNotExist(err) {
+			t.Errorf("%s wrote the match report", mode)
+		}
+	}
+
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := run("-check"); err != nil {
+		t.Errorf("-check after a sync: %v", err)
+	}
+
+	writeDefs("claude-sonnet-4-5", "claude-opus-4-6")
+	err := run("-check")
+	if err == nil || !strings.Contains(err.Error(), "models.json") || !strings.Contains(err.Error(), "factory.json") {
+		t.Errorf("-check with a new model: err = %v", err)
+	}
+
+	// Only the outputs remain: no temporary files from the atomic writes
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			t.Errorf("leftover temporary file %s", e.Name())
+		}
+	}
+}
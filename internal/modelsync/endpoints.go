@@ -0,0 +1,131 @@
+package modelsync
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DefaultBaseURL is where clients reach a stock ThinkingProxy.
+const DefaultBaseURL = "http://localhost:8317"
+
+// defaultAPIKey is sent when the proxy needs no key; clients insist on one.
+const defaultAPIKey = "dummy"
+
+// Endpoints say how the generated Factory and OpenCode configs reach the
+// proxy. URLs are the proxy's root; "/v1" is appended for OpenAI-style
+// clients. Set at most one of APIKey and APIKeyEnv.
+type Endpoints struct {
+	BaseURL string
+	// ProviderURLs override BaseURL per catalog provider, e.g. "kiro".
+	ProviderURLs map[string]string
+	APIKey       string
+	// APIKeyEnv names an environment variable the clients read the key
+	// from, so the key stays out of the generated files.
+	APIKeyEnv string
+}
+
+// providerURLs is the repeatable -provider-url provider=URL flag.
+type providerURLs map[string]string
+
+func (p providerURLs) String() string {
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		keys[i] = k + "=" + p[k]
+	}
+	return strings.Join(keys, ",")
+}
+
+func (p providerURLs) Set(value string) error {
+	provider, u, ok := strings.Cut(value, "=")
+	if !ok || provider == "" || u == "" {
+		return fmt.Errorf("want provider=URL, got %q", value)
+	}
+	p[provider] = u
+	return nil
+}
+
+// validate checks the URLs and trims their trailing slashes.
+func (e *Endpoints) validate() error {
+	if e.BaseURL == "" {
+		e.BaseURL = DefaultBaseURL
+	}
+	if e.APIKey != "" && e.APIKeyEnv != "" {
+		return fmt.Errorf("set an API key or an API key variable, not both")
+	}
+	var err error
+	if e.BaseURL, err = checkBaseURL(e.BaseURL); err != nil {
+		return err
+	}
+	for provider, u := range e.ProviderURLs {
+		if e.ProviderURLs[provider], err = checkBaseURL(u); err != nil {
+			return fmt.Errorf("%s: %w", provider, err)
+		}
+	}
+	return nil
+}
+
+func checkBaseURL(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %q: %w", raw, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid base URL %q: want http(s)://host[:port]", raw)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("invalid base URL %q: no query or fragment allowed", raw)
+	}
+	return strings.TrimRight(raw, "/"), nil
+}
+
+// root is the proxy URL for a catalog provider.
+func (e Endpoints) root(provider string) string {
+	if u := e.ProviderURLs[provider]; u != "" {
+		return u
+	}
+	if e.BaseURL == "" {
+		return DefaultBaseURL
+	}
+	return e.BaseURL
+}
+
+// factoryKey is the Factory apiKey; Factory expands ${VAR}.
+func (e Endpoints) factoryKey() string {
+	switch {
+	case e.APIKeyEnv != "":
+		return "${" + e.APIKeyEnv + "}"
+	case e.APIKey != "":
+		return e.APIKey
+	}
+	return defaultAPIKey
+}
+
+// openCodeKey is the OpenCode apiKey; OpenCode expands {env:VAR}.
+func (e Endpoints) openCodeKey() string {
+	switch {
+	case e.APIKeyEnv != "":
+		return "{env:" + e.APIKeyEnv + "}"
+	case e.APIKey != "":
+		return e.APIKey
+	}
+	return defaultAPIKey
+}
+
+// liveKey is the key sent to -live: override if set, else the key the
+// clients use, read from APIKeyEnv when that names it.
+func (e Endpoints) liveKey(override string) string {
+	switch {
+	case override != "":
+		return override
+	case e.APIKeyEnv != "":
+		return os.Getenv(e.APIKeyEnv)
+	}
+	return e.APIKey
+}
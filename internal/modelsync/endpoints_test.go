@@ -0,0 +1,128 @@
+package modelsync
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/theadriann/vibeproxyplus/internal/thinking"
+)
+
+func TestEndpointsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      Endpoints
+		base    string
+		wantErr string
+	}{
+		{"default", Endpoints{}, DefaultBaseURL, ""},
+		{"trailing slash", Endpoints{BaseURL: "https://proxy.example.com:8443/"}, "https://proxy.example.com:8443", ""},
+		{"path prefix", Endpoints{BaseURL: "https://example.com/vibeproxy"}, "https://example.com/vibeproxy", ""},
+		{"no scheme", Endpoints{BaseURL: "localhost:8317"}, "", "want http(s)://host"},
+		{"unix socket", Endpoints{BaseURL: "unix:///tmp/proxy.sock"}, "", "want http(s)://host"},
+		{"query", Endpoints{BaseURL: "http://localhost:8317?x=1"}, "", "no query"},
+		{"provider", Endpoints{ProviderURLs: map[string]string{"kiro": "ftp://x"}}, "", "kiro: invalid base URL"},
+		{"both keys", Endpoints{APIKey: "k", APIKeyEnv: "K"}, "", "not both"},
+	}
+	for _, tt := range tests {
+		err := tt.in.validate()
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || tt.in.BaseURL != tt.base {
+			t.Errorf("%s: base = %q, err = %v, want %q", tt.name, tt.in.BaseURL, err, tt.base)
+		}
+	}
+}
+
+func TestClientConfigEndpoints(t *testing.T) {
+	models := map[string][]Model{
+		"claude": {{ID: "claude-sonnet-4-5", Provider: "claude", DisplayName: "Claude Sonnet 4.5"}},
+		"codex":  {{ID: "gpt-5", Provider: "codex", DisplayName: "GPT-5"}},
+		"kiro":   {{ID: "kiro-auto", Provider: "kiro", DisplayName: "Kiro Auto"}},
+	}
+	endpoints := Endpoints{
+		BaseURL:      "https://proxy.example.com",
+		ProviderURLs: map[string]string{"kiro": "http://10.0.0.2:8317"},
+		APIKeyEnv:    "VIBEPROXY_KEY",
+	}
+
+	factory := generateFactoryConfig(models, endpoints, thinking.DefaultTiers)
+	urls := make(map[string]string)
+	for _, m := range factory.CustomModels {
+		urls[m.Model] = m.BaseURL
+		if m.APIKey != "${VIBEPROXY_KEY}" {
+			t.Errorf("factory %s: apiKey = %q", m.Model, m.APIKey)
+		}
+	}
+	wantURLs := map[string]string{
+		"claude-sonnet-4-5": "https://proxy.example.com",
+		"gpt-5":             "https://proxy.example.com/v1",
+		"kiro-auto":         "http://10.0.0.2:8317/v1",
+	}
+	for id, want := range wantURLs {
+		if urls[id] != want {
+			t.Errorf("factory %s: baseUrl = %q, want %q", id, urls[id], want)
+		}
+	}
+
+	opencode := generateOpenCodeConfig(models, endpoints, thinking.DefaultTiers)
+	wantProviders := map[string]struct{ url, model string }{
+		"ai-proxy-claude": {"https://proxy.example.com", "claude-sonnet-4-5"},
+		"ai-proxy-openai": {"https://proxy.example.com/v1", "gpt-5"},
+		"ai-proxy-kiro":   {"http://10.0.0.2:8317/v1", "kiro-auto"},
+	}
+	if len(opencode.Provider) != len(wantProviders) {
+		t.Errorf("opencode providers = %d, want %d", len(opencode.Provider), len(wantProviders))
+	}
+	for name, want := range wantProviders {
+		p := opencode.Provider[name]
+		if p == nil || p.BaseURL != want.url || p.Models[want.model] == nil || p.APIKey != "{env:VIBEPROXY_KEY}" {
+			t.Errorf("opencode %s = %+v, want %s serving %s", name, p, want.url, want.model)
+		}
+	}
+}
+
+func TestRunEndpointFlags(t *testing.T) {
+	dir := t.TempDir()
+	defs := filepath.Join(dir, "defs.go")
+	os.WriteFile(defs, []byte("package registry\nfunc GetClaudeModels() []*ModelInfo {\n\treturn []*ModelInfo{{ID: \"claude-sonnet-4-5\"}}\n}\n"), 0o644)
+	api := filepath.Join(dir, "api.json")
+	os.WriteFile(api, []byte(`{}`), 0o644)
+	opts := Options{
+		Output:    filepath.Join(dir, "models.json"),
+		Factory:   filepath.Join(dir, "factory.json"),
+		Endpoints: Endpoints{BaseURL: "http://localhost:9317", APIKeyEnv: "FROM_CONFIG"},
+	}
+	run := func(flags ...string) FactoryModel {
+		t.Helper()
+		if err := runSync(append([]string{"-local-modeldefs", defs, "-local-modelsdev", api}, flags...), opts); err != nil {
+			t.Fatal(err)
+		}
+		var cfg FactoryConfig
+		data, _ := os.ReadFile(opts.Factory)
+		if err := json.Unmarshal(data, &cfg); err != nil || len(cfg.CustomModels) == 0 {
+			t.Fatalf("factory config: %v %s", err, data)
+		}
+		return cfg.CustomModels[0]
+	}
+
+	if m := run(); m.BaseURL != "http://localhost:9317" || m.APIKey != "${FROM_CONFIG}" {
+		t.Errorf("defaults: %s %s", m.BaseURL, m.APIKey)
+	}
+	// A flag key replaces the configured variable
+	if m := run("-base-url", "https://remote:8443/", "-api-key", "secret"); m.BaseURL != "https://remote:8443" || m.APIKey != "secret" {
+		t.Errorf("flags: %s %s", m.BaseURL, m.APIKey)
+	}
+	if m := run("-provider-url", "claude=http://other:8317"); m.BaseURL != "http://other:8317" {
+		t.Errorf("provider URL: %s", m.BaseURL)
+	}
+	if err := runSync([]string{"-local-modeldefs", defs, "-local-modelsdev", api, "-base-url", "proxy:8317"}, opts); err == nil {
+		t.Error("invalid -base-url accepted")
+	}
+}
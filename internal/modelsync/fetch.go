@@ -0,0 +1,166 @@
+package modelsync
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fetcher downloads sources with retries and keeps the last good copy of
+// each in cacheDir, revalidated with ETag and Last-Modified.
+type fetcher struct {
+	client   *http.Client
+	cacheDir string
+	offline  bool
+	retries  int
+	backoff  time.Duration
+	// header is added to every request, e.g. Authorization.
+	header http.Header
+}
+
+// cacheMeta is stored next to each cached body.
+type cacheMeta struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// statusError is a response that is neither 200 nor 304.
+type statusError struct {
+	url    string
+	status string
+	code   int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("GET %s: %s", e.url, e.status)
+}
+
+// temporary reports whether retrying may help.
+func (e *statusError) temporary() bool {
+	return e.code == http.StatusTooManyRequests || e.code >= 500
+}
+
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "vibeproxy", "model-sync")
+}
+
+// get returns url's body. Offline, only the cache is read.
+func (f *fetcher) get(url string) ([]byte, error) {
+	meta, cached := f.readCache(url)
+	if f.offline {
+		if cached == nil {
+			return nil, fmt.Errorf("%s is not cached in %s; run once without -offline", url, f.cacheDir)
+		}
+		return cached, nil
+	}
+
+	var err error
+	for attempt := 0; attempt <= f.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(f.backoff << (attempt - 1))
+		}
+		var body []byte
+		body, err = f.fetch(url, meta, cached)
+		if err == nil {
+			return body, nil
+		}
+		var se *statusError
+		if errors.As(err, &se) && !se.temporary() {
+			break
+		}
+	}
+	return nil, err
+}
+
+func (f *fetcher) fetch(url string, meta *cacheMeta, cached []byte) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range f.header {
+		req.Header[k] = v
+	}
+	if meta != nil && cached != nil {
+		if meta.ETag != "" {
+			req.Header.Set("If-None-Match", meta.ETag)
+		}
+		if meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if cached == nil {
+			return nil, &statusError{url: url, status: resp.Status + " without a cached copy", code: resp.StatusCode}
+		}
+		return cached, nil
+	case http.StatusOK:
+	default:
+		return nil, &statusError{url: url, status: resp.Status, code: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", url, err)
+	}
+	f.writeCache(url, body, &cacheMeta{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now().UTC(),
+	})
+	return body, nil
+}
+
+func (f *fetcher) cachePath(url string) string {
+	return filepath.Join(f.cacheDir, fmt.Sprintf("%x", sha256.Sum256([]byte(url)))[:16])
+}
+
+func (f *fetcher) readCache(url string) (*cacheMeta, []byte) {
+	if f.cacheDir == "" {
+		return nil, nil
+	}
+	path := f.cachePath(url)
+	body, err := os.ReadFile(path + ".body")
+	if err != nil {
+		return nil, nil
+	}
+	var meta cacheMeta
+	if data, err := os.ReadFile(path + ".json"); err == nil {
+		json.Unmarshal(data, &meta)
+	}
+	return &meta, body
+}
+
+// writeCache is best effort: a failed write only costs a later download.
+func (f *fetcher) writeCache(url string, body []byte, meta *cacheMeta) {
+	if f.cacheDir == "" {
+		return
+	}
+	if err := os.MkdirAll(f.cacheDir, 0o755); err != nil {
+		return
+	}
+	path := f.cachePath(url)
+	data, _ := json.MarshalIndent(meta, "", "  ")
+	if os.WriteFile(path+".body", body, 0o644) == nil {
+		os.WriteFile(path+".json", data, 0o644)
+	}
+}
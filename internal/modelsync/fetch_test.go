@@ -0,0 +1,92 @@
+package modelsync
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetcherCaching(t *testing.T) {
+	requests, conditional := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		io.WriteString(w, "body")
+	}))
+	defer srv.Close()
+
+	f := &fetcher{client: srv.Client(), cacheDir: t.TempDir()}
+	for i := 0; i < 2; i++ {
+		body, err := f.get(srv.URL + "/defs.go")
+		if err != nil || string(body) != "body" {
+			t.Fatalf("get #%d = %q, %v", i+1, body, err)
+		}
+	}
+	if requests != 2 || conditional != 1 {
+		t.Errorf("requests = %d (%d conditional), want 2 (1)", requests, conditional)
+	}
+
+	f.offline = true
+	if body, err := f.get(srv.URL + "/defs.go"); err != nil || string(body) != "body" {
+		t.Errorf("offline get = %q, %v", body, err)
+	}
+	if requests != 2 {
+		t.Error("offline get hit the network")
+	}
+	if _, err := f.get(srv.URL + "/other.go"); err == nil || !strings.Contains(err.Error(), "not cached") {
+		t.Errorf("offline uncached get: err = %v", err)
+	}
+}
+
+func TestFetcherRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		wantErr  string
+		wantHits int
+	}{
+		{"recovers from 503", []int{503, 503, 200}, "", 3},
+		{"gives up after retries", []int{500, 500, 500, 200}, "500 Internal Server Error", 3},
+		{"no retry on 404", []int{404, 200}, "404 Not Found", 1},
+		{"retries 429", []int{429, 200}, "", 2},
+	}
+	for _, tt := range tests {
+		hits := 0
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			status := tt.statuses[hits]
+			hits++
+			w.WriteHeader(status)
+			io.WriteString(w, "ok")
+		}))
+		f := &fetcher{client: srv.Client(), retries: 2, backoff: time.Millisecond}
+		_, err := f.get(srv.URL)
+		srv.Close()
+
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+		}
+		if hits != tt.wantHits {
+			t.Errorf("%s: %d requests, want %d", tt.name, hits, tt.wantHits)
+		}
+	}
+}
+
+func TestFetcherTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	f := &fetcher{client: &http.Client{Timeout: 20 * time.Millisecond}}
+	if _, err := f.get(srv.URL); err == nil {
+		t.Error("slow server: no error")
+	}
+}
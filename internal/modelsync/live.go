@@ -0,0 +1,102 @@
+package modelsync
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// liveModel is one entry of a running backend's /v1/models.
+type liveModel struct {
+	ID      string `json:"id"`
+	OwnedBy string `json:"owned_by"`
+	Type    string `json:"type"`
+}
+
+// liveModelsURL is the model list of a CLIProxyAPIPlus or ThinkingProxy
+// base URL.
+func liveModelsURL(baseURL string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v1") + "/v1/models"
+}
+
+// parseLiveModels reads an OpenAI-style model list.
+func parseLiveModels(data []byte) ([]liveModel, error) {
+	var list struct {
+		Data []liveModel `json:"data"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid model list: %w", err)
+	}
+	if list.Data == nil {
+		return nil, fmt.Errorf("invalid model list: no data array")
+	}
+	return list.Data, nil
+}
+
+// markAvailability sets Available on every model, by whether the backend
+// lists its ID, and adds the models only the backend knows (aliases,
+// providers upstream hasn't defined yet). It returns the added models.
+func markAvailability(models map[string][]Model, live []liveModel, source string) []Model {
+	listed := make(map[string]bool, len(live))
+	for _, m := range live {
+		listed[m.ID] = true
+	}
+	known := make(map[string]bool)
+	for provider := range models {
+		for i := range models[provider] {
+			m := &models[provider][i]
+			available := listed[m.ID]
+			m.Available = &available
+			m.setSource("available", source)
+			known[m.ID] = true
+		}
+	}
+
+	var added []Model
+	for _, lm := range live {
+		if known[lm.ID] {
+			continue
+		}
+		known[lm.ID] = true
+		provider := lm.Type
+		if provider == "" {
+			provider = lm.OwnedBy
+		}
+		if provider == "" {
+			provider = "unknown"
+		}
+		available := true
+		m := Model{
+			ID:          lm.ID,
+			Provider:    provider,
+			DisplayName: formatDisplayName(lm.ID),
+			Type:        lm.Type,
+			OwnedBy:     lm.OwnedBy,
+			Available:   &available,
+		}
+		m.setSource("display_name", sourceInferred)
+		m.setSource("available", source)
+		if lm.Type != "" {
+			m.setSource("type", source)
+		}
+		if lm.OwnedBy != "" {
+			m.setSource("owned_by", source)
+		}
+		models[provider] = append(models[provider], m)
+		added = append(added, m)
+	}
+	return added
+}
+
+// availableOnly drops models the backend did not list.
+func availableOnly(models map[string][]Model) map[string][]Model {
+	filtered := make(map[string][]Model, len(models))
+	for provider, list := range models {
+		for _, m := range list {
+			if m.Available != nil && *m.Available {
+				filtered[provider] = append(filtered[provider], m)
+			}
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,154 @@
+package modelsync
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestRunLive(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, `{"object":"list","data":[
+			{"id":"claude-sonnet-4-5","object":"model","owned_by":"anthropic","type":"claude"},
+			{"id":"gemini-claude-sonnet-4-5","object":"model","owned_by":"antigravity","type":"antigravity"}
+		]}`)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	defs := filepath.Join(dir, "defs.go")
+	os.WriteFile(defs, []byte(`package registry
+func GetClaudeModels() []*ModelInfo {
+	return []*ModelInfo{{ID: "claude-sonnet-4-5"}, {ID: "claude-opus-4-5"}}
+}
+`), 0o644)
+	api := filepath.Join(dir, "api.json")
+	os.WriteFile(api, []byte(`{}`), 0o644)
+	output := filepath.Join(dir, "models.json")
+	factory := filepath.Join(dir, "factory.json")
+
+	args := []string{"-local-modeldefs", defs, "-local-modelsdev", api, "-live", srv.URL + "/v1", "-available-only"}
+	if err := runSync(args, Options{Output: output, Factory: factory}); err != nil {
+		t.Fatal(err)
+	}
+
+	var config CanonicalConfig
+	data, _ := os.ReadFile(output)
+	json.Unmarshal(data, &config)
+	available := make(map[string]bool)
+	for _, list := range config.Models {
+		for _, m := range list {
+			if m.Available == nil {
+				t.Fatalf("%s has no availability", m.ID)
+			}
+			available[m.ID] = *m.Available
+		}
+	}
+	want := map[string]bool{"claude-sonnet-4-5": true, "claude-opus-4-5": false, "gemini-claude-sonnet-4-5": true}
+	for id, w := range want {
+		if got, ok := available[id]; !ok || got != w {
+			t.Errorf("%s available = %v (present %v), want %v", id, got, ok, w)
+		}
+	}
+	if len(config.Models["antigravity"]) != 1 {
+		t.Errorf("live-only model not added under its type: %v", config.Models)
+	}
+
+	var fc FactoryConfig
+	data, _ = os.ReadFile(factory)
+	json.Unmarshal(data, &fc)
+	var ids []string
+	for _, m := range fc.CustomModels {
+		ids = append(ids, m.Model)
+	}
+	sort.Strings(ids)
+	if len(ids) == 0 || ids[0] != "claude-sonnet-4-5" {
+		t.Errorf("factory models = %v, want only available ones", ids)
+	}
+	for _, id := range ids {
+		if id == "claude-opus-4-5" {
+			t.Error("unavailable model in the Factory config")
+		}
+	}
+}
+
+func TestRunLiveUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	dir := t.TempDir()
+	defs := filepath.Join(dir, "defs.go")
+	os.WriteFile(defs, []byte("package registry\nfunc GetClaudeModels() []*ModelInfo { return []*ModelInfo{{ID: \"a\"}} }\n"), 0o644)
+	api := filepath.Join(dir, "api.json")
+	os.WriteFile(api, []byte(`{}`), 0o644)
+	output := filepath.Join(dir, "models.json")
+
+	args := []string{"-local-modeldefs", defs, "-local-modelsdev", api, "-live", srv.URL, "-retries", "0"}
+	if err := runSync(args, Options{Output: output}); err == nil {
+		t.Error("unreachable backend: Run succeeded")
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Error("output written despite the failed live query")
+	}
+}
+
+func TestRunLiveAPIKey(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+		io.WriteString(w, `{"object":"list","data":[{"id":"a","owned_by":"anthropic","type":"claude"}]}`)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	defs := filepath.Join(dir, "defs.go")
+	if err := os.WriteFile(defs, []byte("package registry\nfunc GetClaudeModels() []*ModelInfo { return []*ModelInfo{{ID: \"a\"}} }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	api := filepath.Join(dir, "api.json")
+	if err := os.WriteFile(api, []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VIBEPROXY_TEST_KEY", "from-env")
+
+	tests := []struct {
+		name      string
+		flags     []string
+		endpoints Endpoints
+		want      string
+	}{
+		{"none", nil, Endpoints{}, ""},
+		{"client key", nil, Endpoints{APIKey: "sk-client"}, "Bearer sk-client"},
+		{"client key variable", nil, Endpoints{APIKeyEnv: "VIBEPROXY_TEST_KEY"}, "Bearer from-env"},
+		{"flag", []string{"-live-api-key", "sk-live"}, Endpoints{APIKey: "sk-client"}, "Bearer sk-live"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = "unset"
+			args := append([]string{"-local-modeldefs", defs, "-local-modelsdev", api, "-live", srv.URL}, tt.flags...)
+			if err := runSync(args, Options{Output: filepath.Join(dir, "models.json"), Endpoints: tt.endpoints}); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Authorization = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLiveModelsURL(t *testing.T) {
+	for _, base := range []string{"http://127.0.0.1:8318", "http://127.0.0.1:8318/", "http://127.0.0.1:8318/v1", "http://127.0.0.1:8318/v1/"} {
+		if got := liveModelsURL(base); got != "http://127.0.0.1:8318/v1/models" {
+			t.Errorf("liveModelsURL(%s) = %s", base, got)
+		}
+	}
+}
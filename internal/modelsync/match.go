@@ -0,0 +1,276 @@
+package modelsync
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Match methods, strongest first.
+const (
+	matchPinned     = "pinned"
+	matchExact      = "exact"
+	matchNormalized = "normalized"
+	matchFuzzy      = "fuzzy"
+	matchNone       = "none"
+)
+
+const (
+	// minFuzzyScore is the least score a fuzzy match is used at.
+	minFuzzyScore = 0.6
+	// confidentScore is the score below which a fuzzy match is reported.
+	confidentScore = 0.8
+)
+
+// modelsDevAffinity lists the models.dev providers whose entries describe a
+// catalog provider's models best.
+var modelsDevAffinity = map[string][]string{
+	"claude":         {"anthropic"},
+	"codex":          {"openai"},
+	"gemini":         {"google"},
+	"gemini-cli":     {"google"},
+	"vertex":         {"google-vertex", "google"},
+	"aistudio":       {"google"},
+	"qwen":           {"alibaba"},
+	"iflow":          {"iflowcn"},
+	"github-copilot": {"github-copilot"},
+	"kiro":           {"anthropic", "amazon-bedrock"},
+	"amazonq":        {"amazon-bedrock"},
+	"antigravity":    {"google", "anthropic"},
+}
+
+// MatchResult is how one catalog model was matched to models.dev.
+type MatchResult struct {
+	Model  string  `json:"model"`
+	Match  string  `json:"match,omitempty"`
+	Method string  `json:"method"`
+	Score  float64 `json:"score"`
+}
+
+// MatchReport lists the matches worth a look.
+type MatchReport struct {
+	Unmatched     []MatchResult `json:"unmatched"`
+	LowConfidence []MatchResult `json:"low_confidence"`
+	Pinned        []MatchResult `json:"pinned,omitempty"`
+}
+
+// candidate is a models.dev entry with its ID split for scoring.
+type candidate struct {
+	model   *ModelsDevModel
+	words   []string
+	version string
+	family  []string
+}
+
+// matcher finds the models.dev entry describing a catalog model.
+type matcher struct {
+	index      map[string]*ModelsDevModel
+	candidates []candidate
+	byRef      map[string]*ModelsDevModel
+	// pins map "id" or "provider/id" to a "provider/id" on models.dev,
+	// or "" for no match.
+	pins    map[string]string
+	results []MatchResult
+}
+
+// newMatcher indexes api. Every pin must name an existing models.dev entry.
+func newMatcher(api ModelsDevAPI, pins map[string]string) (*matcher, error) {
+	mt := &matcher{
+		index: buildModelsDevIndex(api),
+		byRef: make(map[string]*ModelsDevModel),
+		pins:  pins,
+	}
+	for providerID, provider := range api {
+		if provider == nil {
+			continue
+		}
+		for modelID, model := range provider.Models {
+			if model == nil {
+				continue
+			}
+			mt.byRef[providerID+"/"+modelID] = model
+			words, version := matchTokens(modelID)
+			family, _ := matchTokens(model.Family)
+			mt.candidates = append(mt.candidates, candidate{model: model, words: words, version: version, family: family})
+		}
+	}
+	// Deterministic tie-breaking regardless of map order
+	sort.Slice(mt.candidates, func(i, j int) bool {
+		a, b := mt.candidates[i].model, mt.candidates[j].model
+		if pa, pb := modelsDevProviderPriority(a.Provider), modelsDevProviderPriority(b.Provider); pa != pb {
+			return pa < pb
+		}
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		return a.ID < b.ID
+	})
+
+	for key, ref := range pins {
+		if ref != "" && mt.byRef[ref] == nil {
+			return nil, fmt.Errorf("pinned match %s → %s: no such models.dev entry", key, ref)
+		}
+	}
+	return mt, nil
+}
+
+// match returns the models.dev entry for m, or nil, and records the result.
+func (mt *matcher) match(m *Model) (*ModelsDevModel, MatchResult) {
+	result := MatchResult{Model: m.Provider + "/" + m.ID, Method: matchNone}
+	if mt == nil {
+		return nil, result
+	}
+	md, result := mt.find(m, result)
+	if md != nil {
+		result.Match = md.Provider + "/" + md.ID
+	}
+	mt.results = append(mt.results, result)
+	return md, result
+}
+
+func (mt *matcher) find(m *Model, result MatchResult) (*ModelsDevModel, MatchResult) {
+	for _, key := range []string{m.Provider + "/" + m.ID, m.ID} {
+		if ref, ok := mt.pins[key]; ok {
+			result.Method, result.Score = matchPinned, 1
+			if ref == "" {
+				result.Score = 0
+				return nil, result
+			}
+			return mt.byRef[ref], result
+		}
+	}
+
+	if md := mt.index[m.ID]; md != nil {
+		result.Method, result.Score = matchExact, 1
+		if md.ID != m.ID {
+			// An index key from a dated models.dev ID
+			result.Method, result.Score = matchNormalized, 0.95
+		}
+		return md, result
+	}
+	if md := mt.index[normalizeModelID(m.ID)]; md != nil {
+		result.Method, result.Score = matchNormalized, 0.95
+		return md, result
+	}
+
+	words, version := matchTokens(m.ID)
+	affinity := make(map[string]bool)
+	for _, p := range modelsDevAffinity[m.Provider] {
+		affinity[p] = true
+	}
+	var best *ModelsDevModel
+	bestScore := 0.0
+	for _, c := range mt.candidates {
+		if score := matchScore(words, version, c, affinity); score > bestScore {
+			best, bestScore = c.model, score
+		}
+	}
+	if bestScore < minFuzzyScore {
+		return nil, result
+	}
+	result.Method, result.Score = matchFuzzy, bestScore
+	return best, result
+}
+
+// matchScore rates how well a candidate describes a model with the given
+// ID tokens, from 0 to 0.99. Versions must agree exactly; every qualifier
+// the model has and the candidate lacks ("thinking", "lite") halves the
+// score, so variants don't fall back to their base model.
+func matchScore(words []string, version string, c candidate, affinity map[string]bool) float64 {
+	if version != c.version || len(words) == 0 {
+		return 0
+	}
+	has := make(map[string]bool, len(c.words))
+	for _, w := range c.words {
+		has[w] = true
+	}
+	common := 0
+	for _, w := range words {
+		if has[w] {
+			common++
+		}
+	}
+	if common == 0 {
+		return 0
+	}
+	score := float64(common) / float64(len(words)+len(c.words)-common)
+	for _, w := range words {
+		if !has[w] {
+			score *= 0.5
+		}
+	}
+
+	if len(c.family) > 0 {
+		inModel := true
+		for _, f := range c.family {
+			found := false
+			for _, w := range words {
+				found = found || w == f
+			}
+			inModel = inModel && found
+		}
+		if inModel {
+			score += 0.1
+		}
+	}
+	if affinity[c.model.Provider] {
+		score += 0.05
+	}
+	if score > 0.99 {
+		score = 0.99
+	}
+	return score
+}
+
+// matchTokens splits a model ID into lower-case words and its version: the
+// first run of numeric tokens, joined with dots ("claude-3-5-sonnet" →
+// "3.5"). Later numeric tokens are dates and snapshot suffixes and are
+// dropped, as is "latest".
+func matchTokens(id string) (words []string, version string) {
+	fields := strings.FieldsFunc(strings.ToLower(id), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var numbers []string
+	inVersion, versionDone := false, false
+	for _, f := range fields {
+		numeric := strings.IndexFunc(f, func(r rune) bool { return !unicode.IsDigit(r) }) < 0
+		switch {
+		case numeric && !versionDone && len(f) < 4:
+			numbers = append(numbers, f)
+			inVersion = true
+		case numeric:
+			// Dates like 20250929, or 2024-08-06 after "gpt-4o"
+			versionDone = true
+		case f == "latest":
+		default:
+			words = append(words, f)
+			if inVersion {
+				versionDone = true
+			}
+		}
+	}
+	return words, strings.Join(numbers, ".")
+}
+
+// report gathers unmatched, low-confidence and pinned results.
+func (mt *matcher) report() MatchReport {
+	r := MatchReport{Unmatched: []MatchResult{}, LowConfidence: []MatchResult{}}
+	if mt == nil {
+		return r
+	}
+	for _, res := range mt.results {
+		switch {
+		case res.Method == matchPinned:
+			r.Pinned = append(r.Pinned, res)
+		case res.Method == matchNone:
+			r.Unmatched = append(r.Unmatched, res)
+		case res.Method == matchFuzzy && res.Score < confidentScore:
+			r.LowConfidence = append(r.LowConfidence, res)
+		}
+	}
+	for _, list := range [][]MatchResult{r.Unmatched, r.LowConfidence, r.Pinned} {
+		sort.Slice(list, func(i, j int) bool { return list[i].Model < list[j].Model })
+	}
+	return r
+}
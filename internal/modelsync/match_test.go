@@ -0,0 +1,126 @@
+package modelsync
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchTokens(t *testing.T) {
+	tests := []struct {
+		id      string
+		words   []string
+		version string
+	}{
+		{"claude-sonnet-4-5-20250929", []string{"claude", "sonnet"}, "4.5"},
+		{"claude-3-5-sonnet-latest", []string{"claude", "sonnet"}, "3.5"},
+		{"gemini-2.5-flash-preview-05-20", []string{"gemini", "flash", "preview"}, "2.5"},
+		{"gpt-4o-2024-08-06", []string{"gpt", "4o"}, ""},
+		{"GPT-5.1-Codex", []string{"gpt", "codex"}, "5.1"},
+	}
+	for _, tt := range tests {
+		words, version := matchTokens(tt.id)
+		if !reflect.DeepEqual(words, tt.words) || version != tt.version {
+			t.Errorf("matchTokens(%s) = %v %q, want %v %q", tt.id, words, version, tt.words, tt.version)
+		}
+	}
+}
+
+func testModelsDev() ModelsDevAPI {
+	return ModelsDevAPI{
+		"anthropic": {ID: "anthropic", Models: map[string]*ModelsDevModel{
+			"claude-sonnet-4-5-20250929": {Name: "Claude Sonnet 4.5", Family: "claude-sonnet"},
+			"claude-opus-4-1":            {Name: "Claude Opus 4.1", Family: "claude-opus"},
+		}},
+		"google": {ID: "google", Models: map[string]*ModelsDevModel{
+			"gemini-2.5-flash":             {Name: "Gemini 2.5 Flash"},
+			"gemini-2.5-flash-lite":        {Name: "Gemini 2.5 Flash Lite"},
+			"gemini-2.5-pro-preview-05-06": {Name: "Gemini 2.5 Pro Preview"},
+		}},
+		"openai": {ID: "openai", Models: map[string]*ModelsDevModel{
+			"gpt-5": {Name: "GPT-5"},
+		}},
+		"github-copilot": {ID: "github-copilot", Models: map[string]*ModelsDevModel{
+			"gpt-5": {Name: "Copilot GPT-5"},
+		}},
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	mt, err := newMatcher(testModelsDev(), map[string]string{
+		"antigravity/claude-sonnet-4-5-thinking": "anthropic/claude-sonnet-4-5-20250929",
+		"kiro/gpt-5":                             "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		provider, id string
+		match        string
+		method       string
+	}{
+		{"claude", "claude-sonnet-4-5", "anthropic/claude-sonnet-4-5-20250929", matchNormalized},
+		{"claude", "claude-sonnet-4-5-20250929", "anthropic/claude-sonnet-4-5-20250929", matchExact},
+		// A variant must not fall back to its base model
+		{"claude", "claude-sonnet-4-5-thinking", "", matchNone},
+		{"claude", "claude-opus-4-5", "", matchNone},
+		{"gemini", "gemini-2.5-flash-lite", "google/gemini-2.5-flash-lite", matchExact},
+		{"gemini", "gemini-2.5-pro", "google/gemini-2.5-pro-preview-05-06", matchFuzzy},
+		{"codex", "gpt-5", "openai/gpt-5", matchExact},
+		{"antigravity", "claude-sonnet-4-5-thinking", "anthropic/claude-sonnet-4-5-20250929", matchPinned},
+		{"kiro", "gpt-5", "", matchPinned},
+	}
+	for _, tt := range tests {
+		md, result := mt.match(&Model{Provider: tt.provider, ID: tt.id})
+		if result.Match != tt.match || result.Method != tt.method || (md == nil) != (tt.match == "") {
+			t.Errorf("%s/%s matched %q by %s, want %q by %s", tt.provider, tt.id, result.Match, result.Method, tt.match, tt.method)
+		}
+	}
+
+	report := mt.report()
+	var unmatched, low, pinned []string
+	for _, r := range report.Unmatched {
+		unmatched = append(unmatched, r.Model)
+	}
+	for _, r := range report.LowConfidence {
+		low = append(low, r.Model)
+	}
+	for _, r := range report.Pinned {
+		pinned = append(pinned, r.Model)
+	}
+	if want := []string{"claude/claude-opus-4-5", "claude/claude-sonnet-4-5-thinking"}; !reflect.DeepEqual(unmatched, want) {
+		t.Errorf("unmatched = %v, want %v", unmatched, want)
+	}
+	if want := []string{"gemini/gemini-2.5-pro"}; !reflect.DeepEqual(low, want) {
+		t.Errorf("low confidence = %v, want %v", low, want)
+	}
+	if want := []string{"antigravity/claude-sonnet-4-5-thinking", "kiro/gpt-5"}; !reflect.DeepEqual(pinned, want) {
+		t.Errorf("pinned = %v, want %v", pinned, want)
+	}
+}
+
+func TestMatcherProviderAffinity(t *testing.T) {
+	api := ModelsDevAPI{
+		"openai":         {ID: "openai", Models: map[string]*ModelsDevModel{"gpt-5-mini-preview": {Name: "OpenAI"}}},
+		"github-copilot": {ID: "github-copilot", Models: map[string]*ModelsDevModel{"gpt-5-mini-preview": {Name: "Copilot"}}},
+	}
+	mt, err := newMatcher(api, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Equally good fuzzy candidates: the provider's own listing wins
+	for provider, want := range map[string]string{"github-copilot": "Copilot", "codex": "OpenAI"} {
+		md, result := mt.match(&Model{Provider: provider, ID: "gpt-5-mini"})
+		if md == nil || md.Name != want {
+			t.Errorf("%s/gpt-5-mini matched %q, want the %s entry", provider, result.Match, want)
+		}
+	}
+}
+
+func TestMatcherBadPin(t *testing.T) {
+	_, err := newMatcher(testModelsDev(), map[string]string{"gpt-5": "openai/gpt-6"})
+	if err == nil || !strings.Contains(err.Error(), "openai/gpt-6") {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,1153 @@
+// Package modelsync builds the canonical model catalog from CLIProxyAPIPlus
+// model definitions and models.dev, and generates client configs from it.
+package modelsync
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/theadriann/vibeproxyplus/internal/thinking"
+)
+
+const (
+	modelsDevURL = "https://models.dev/api.json"
+
+	// DefaultRef is the upstream ref used when neither -ref nor an
+	// installed backend version is known.
+	DefaultRef = "main"
+)
+
+// upstreamRawURL serves CLIProxyAPIPlus sources by ref; tests point it at
+// a stub.
+var upstreamRawURL = "https://raw.githubusercontent.com/router-for-me/CLIProxyAPIPlus"
+
+// modelDefsFiles hold the upstream model definitions; the static data file
+// has Claude, OpenAI, Gemini, etc.
+var modelDefsFiles = []string{
+	"internal/registry/model_definitions.go",
+	"internal/registry/model_definitions_static_data.go",
+}
+
+// modelDefsURL is the raw URL of an upstream file at ref.
+func modelDefsURL(ref, file string) string {
+	return upstreamRawURL + "/" + ref + "/" + file
+}
+
+// sourceEntry records where a catalog input came from and its content hash.
+func sourceEntry(location string, data []byte) string {
+	return fmt.Sprintf("%s sha256:%x", location, sha256.Sum256(data))
+}
+
+// Canonical model with merged metadata
+type Model struct {
+	ID                  string        `json:"id"`
+	Provider            string        `json:"provider"`
+	DisplayName         string        `json:"display_name"`
+	Description         string        `json:"description,omitempty"`
+	Family              string        `json:"family,omitempty"`
+	Type                string        `json:"type"`
+	OwnedBy             string        `json:"owned_by"`
+	ContextLength       int           `json:"context_length,omitempty"`
+	MaxCompletionTokens int           `json:"max_completion_tokens,omitempty"`
+	Thinking            *Thinking     `json:"thinking,omitempty"`
+	Modalities          *Modalities   `json:"modalities,omitempty"`
+	Capabilities        *Capabilities `json:"capabilities,omitempty"`
+	Cost                *Cost         `json:"cost,omitempty"`
+	// Available is whether the running backend lists the model; unset when
+	// the sync did not ask it.
+	Available *bool `json:"available,omitempty"`
+	// Provenance maps each field to where its value came from:
+	// "upstream:<function>", "models.dev:<provider>/<model>",
+	// "inferred", "live:<url>" or "override".
+	Provenance map[string]string `json:"provenance,omitempty"`
+}
+
+// Provenance sources without a location.
+const (
+	sourceInferred = "inferred"
+	sourceOverride = "override"
+)
+
+// setSource records where a field's value came from.
+func (m *Model) setSource(field, source string) {
+	if m.Provenance == nil {
+		m.Provenance = make(map[string]string)
+	}
+	m.Provenance[field] = source
+}
+
+type Thinking struct {
+	Supported   bool     `json:"supported"`
+	Min         int      `json:"min,omitempty"`
+	Max         int      `json:"max,omitempty"`
+	ZeroAllowed bool     `json:"zero_allowed,omitempty"`
+	Levels      []string `json:"levels,omitempty"`
+}
+
+type Modalities struct {
+	Input  []string `json:"input"`
+	Output []string `json:"output"`
+}
+
+type Capabilities struct {
+	Reasoning        bool `json:"reasoning,omitempty"`
+	ToolCall         bool `json:"tool_call,omitempty"`
+	StructuredOutput bool `json:"structured_output,omitempty"`
+	Attachment       bool `json:"attachment,omitempty"`
+	Temperature      bool `json:"temperature,omitempty"`
+}
+
+type Cost struct {
+	Input      float64 `json:"input,omitempty"`
+	Output     float64 `json:"output,omitempty"`
+	CacheRead  float64 `json:"cache_read,omitempty"`
+	CacheWrite float64 `json:"cache_write,omitempty"`
+}
+
+type CanonicalConfig struct {
+	Version string             `json:"version"`
+	Sources []string           `json:"sources"`
+	Models  map[string][]Model `json:"models"`
+}
+
+// LoadModelIDs reads a canonical models.json into provider key → model IDs.
+func LoadModelIDs(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config CanonicalConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	ids := make(map[string][]string, len(config.Models))
+	for provider, models := range config.Models {
+		for _, m := range models {
+			ids[provider] = append(ids[provider], m.ID)
+		}
+	}
+	return ids, nil
+}
+
+// models.dev types
+type ModelsDevAPI map[string]*ModelsDevProvider
+
+type ModelsDevProvider struct {
+	ID     string                     `json:"id"`
+	Name   string                     `json:"name"`
+	Models map[string]*ModelsDevModel `json:"models"`
+}
+
+type ModelsDevModel struct {
+	ID               string             `json:"id"`
+	Name             string             `json:"name"`
+	Family           string             `json:"family"`
+	Attachment       bool               `json:"attachment"`
+	Reasoning        bool               `json:"reasoning"`
+	ToolCall         bool               `json:"tool_call"`
+	StructuredOutput bool               `json:"structured_output"`
+	Temperature      bool               `json:"temperature"`
+	Modalities       *Modalities        `json:"modalities"`
+	Cost             map[string]float64 `json:"cost"`
+	Limit            map[string]int     `json:"limit"`
+	// Provider is the models.dev provider listing this entry, set while
+	// indexing.
+	Provider string `json:"-"`
+}
+
+type indexedModelsDevModel struct {
+	Provider string
+	Model    *ModelsDevModel
+}
+
+// Factory config types (settings.json format - camelCase)
+type FactoryModel struct {
+	Model           string                 `json:"model"`
+	DisplayName     string                 `json:"displayName,omitempty"`
+	BaseURL         string                 `json:"baseUrl"`
+	APIKey          string                 `json:"apiKey"`
+	Provider        string                 `json:"provider"`
+	MaxOutputTokens int                    `json:"maxOutputTokens,omitempty"`
+	SupportsImages  bool                   `json:"supportsImages,omitempty"`
+	ExtraArgs       map[string]interface{} `json:"extraArgs,omitempty"`
+	ExtraHeaders    map[string]string      `json:"extraHeaders,omitempty"`
+}
+
+type FactoryConfig struct {
+	CustomModels []FactoryModel `json:"customModels"`
+}
+
+// Options are the default output paths; an empty Factory or OpenCode path
+// skips that config. Ref is the default upstream ref, typically the
+// installed backend's release tag. Endpoints are the defaults for the
+// client config flags. Tiers name the thinking variants; nil uses
+// thinking.DefaultTiers.
+type Options struct {
+	Ref         string
+	Output      string
+	Factory     string
+	OpenCode    string
+	ProviderMap string
+	Overrides   string
+	Endpoints   Endpoints
+	Tiers       []thinking.Tier
+}
+
+// Run registers model-sync's flags on fs, parses them from args and syncs
+// the catalog.
+func Run(fs *flag.FlagSet, args []string, defaults Options) error {
+	if defaults.Output == "" {
+		defaults.Output = "models.json"
+	}
+	outputFile := fs.String("output", defaults.Output, "Output file for canonical config")
+	factoryFile := fs.String("factory", defaults.Factory, "Generate Factory CLI config file")
+	opencodeFile := fs.String("opencode", defaults.OpenCode, "Generate OpenCode CLI config file")
+	localModelDefs := fs.String("local-modeldefs", "", "Use local model definition files (comma-separated)")
+	localModelsDev := fs.String("local-modelsdev", "", "Use local models.dev api.json")
+	ref := fs.String("ref", defaults.Ref, "Upstream tag or commit to read model definitions from (default: installed backend, else main)")
+	offline := fs.Bool("offline", false, "Use only cached downloads")
+	cacheDir := fs.String("cache-dir", defaultCacheDir(), "Directory caching downloaded sources")
+	timeout := fs.Duration("timeout", 30*time.Second, "Timeout per download attempt")
+	retries := fs.Int("retries", 2, "Retries for failed downloads")
+	live := fs.String("live", "", "Base URL of a running CLIProxyAPIPlus or ThinkingProxy to mark models available from, e.g. http://127.0.0.1:8318")
+	liveAPIKey := fs.String("live-api-key", "", "API key sent to -live (default: the client API key)")
+	onlyAvailable := fs.Bool("available-only", false, "Generate Factory/OpenCode configs with only the models -live lists")
+	showDiff := fs.Bool("diff", false, "Print the models added, removed and changed against the existing outputs, without writing them")
+	check := fs.Bool("check", false, "Exit non-zero if the outputs are stale, without writing them")
+	matchReport := fs.String("match-report", "", "Write unmatched and low-confidence models.dev matches as JSON to this file (- for stdout)")
+	overridesFile := fs.String("overrides", defaults.Overrides, "models.overrides.json patching and filtering the catalog")
+	providerMapFile := fs.String("provider-map", defaults.ProviderMap, "JSON map of upstream model function → provider key, over the defaults")
+	endpoints := defaults.Endpoints
+	urls := providerURLs{}
+	for provider, u := range defaults.Endpoints.ProviderURLs {
+		urls[provider] = u
+	}
+	fs.StringVar(&endpoints.BaseURL, "base-url", endpoints.BaseURL, "Proxy URL written to the Factory/OpenCode configs (default "+DefaultBaseURL+")")
+	fs.Var(urls, "provider-url", "Proxy URL for one provider's models, as provider=URL (repeatable)")
+	fs.StringVar(&endpoints.APIKey, "api-key", endpoints.APIKey, "API key written to the Factory/OpenCode configs")
+	fs.StringVar(&endpoints.APIKeyEnv, "api-key-env", endpoints.APIKeyEnv, "Environment variable the clients read the API key from, instead of -api-key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// A key given on the command line replaces the configured one
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["api-key"] && !set["api-key-env"] {
+		endpoints.APIKeyEnv = ""
+	}
+	if set["api-key-env"] && !set["api-key"] {
+		endpoints.APIKey = ""
+	}
+	endpoints.ProviderURLs = urls
+	if err := endpoints.validate(); err != nil {
+		return fmt.Errorf("client endpoints: %w", err)
+	}
+	tiers := defaults.Tiers
+	if tiers == nil {
+		tiers = thinking.DefaultTiers
+	}
+	if err := thinking.Validate(tiers); err != nil {
+		return err
+	}
+
+	// The default provider map is optional; one named on the command line is not
+	providers, err := LoadProviderMap(*providerMapFile)
+	if errors.Is(err, os.ErrNotExist) && *providerMapFile == defaults.ProviderMap {
+		providers, err = LoadProviderMap("")
+	}
+	if err != nil {
+		return fmt.Errorf("load provider map: %w", err)
+	}
+
+	// Like the provider map, the default overrides file is optional
+	var overrides *Overrides
+	if *overridesFile != "" {
+		overrides, err = LoadOverrides(*overridesFile)
+		if errors.Is(err, os.ErrNotExist) && *overridesFile == defaults.Overrides {
+			overrides, err = nil, nil
+		}
+		if err != nil {
+			return fmt.Errorf("load overrides: %w", err)
+		}
+	}
+
+	if *onlyAvailable && *live == "" {
+		return fmt.Errorf("-available-only needs -live")
+	}
+	if *offline && *live != "" {
+		return fmt.Errorf("-live cannot be combined with -offline")
+	}
+
+	fetch := &fetcher{
+		client:   &http.Client{Timeout: *timeout},
+		cacheDir: *cacheDir,
+		offline:  *offline,
+		retries:  *retries,
+		backoff:  time.Second,
+	}
+
+	// Download/load CLIProxyAPIPlus model definitions (both files)
+	var modelDefs []SourceFile
+	var sources []string
+	if *localModelDefs != "" {
+		for _, path := range strings.Split(*localModelDefs, ",") {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("read local model definitions: %w", err)
+			}
+			modelDefs = append(modelDefs, SourceFile{Name: path, Data: data})
+			sources = append(sources, sourceEntry(path, data))
+			fmt.Printf("Using local model definitions: %s\n", path)
+		}
+	} else {
+		if *ref == "" {
+			*ref = DefaultRef
+		}
+		fmt.Printf("Downloading CLIProxyAPIPlus model definitions at %s...\n", *ref)
+		for _, file := range modelDefsFiles {
+			url := modelDefsURL(*ref, file)
+			data, err := fetch.get(url)
+			if err != nil {
+				return fmt.Errorf("download %s at %s: %w", path.Base(file), *ref, err)
+			}
+			modelDefs = append(modelDefs, SourceFile{Name: path.Base(file), Data: data})
+			sources = append(sources, sourceEntry(url, data))
+		}
+	}
+
+	// Download/load models.dev API
+	var modelsDevData ModelsDevAPI
+	var data []byte
+	if *localModelsDev != "" {
+		data, err = os.ReadFile(*localModelsDev)
+		if err != nil {
+			return fmt.Errorf("read local models.dev api.json: %w", err)
+		}
+		sources = append(sources, sourceEntry(*localModelsDev, data))
+		fmt.Printf("Using local models.dev api.json: %s\n", *localModelsDev)
+	} else {
+		fmt.Printf("Downloading models.dev API...\n")
+		data, err = fetch.get(modelsDevURL)
+		if err != nil {
+			return fmt.Errorf("download models.dev API: %w", err)
+		}
+		sources = append(sources, sourceEntry(modelsDevURL, data))
+	}
+	if err := json.Unmarshal(data, &modelsDevData); err != nil {
+		return fmt.Errorf("invalid models.dev API response: %w", err)
+	}
+
+	// Build models.dev lookup index
+	var pins map[string]string
+	if overrides != nil {
+		pins = overrides.Matches
+	}
+	mt, err := newMatcher(modelsDevData, pins)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Indexed %d models from models.dev\n", len(mt.index))
+
+	// Parse CLIProxyAPIPlus models and enrich with models.dev
+	models, unmapped, err := parseAndEnrichModels(modelDefs, providers, mt)
+	if err != nil {
+		return fmt.Errorf("parse model definitions: %w", err)
+	}
+	if len(unmapped) > 0 {
+		fmt.Printf("New upstream model functions, not in the provider map:\n")
+		for _, u := range unmapped {
+			fmt.Printf("  %s\n", u)
+		}
+		fmt.Printf("  Add them to %s to rename or skip them (\"\")\n", providerMapName(*providerMapFile))
+	}
+
+	// Ask the running backend what our accounts can actually call
+	if *live != "" {
+		url := liveModelsURL(*live)
+		fmt.Printf("Querying %s...\n", url)
+		liveFetch := &fetcher{client: fetch.client, retries: fetch.retries, backoff: fetch.backoff}
+		if key := endpoints.liveKey(*liveAPIKey); key != "" {
+			liveFetch.header = http.Header{"Authorization": {"Bearer " + key}}
+		}
+		data, err := liveFetch.get(url)
+		if err != nil {
+			return fmt.Errorf("query live models: %w", err)
+		}
+		liveModels, err := parseLiveModels(data)
+		if err != nil {
+			return fmt.Errorf("query live models from %s: %w", url, err)
+		}
+		added := markAvailability(models, liveModels, "live:"+url)
+		for provider := range models {
+			for i := range models[provider] {
+				if models[provider][i].Capabilities == nil {
+					enrichFromModelsDev(&models[provider][i], mt)
+				}
+			}
+			sort.Slice(models[provider], func(i, j int) bool {
+				return models[provider][i].ID < models[provider][j].ID
+			})
+		}
+		sources = append(sources, sourceEntry(url, data))
+		fmt.Printf("Backend lists %d models (%d not in the upstream definitions)\n", len(liveModels), len(added))
+	}
+
+	report := mt.report()
+	fmt.Printf("models.dev matches: %d unmatched, %d low confidence\n", len(report.Unmatched), len(report.LowConfidence))
+	if *matchReport != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		if *matchReport == "-" {
+			fmt.Println(string(data))
+		} else if *showDiff || *check {
+			// Neither mode writes files
+			fmt.Printf("Not writing match report to %s with -diff or -check\n", *matchReport)
+		} else if err := writeFileAtomic(*matchReport, data, 0644); err != nil {
+			return err
+		} else {
+			fmt.Printf("Written match report to: %s\n", *matchReport)
+		}
+	}
+
+	if overrides != nil {
+		unmatched, excluded := overrides.Apply(models)
+		fmt.Printf("Applied %s (%d models excluded)\n", *overridesFile, excluded)
+		for _, key := range unmatched {
+			fmt.Printf("  Warning: override for %s matches no model\n", key)
+		}
+	}
+
+	total := 0
+	for _, providerModels := range models {
+		total += len(providerModels)
+	}
+	if total == 0 {
+		return fmt.Errorf("no models found in the upstream definitions; keeping %s", *outputFile)
+	}
+
+	// Render every output before writing any, so a failure leaves them all as they were
+	config := CanonicalConfig{
+		Version: "2.0",
+		Sources: sources,
+		Models:  models,
+	}
+	var outputs []output
+	data, err = json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	outputs = append(outputs, output{catalogOutput, *outputFile, data, fmt.Sprintf("Written canonical config to: %s", *outputFile)})
+
+	clientModels := models
+	if *onlyAvailable {
+		clientModels = availableOnly(models)
+	}
+
+	if *factoryFile != "" {
+		factoryConfig := generateFactoryConfig(clientModels, endpoints, tiers)
+		data, err := json.MarshalIndent(factoryConfig, "", "  ")
+		if err != nil {
+			return err
+		}
+		outputs = append(outputs, output{factoryOutput, *factoryFile, data, fmt.Sprintf("Written Factory config to: %s (%d models)", *factoryFile, len(factoryConfig.CustomModels))})
+	}
+
+	if *opencodeFile != "" {
+		data, err := json.MarshalIndent(generateOpenCodeConfig(clientModels, endpoints, tiers), "", "  ")
+		if err != nil {
+			return err
+		}
+		outputs = append(outputs, output{openCodeOutput, *opencodeFile, data, fmt.Sprintf("Written OpenCode config to: %s", *opencodeFile)})
+	}
+
+	if *showDiff {
+		for _, out := range outputs {
+			lines := out.diff()
+			fmt.Printf("%s: %d change(s)\n", out.path, len(lines))
+			for _, line := range lines {
+				fmt.Printf("  %s\n", line)
+			}
+		}
+	}
+	if *check {
+		var stale []string
+		for _, out := range outputs {
+			if !out.upToDate() {
+				stale = append(stale, out.path)
+			}
+		}
+		if len(stale) > 0 {
+			return fmt.Errorf("stale: %s", strings.Join(stale, ", "))
+		}
+		fmt.Println("All outputs are up to date")
+		return nil
+	}
+	if *showDiff {
+		return nil
+	}
+
+	for _, out := range outputs {
+		if err := writeFileAtomic(out.path, out.data, 0644); err != nil {
+			return err
+		}
+		fmt.Println(out.message)
+	}
+
+	// Print summary
+	for provider, providerModels := range models {
+		fmt.Printf("  %s: %d models\n", provider, len(providerModels))
+	}
+	fmt.Printf("  Total: %d models\n", total)
+	return nil
+}
+
+// output is a rendered file waiting to be written.
+type output struct {
+	kind    outputKind
+	path    string
+	data    []byte
+	message string
+}
+
+type outputKind int
+
+const (
+	catalogOutput outputKind = iota
+	factoryOutput
+	openCodeOutput
+)
+
+func providerMapName(path string) string {
+	if path == "" {
+		return "a -provider-map file"
+	}
+	return path
+}
+
+// buildModelsDevIndex creates a lookup map by model ID across all providers
+func buildModelsDevIndex(api ModelsDevAPI) map[string]*ModelsDevModel {
+	index := make(map[string]indexedModelsDevModel)
+
+	providerIDs := make([]string, 0, len(api))
+	for providerID := range api {
+		providerIDs = append(providerIDs, providerID)
+	}
+	sort.Slice(providerIDs, func(i, j int) bool {
+		pi := modelsDevProviderPriority(providerIDs[i])
+		pj := modelsDevProviderPriority(providerIDs[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return providerIDs[i] < providerIDs[j]
+	})
+
+	for _, providerID := range providerIDs {
+		provider := api[providerID]
+		if provider == nil || provider.Models == nil {
+			continue
+		}
+
+		modelIDs := make([]string, 0, len(provider.Models))
+		for modelID := range provider.Models {
+			modelIDs = append(modelIDs, modelID)
+		}
+		sort.Strings(modelIDs)
+
+		for _, modelID := range modelIDs {
+			model := provider.Models[modelID]
+			if model == nil {
+				continue
+			}
+			model.Provider = providerID
+			if model.ID == "" {
+				model.ID = modelID
+			}
+
+			upsertIndexedModel(index, modelID, providerID, model)
+
+			// Also index by normalized ID (lowercase, no version suffix)
+			normalized := normalizeModelID(modelID)
+			upsertIndexedModel(index, normalized, providerID, model)
+		}
+	}
+
+	flat := make(map[string]*ModelsDevModel, len(index))
+	for key, item := range index {
+		flat[key] = item.Model
+	}
+	return flat
+}
+
+func upsertIndexedModel(index map[string]indexedModelsDevModel, key, providerID string, model *ModelsDevModel) {
+	current, exists := index[key]
+	if !exists {
+		index[key] = indexedModelsDevModel{
+			Provider: providerID,
+			Model:    model,
+		}
+		return
+	}
+
+	if shouldReplaceIndexedModel(current.Provider, current.Model, providerID, model) {
+		index[key] = indexedModelsDevModel{
+			Provider: providerID,
+			Model:    model,
+		}
+	}
+}
+
+func shouldReplaceIndexedModel(currentProvider string, currentModel *ModelsDevModel, candidateProvider string, candidateModel *ModelsDevModel) bool {
+	currentPriority := modelsDevProviderPriority(currentProvider)
+	candidatePriority := modelsDevProviderPriority(candidateProvider)
+
+	if candidatePriority != currentPriority {
+		return candidatePriority < currentPriority
+	}
+
+	currentScore := modelsDevModelQualityScore(currentModel)
+	candidateScore := modelsDevModelQualityScore(candidateModel)
+	if candidateScore != currentScore {
+		return candidateScore > currentScore
+	}
+
+	// Final deterministic tie-breaker.
+	return candidateProvider < currentProvider
+}
+
+func modelsDevModelQualityScore(model *ModelsDevModel) int {
+	if model == nil {
+		return 0
+	}
+
+	score := 0
+	if model.Name != "" {
+		score++
+	}
+	if model.Family != "" {
+		score++
+	}
+	if model.Modalities != nil {
+		score += 2
+		score += len(model.Modalities.Input)
+		score += len(model.Modalities.Output)
+	}
+	if model.Limit != nil && len(model.Limit) > 0 {
+		score += 2 + len(model.Limit)
+	}
+	if model.Cost != nil && len(model.Cost) > 0 {
+		score += 2 + len(model.Cost)
+	}
+	if model.Attachment {
+		score++
+	}
+	if model.Reasoning {
+		score++
+	}
+	if model.ToolCall {
+		score++
+	}
+	if model.StructuredOutput {
+		score++
+	}
+	if model.Temperature {
+		score++
+	}
+	return score
+}
+
+func modelsDevProviderPriority(providerID string) int {
+	switch providerID {
+	case "openai":
+		return 10
+	case "anthropic":
+		return 20
+	case "google", "google-vertex":
+		return 30
+	case "google-vertex-anthropic":
+		return 40
+	case "github-copilot", "github-models":
+		return 50
+	case "amazon-bedrock":
+		return 60
+	case "alibaba", "alibaba-cn":
+		return 70
+	case "iflowcn":
+		return 80
+	default:
+		return 1000
+	}
+}
+
+func normalizeModelID(id string) string {
+	// Remove date suffixes like -20250929
+	re := regexp.MustCompile(`-\d{8}$`)
+	normalized := re.ReplaceAllString(id, "")
+	return strings.ToLower(normalized)
+}
+
+// parseAndEnrichModels evaluates every upstream model function and enriches
+// each model from models.dev. providers maps functions to catalog keys;
+// functions missing from it get a derived key and are listed in unmapped
+// as "GetFooModels → foo".
+func parseAndEnrichModels(files []SourceFile, providers map[string]string, mt *matcher) (models map[string][]Model, unmapped []string, err error) {
+	defs, err := parseDefinitions(files)
+	if err != nil {
+		return nil, nil, err
+	}
+	models = make(map[string][]Model)
+
+	for _, funcName := range defs.modelSources() {
+		provider, ok := providers[funcName]
+		if !ok {
+			provider = deriveProvider(funcName)
+			unmapped = append(unmapped, fmt.Sprintf("%s → %s", funcName, provider))
+		}
+		if provider == "" {
+			continue
+		}
+		funcModels, err := defs.sourceModels(funcName, provider)
+		if err != nil {
+			return nil, nil, err
+		}
+		models[provider] = appendNewModels(models[provider], funcModels)
+	}
+
+	for provider := range models {
+		for i := range models[provider] {
+			enrichFromModelsDev(&models[provider][i], mt)
+		}
+		sort.Slice(models[provider], func(i, j int) bool {
+			return models[provider][i].ID < models[provider][j].ID
+		})
+	}
+
+	return models, unmapped, nil
+}
+
+// appendNewModels adds the models whose IDs are not in list yet, for
+// functions that share a provider key.
+func appendNewModels(list, models []Model) []Model {
+	seen := make(map[string]bool, len(list))
+	for _, m := range list {
+		seen[m.ID] = true
+	}
+	for _, m := range models {
+		if !seen[m.ID] {
+			seen[m.ID] = true
+			list = append(list, m)
+		}
+	}
+	return list
+}
+
+func enrichFromModelsDev(model *Model, mt *matcher) {
+	mdModel, result := mt.match(model)
+
+	if mdModel == nil {
+		// Set defaults
+		model.Modalities = inferModalities(model.ID, model.Type)
+		model.Capabilities = &Capabilities{
+			ToolCall:    true,
+			Temperature: true,
+		}
+		model.setSource("modalities", sourceInferred)
+		model.setSource("capabilities", sourceInferred)
+		return
+	}
+
+	// Enrich from models.dev
+	source := "models.dev:" + result.Match
+	switch result.Method {
+	case matchNormalized:
+		source += " (matched as " + normalizeModelID(model.ID) + ")"
+	case matchFuzzy:
+		source += fmt.Sprintf(" (fuzzy match, score %.2f)", result.Score)
+	case matchPinned:
+		source += " (pinned)"
+	}
+	if model.DisplayName == "" || model.DisplayName == model.ID {
+		model.DisplayName = mdModel.Name
+		model.setSource("display_name", source)
+	}
+	model.Family = mdModel.Family
+	if model.Family != "" {
+		model.setSource("family", source)
+	}
+
+	if mdModel.Modalities != nil {
+		model.Modalities = mdModel.Modalities
+		model.setSource("modalities", source)
+	} else {
+		model.Modalities = inferModalities(model.ID, model.Type)
+		model.setSource("modalities", sourceInferred)
+	}
+
+	model.Capabilities = &Capabilities{
+		Reasoning:        mdModel.Reasoning,
+		ToolCall:         mdModel.ToolCall,
+		StructuredOutput: mdModel.StructuredOutput,
+		Attachment:       mdModel.Attachment,
+		Temperature:      mdModel.Temperature,
+	}
+	model.setSource("capabilities", source)
+
+	if mdModel.Limit != nil {
+		if ctx, ok := mdModel.Limit["context"]; ok && model.ContextLength == 0 {
+			model.ContextLength = ctx
+			model.setSource("context_length", source)
+		}
+		if out, ok := mdModel.Limit["output"]; ok && model.MaxCompletionTokens == 0 {
+			model.MaxCompletionTokens = out
+			model.setSource("max_completion_tokens", source)
+		}
+	}
+
+	if mdModel.Cost != nil {
+		model.Cost = &Cost{
+			Input:      mdModel.Cost["input"],
+			Output:     mdModel.Cost["output"],
+			CacheRead:  mdModel.Cost["cache_read"],
+			CacheWrite: mdModel.Cost["cache_write"],
+		}
+		model.setSource("cost", source)
+	}
+}
+
+func formatDisplayName(id string) string {
+	parts := strings.Split(id, "-")
+	for i, p := range parts {
+		if len(p) > 0 {
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func inferModalities(modelID, modelType string) *Modalities {
+	m := &Modalities{
+		Input:  []string{"text"},
+		Output: []string{"text"},
+	}
+
+	if strings.Contains(modelID, "vision") || strings.Contains(modelID, "-vl-") ||
+		strings.Contains(modelID, "image") || strings.Contains(modelID, "gemini") ||
+		strings.Contains(modelID, "gpt-5") || strings.Contains(modelID, "gpt-4") ||
+		strings.Contains(modelID, "claude") {
+		m.Input = []string{"text", "image"}
+	}
+
+	if strings.Contains(modelID, "imagen") || strings.Contains(modelID, "image-generate") {
+		m.Output = []string{"image"}
+	}
+
+	return m
+}
+
+func generateFactoryConfig(models map[string][]Model, endpoints Endpoints, tiers []thinking.Tier) FactoryConfig {
+	var factoryModels []FactoryModel
+
+	// Provider config: provider value must be "anthropic", "openai", or "generic-chat-completion-api"
+	// - anthropic: for Anthropic Messages API (Claude via direct anthropic endpoint)
+	// - openai: for OpenAI Responses API (GPT-5, Codex - newest models)
+	// - generic-chat-completion-api: for OpenAI Chat Completions API (most other providers)
+	// path is appended to the provider's proxy URL.
+	providerConfig := map[string]struct {
+		path     string
+		provider string
+		include  bool
+	}{
+		"claude":         {path: "", provider: "anthropic", include: true},
+		"codex":          {path: "/v1", provider: "openai", include: true},
+		"gemini":         {path: "/v1", provider: "generic-chat-completion-api", include: true},
+		"gemini-cli":     {path: "/v1", provider: "generic-chat-completion-api", include: false},
+		"antigravity":    {path: "/v1", provider: "generic-chat-completion-api", include: true},
+		"qwen":           {path: "/v1", provider: "generic-chat-completion-api", include: true},
+		"github-copilot": {path: "/v1", provider: "generic-chat-completion-api", include: true},
+		"kiro":           {path: "/v1", provider: "generic-chat-completion-api", include: true},
+	}
+	apiKey := endpoints.factoryKey()
+
+	// Human-readable prefixes for display names
+	displayPrefixes := map[string]string{
+		"claude":         "Claude",
+		"codex":          "OpenAI",
+		"gemini":         "Gemini",
+		"gemini-cli":     "Gemini",
+		"antigravity":    "AG",
+		"qwen":           "Qwen",
+		"github-copilot": "Copilot",
+		"kiro":           "Kiro",
+	}
+
+	for providerKey, providerModels := range models {
+		cfg, ok := providerConfig[providerKey]
+		if !ok || !cfg.include {
+			continue
+		}
+
+		prefix := displayPrefixes[providerKey]
+		if prefix == "" {
+			prefix = providerKey
+		}
+		baseURL := endpoints.root(providerKey) + cfg.path
+
+		for _, m := range providerModels {
+
+			// Check if model supports images
+			supportsImages := false
+			if m.Modalities != nil {
+				for _, mod := range m.Modalities.Input {
+					if mod == "image" {
+						supportsImages = true
+						break
+					}
+				}
+			}
+
+			fm := FactoryModel{
+				Model:           m.ID,
+				DisplayName:     fmt.Sprintf("[%s] %s", prefix, m.DisplayName),
+				BaseURL:         baseURL,
+				APIKey:          apiKey,
+				Provider:        cfg.provider,
+				MaxOutputTokens: m.MaxCompletionTokens,
+				SupportsImages:  supportsImages,
+			}
+			factoryModels = append(factoryModels, fm)
+
+			// Add thinking variants for Claude models. ThinkingProxy reads
+			// their -thinking-N suffix and caps it.
+			if m.Provider == "claude" && m.Thinking != nil && m.Thinking.Supported {
+				r := thinkingRange(m.Thinking)
+				if r.Max == 0 || r.Max > thinking.MaxBudget {
+					r.Max = thinking.MaxBudget
+				}
+				for _, budget := range thinking.Budgets(tiers, r) {
+					// The base model already has thinking off
+					if budget.Tokens == 0 {
+						continue
+					}
+					fm := FactoryModel{
+						Model:           fmt.Sprintf("%s-thinking-%d", m.ID, budget.Tokens),
+						DisplayName:     fmt.Sprintf("[%s] %s (Thinking %s)", prefix, m.DisplayName, strings.Title(budget.Tier)),
+						BaseURL:         baseURL,
+						APIKey:          apiKey,
+						Provider:        cfg.provider,
+						MaxOutputTokens: m.MaxCompletionTokens,
+						SupportsImages:  supportsImages,
+					}
+					factoryModels = append(factoryModels, fm)
+				}
+			}
+
+			// Add reasoning effort variants for Codex/OpenAI models with thinking levels
+			if m.Provider == "codex" && m.Thinking != nil && len(m.Thinking.Levels) > 0 {
+				for _, level := range m.Thinking.Levels {
+					// Skip "none" level as it's the default/base model
+					if level == "none" {
+						continue
+					}
+					fm := FactoryModel{
+						Model:           fmt.Sprintf("%s(%s)", m.ID, level),
+						DisplayName:     fmt.Sprintf("[%s] %s (%s)", prefix, m.DisplayName, strings.Title(level)),
+						BaseURL:         baseURL,
+						APIKey:          apiKey,
+						Provider:        cfg.provider,
+						MaxOutputTokens: m.MaxCompletionTokens,
+						SupportsImages:  supportsImages,
+					}
+					factoryModels = append(factoryModels, fm)
+				}
+			}
+		}
+	}
+
+	sort.Slice(factoryModels, func(i, j int) bool {
+		return factoryModels[i].DisplayName < factoryModels[j].DisplayName
+	})
+
+	return FactoryConfig{CustomModels: factoryModels}
+}
+
+// OpenCode config types
+type OpenCodeConfig struct {
+	Schema   string                       `json:"$schema"`
+	Provider map[string]*OpenCodeProvider `json:"provider"`
+}
+
+type OpenCodeProvider struct {
+	Name    string                    `json:"name,omitempty"`
+	Type    string                    `json:"type,omitempty"`
+	BaseURL string                    `json:"baseURL,omitempty"`
+	APIKey  string                    `json:"apiKey,omitempty"`
+	Models  map[string]*OpenCodeModel `json:"models"`
+}
+
+type OpenCodeModel struct {
+	Name       string                      `json:"name,omitempty"`
+	Options    *OpenCodeOptions            `json:"options,omitempty"`
+	Variants   map[string]*OpenCodeVariant `json:"variants,omitempty"`
+	Modalities *Modalities                 `json:"modalities,omitempty"`
+}
+
+type OpenCodeOptions struct {
+	Thinking         *OpenCodeThinking `json:"thinking,omitempty"`
+	ReasoningEffort  string            `json:"reasoningEffort,omitempty"`
+	TextVerbosity    string            `json:"textVerbosity,omitempty"`
+	ReasoningSummary string            `json:"reasoningSummary,omitempty"`
+}
+
+type OpenCodeThinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budgetTokens,omitempty"`
+}
+
+type OpenCodeVariant struct {
+	ReasoningEffort  string            `json:"reasoningEffort,omitempty"`
+	TextVerbosity    string            `json:"textVerbosity,omitempty"`
+	ReasoningSummary string            `json:"reasoningSummary,omitempty"`
+	Thinking         *OpenCodeThinking `json:"thinking,omitempty"`
+}
+
+func generateOpenCodeConfig(models map[string][]Model, endpoints Endpoints, tiers []thinking.Tier) OpenCodeConfig {
+	config := OpenCodeConfig{
+		Schema:   "https://opencode.ai/config.json",
+		Provider: make(map[string]*OpenCodeProvider),
+	}
+	apiKey := endpoints.openCodeKey()
+
+	claudeProvider := &OpenCodeProvider{
+		Name:    "AI Proxy (Claude)",
+		Type:    "anthropic",
+		BaseURL: endpoints.root("claude"),
+		APIKey:  apiKey,
+		Models:  make(map[string]*OpenCodeModel),
+	}
+
+	openaiProvider := &OpenCodeProvider{
+		Name:    "AI Proxy (OpenAI)",
+		Type:    "openai",
+		BaseURL: endpoints.root("") + "/v1",
+		APIKey:  apiKey,
+		Models:  make(map[string]*OpenCodeModel),
+	}
+
+	// Providers with their own URL get their own OpenCode provider
+	ownProviders := make(map[string]*OpenCodeProvider)
+	openaiFor := func(providerKey string) *OpenCodeProvider {
+		if endpoints.ProviderURLs[providerKey] == "" {
+			return openaiProvider
+		}
+		if ownProviders[providerKey] == nil {
+			ownProviders[providerKey] = &OpenCodeProvider{
+				Name:    "AI Proxy (" + providerKey + ")",
+				Type:    "openai",
+				BaseURL: endpoints.root(providerKey) + "/v1",
+				APIKey:  apiKey,
+				Models:  make(map[string]*OpenCodeModel),
+			}
+		}
+		return ownProviders[providerKey]
+	}
+
+	// Process Claude models
+	if claudeModels, ok := models["claude"]; ok {
+		for _, m := range claudeModels {
+			ocModel := &OpenCodeModel{
+				Name:       m.DisplayName,
+				Modalities: m.Modalities,
+			}
+
+			if m.Thinking != nil && m.Thinking.Supported {
+				ocModel.Variants = budgetVariants(m.Thinking, tiers)
+			}
+
+			claudeProvider.Models[m.ID] = ocModel
+		}
+	}
+
+	// Process Codex/OpenAI models
+	if codexModels, ok := models["codex"]; ok {
+		for _, m := range codexModels {
+			ocModel := &OpenCodeModel{
+				Name:       m.DisplayName,
+				Modalities: m.Modalities,
+			}
+
+			if m.Thinking != nil && len(m.Thinking.Levels) > 0 {
+				ocModel.Variants = make(map[string]*OpenCodeVariant)
+				for _, level := range m.Thinking.Levels {
+					ocModel.Variants[level] = &OpenCodeVariant{
+						ReasoningEffort:  level,
+						TextVerbosity:    "low",
+						ReasoningSummary: "auto",
+					}
+				}
+			}
+
+			openaiFor("codex").Models[m.ID] = ocModel
+		}
+	}
+
+	// Process other providers
+	for _, providerKey := range []string{"gemini", "antigravity", "kiro", "github-copilot", "qwen"} {
+		if providerModels, ok := models[providerKey]; ok {
+			for _, m := range providerModels {
+				ocModel := &OpenCodeModel{
+					Name:       m.DisplayName,
+					Modalities: m.Modalities,
+				}
+
+				if m.Thinking != nil && m.Thinking.Supported {
+					ocModel.Variants = budgetVariants(m.Thinking, tiers)
+				}
+
+				openaiFor(providerKey).Models[m.ID] = ocModel
+			}
+		}
+	}
+
+	if len(claudeProvider.Models) > 0 {
+		config.Provider["ai-proxy-claude"] = claudeProvider
+	}
+	if len(openaiProvider.Models) > 0 {
+		config.Provider["ai-proxy-openai"] = openaiProvider
+	}
+	for providerKey, p := range ownProviders {
+		config.Provider["ai-proxy-"+providerKey] = p
+	}
+
+	return config
+}
+
+// thinkingRange is t's budget range for the shared thinking tiers.
+func thinkingRange(t *Thinking) thinking.Range {
+	return thinking.Range{Min: t.Min, Max: t.Max, ZeroAllowed: t.ZeroAllowed}
+}
+
+// budgetVariants are OpenCode variants setting each tier's budget within
+// t's range; an off tier disables thinking.
+func budgetVariants(t *Thinking, tiers []thinking.Tier) map[string]*OpenCodeVariant {
+	variants := make(map[string]*OpenCodeVariant)
+	for _, budget := range thinking.Budgets(tiers, thinkingRange(t)) {
+		th := &OpenCodeThinking{Type: "enabled", BudgetTokens: budget.Tokens}
+		if budget.Tokens == 0 {
+			th.Type = "disabled"
+		}
+		variants[budget.Tier] = &OpenCodeVariant{Thinking: th}
+	}
+	return variants
+}
@@ -0,0 +1,310 @@
+package modelsync
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/theadriann/vibeproxyplus/internal/thinking"
+)
+
+// runSync runs model-sync as a command would, without exiting on bad flags.
+func runSync(args []string, opts Options) error {
+	return Run(flag.NewFlagSet("model-sync", flag.ContinueOnError), args, opts)
+}
+
+func TestRunRegistersFlagsOnCallerFlagSet(t *testing.T) {
+	var out bytes.Buffer
+	fs := flag.NewFlagSet("vibeproxy sync-models", flag.ContinueOnError)
+	fs.SetOutput(&out)
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), "custom usage") }
+	if err := Run(fs, []string{"-h"}, Options{}); err != flag.ErrHelp {
+		t.Fatalf("err = %v, want flag.ErrHelp", err)
+	}
+	if !strings.Contains(out.String(), "custom usage") {
+		t.Errorf("usage output = %q, want the caller's usage", out.String())
+	}
+	for _, name := range []string{"output", "factory", "match-report", "base-url"} {
+		if fs.Lookup(name) == nil {
+			t.Errorf("flag -%s not registered on the caller's flag set", name)
+		}
+	}
+}
+
+func TestBuildModelsDevIndex_PrefersAuthoritativeProvider(t *testing.T) {
+	api := ModelsDevAPI{
+		"github-copilot": {
+			ID: "github-copilot",
+			Models: map[string]*ModelsDevModel{
+				"gpt-5.2-codex": {
+					ID:   "gpt-5.2-codex",
+					Name: "Copilot GPT-5.2 Codex",
+					Cost: map[string]float64{
+						"input": 999,
+					},
+				},
+			},
+		},
+		"openai": {
+			ID: "openai",
+			Models: map[string]*ModelsDevModel{
+				"gpt-5.2-codex": {
+					ID:   "gpt-5.2-codex",
+					Name: "OpenAI GPT-5.2 Codex",
+					Cost: map[string]float64{
+						"input": 1.75,
+					},
+				},
+			},
+		},
+	}
+
+	index := buildModelsDevIndex(api)
+	got := index["gpt-5.2-codex"]
+	if got == nil {
+		t.Fatal("missing index entry for gpt-5.2-codex")
+	}
+	if got.Name != "OpenAI GPT-5.2 Codex" {
+		t.Fatalf("got model name %q, want %q", got.Name, "OpenAI GPT-5.2 Codex")
+	}
+}
+
+func TestBuildModelsDevIndex_PopulatesNormalizedID(t *testing.T) {
+	api := ModelsDevAPI{
+		"anthropic": {
+			ID: "anthropic",
+			Models: map[string]*ModelsDevModel{
+				"claude-sonnet-4-5-20250929": {
+					ID:   "claude-sonnet-4-5-20250929",
+					Name: "Claude Sonnet 4.5",
+				},
+			},
+		},
+	}
+
+	index := buildModelsDevIndex(api)
+	got := index["claude-sonnet-4-5"]
+	if got == nil {
+		t.Fatal("missing normalized index entry for claude-sonnet-4-5")
+	}
+	if got.ID != "claude-sonnet-4-5-20250929" {
+		t.Fatalf("got normalized model id %q, want %q", got.ID, "claude-sonnet-4-5-20250929")
+	}
+}
+
+func TestLoadModelIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.json")
+	if err := os.WriteFile(path, []byte(`{"models":{"claude":[{"id":"a"},{"id":"b"}],"codex":[{"id":"c"}]}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := LoadModelIDs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids["claude"]) != 2 || ids["codex"][0] != "c" {
+		t.Errorf("LoadModelIDs = %v", ids)
+	}
+}
+
+func TestRunPinsRef(t *testing.T) {
+	files := map[string]string{
+		"/v6.6.1-0/internal/registry/model_definitions.go":             "package registry\n",
+		"/v6.6.1-0/internal/registry/model_definitions_static_data.go": "package registry\nfunc GetClaudeModels() []*ModelInfo {\n\treturn []*ModelInfo{{ID: \"claude-sonnet-4-5\"}}\n}\n",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+	defer func(orig string) { upstreamRawURL = orig }(upstreamRawURL)
+	upstreamRawURL = srv.URL
+
+	dir := t.TempDir()
+	modelsDev := filepath.Join(dir, "api.json")
+	if err := os.WriteFile(modelsDev, []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "models.json")
+
+	cache := filepath.Join(dir, "cache")
+	err := runSync([]string{"-local-modelsdev", modelsDev, "-cache-dir", cache}, Options{Output: output, Ref: "v6.6.1-0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(output)
+	var config CanonicalConfig
+	json.Unmarshal(data, &config)
+
+	static := files["/v6.6.1-0/internal/registry/model_definitions_static_data.go"]
+	want := fmt.Sprintf("%s/v6.6.1-0/internal/registry/model_definitions_static_data.go sha256:%x", srv.URL, sha256.Sum256([]byte(static)))
+	if len(config.Sources) != 3 || config.Sources[1] != want {
+		t.Errorf("Sources = %v, want [1] = %s", config.Sources, want)
+	}
+	if len(config.Models["claude"]) != 1 {
+		t.Errorf("models = %v", config.Models)
+	}
+
+	// A ref upstream doesn't have fails instead of writing an empty catalog
+	err = runSync([]string{"-local-modelsdev", modelsDev, "-cache-dir", cache, "-ref", "v0.0.0"}, Options{Output: output})
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("unknown ref: err = %v", err)
+	}
+}
+
+func TestRunKeepsOutputsOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	defs := filepath.Join(dir, "defs.go")
+	if err := os.WriteFile(defs, []byte("package registry\nfunc GetClaudeModels() []*ModelInfo {\n\treturn []*ModelInfo{{ID: \"a\"}}\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "models.json")
+	if err := os.WriteFile(output, []byte("previous"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"error page":   "<html>502 Bad Gateway</html>",
+		"empty object": "{}",
+	}
+	for name, modelsDev := range tests {
+		api := filepath.Join(dir, "api.json")
+		if err := os.WriteFile(api, []byte(modelsDev), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		args := []string{"-local-modeldefs", defs, "-local-modelsdev", api}
+		if name == "empty object" {
+			// Valid sources, but no models: still not written
+			if err := os.WriteFile(defs, []byte("package registry\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := runSync(args, Options{Output: output}); err == nil {
+			t.Errorf("%s: Run succeeded", name)
+		}
+		if data, _ := os.ReadFile(output); string(data) != "previous" {
+			t.Errorf("%s: output overwritten with %q", name, data)
+		}
+	}
+}
+
+func TestProvenance(t *testing.T) {
+	mt, err := newMatcher(ModelsDevAPI{
+		"anthropic": {
+			ID: "anthropic",
+			Models: map[string]*ModelsDevModel{
+				"claude-sonnet-4-5-20250929": {
+					Name:   "Claude Sonnet 4.5",
+					Family: "claude-sonnet",
+					Limit:  map[string]int{"context": 200000, "output": 64000},
+					Cost:   map[string]float64{"input": 3},
+				},
+			},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := `package registry
+func GetClaudeModels() []*ModelInfo {
+	return []*ModelInfo{
+		{ID: "claude-sonnet-4-5", DisplayName: "Claude Sonnet 4.5", MaxCompletionTokens: 32000, Thinking: &ThinkingSupport{Max: 64000}},
+		{ID: "claude-mystery"},
+	}
+}
+`
+	models, _, err := parseAndEnrichModels([]SourceFile{{Name: "defs.go", Data: []byte(src)}}, defaultProviders, mt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	markAvailability(models, []liveModel{{ID: "claude-sonnet-4-5"}}, "live:http://backend/v1/models")
+
+	byID := make(map[string]Model)
+	for _, m := range models["claude"] {
+		byID[m.ID] = m
+	}
+
+	const md = "models.dev:anthropic/claude-sonnet-4-5-20250929 (matched as claude-sonnet-4-5)"
+	want := map[string]string{
+		"display_name":          "upstream:GetClaudeModels",
+		"max_completion_tokens": "upstream:GetClaudeModels",
+		"thinking":              "upstream:GetClaudeModels",
+		"context_length":        md,
+		"family":                md,
+		"cost":                  md,
+		"capabilities":          md,
+		"modalities":            sourceInferred,
+		"available":             "live:http://backend/v1/models",
+	}
+	if got := byID["claude-sonnet-4-5"].Provenance; !reflect.DeepEqual(got, want) {
+		t.Errorf("sonnet provenance =\n%v\nwant\n%v", got, want)
+	}
+
+	mystery := byID["claude-mystery"].Provenance
+	for _, field := range []string{"display_name", "modalities", "capabilities"} {
+		if mystery[field] != sourceInferred {
+			t.Errorf("claude-mystery %s from %q, want inferred", field, mystery[field])
+		}
+	}
+}
+
+func TestThinkingVariants(t *testing.T) {
+	models := map[string][]Model{
+		"claude": {{ID: "claude-sonnet-4-5", Provider: "claude", DisplayName: "Claude Sonnet 4.5",
+			Thinking: &Thinking{Supported: true, Min: 1024, Max: 128000, ZeroAllowed: true}}},
+		"gemini": {{ID: "gemini-2.5-flash", Provider: "gemini", DisplayName: "Gemini 2.5 Flash",
+			Thinking: &Thinking{Supported: true, Max: 24576, ZeroAllowed: true}}},
+	}
+	tiers := []thinking.Tier{{Name: "off"}, {Name: "low", Level: 0.3}, {Name: "max", Level: 1}}
+
+	var factory []string
+	for _, m := range generateFactoryConfig(models, Endpoints{}, tiers).CustomModels {
+		factory = append(factory, m.Model+" "+m.DisplayName)
+	}
+	// Suffix budgets are capped at what ThinkingProxy accepts
+	wantFactory := []string{
+		"claude-sonnet-4-5 [Claude] Claude Sonnet 4.5",
+		"claude-sonnet-4-5-thinking-3072 [Claude] Claude Sonnet 4.5 (Thinking Low)",
+		"claude-sonnet-4-5-thinking-32768 [Claude] Claude Sonnet 4.5 (Thinking Max)",
+		"gemini-2.5-flash [Gemini] Gemini 2.5 Flash",
+	}
+	if !reflect.DeepEqual(factory, wantFactory) {
+		t.Errorf("factory models =\n%v\nwant\n%v", factory, wantFactory)
+	}
+
+	opencode := generateOpenCodeConfig(models, Endpoints{}, tiers)
+	variants := func(provider, id string) map[string]OpenCodeThinking {
+		got := make(map[string]OpenCodeThinking)
+		for name, v := range opencode.Provider[provider].Models[id].Variants {
+			got[name] = *v.Thinking
+		}
+		return got
+	}
+	if got, want := variants("ai-proxy-claude", "claude-sonnet-4-5"), map[string]OpenCodeThinking{
+		"off": {Type: "disabled"},
+		"low": {Type: "enabled", BudgetTokens: 4096},
+		"max": {Type: "enabled", BudgetTokens: 128000},
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("claude variants = %v, want %v", got, want)
+	}
+	if got, want := variants("ai-proxy-openai", "gemini-2.5-flash"), map[string]OpenCodeThinking{
+		"off": {Type: "disabled"},
+		"low": {Type: "enabled", BudgetTokens: 3072},
+		"max": {Type: "enabled", BudgetTokens: 24576},
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("gemini variants = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,238 @@
+package modelsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Overrides is models.overrides.json: hand corrections applied to the
+// catalog after enrichment, so they survive every sync.
+type Overrides struct {
+	// Exclude drops matching models unless Include matches them too.
+	// Patterns are globs on "provider/id", or on the ID when they have no
+	// slash.
+	Exclude []string `json:"exclude,omitempty"`
+	Include []string `json:"include,omitempty"`
+	// Providers patch every model of a provider.
+	Providers map[string]ModelPatch `json:"providers,omitempty"`
+	// Models patch single models by "id" or "provider/id"; the latter is
+	// applied last.
+	Models map[string]ModelPatch `json:"models,omitempty"`
+	// Matches pin catalog models ("id" or "provider/id") to a models.dev
+	// "provider/id"; "" stops a model from matching anything.
+	Matches map[string]string `json:"matches,omitempty"`
+}
+
+// ModelPatch sets the fields it names and leaves the rest alone.
+type ModelPatch struct {
+	DisplayName         *string            `json:"display_name,omitempty"`
+	ContextLength       *int               `json:"context_length,omitempty"`
+	MaxCompletionTokens *int               `json:"max_completion_tokens,omitempty"`
+	Modalities          *Modalities        `json:"modalities,omitempty"`
+	Cost                *CostPatch         `json:"cost,omitempty"`
+	Thinking            *ThinkingPatch     `json:"thinking,omitempty"`
+	Capabilities        *CapabilitiesPatch `json:"capabilities,omitempty"`
+}
+
+type CostPatch struct {
+	Input      *float64 `json:"input,omitempty"`
+	Output     *float64 `json:"output,omitempty"`
+	CacheRead  *float64 `json:"cache_read,omitempty"`
+	CacheWrite *float64 `json:"cache_write,omitempty"`
+}
+
+type ThinkingPatch struct {
+	Supported   *bool    `json:"supported,omitempty"`
+	Min         *int     `json:"min,omitempty"`
+	Max         *int     `json:"max,omitempty"`
+	ZeroAllowed *bool    `json:"zero_allowed,omitempty"`
+	Levels      []string `json:"levels,omitempty"`
+}
+
+type CapabilitiesPatch struct {
+	Reasoning        *bool `json:"reasoning,omitempty"`
+	ToolCall         *bool `json:"tool_call,omitempty"`
+	StructuredOutput *bool `json:"structured_output,omitempty"`
+	Attachment       *bool `json:"attachment,omitempty"`
+	Temperature      *bool `json:"temperature,omitempty"`
+}
+
+// LoadOverrides reads an overrides file. Unknown keys are errors, so a
+// typo doesn't silently patch nothing.
+func LoadOverrides(file string) (*Overrides, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var o Overrides
+	if err := dec.Decode(&o); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", file, err)
+	}
+	for _, pattern := range append(append([]string(nil), o.Exclude...), o.Include...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid %s: bad pattern %q", file, pattern)
+		}
+	}
+	return &o, nil
+}
+
+// Apply filters and patches models in place. It returns the model keys in
+// Models that matched nothing, and how many models were excluded.
+func (o *Overrides) Apply(models map[string][]Model) (unmatched []string, excluded int) {
+	matched := make(map[string]bool)
+	for provider, list := range models {
+		kept := list[:0]
+		for _, m := range list {
+			if matchAny(o.Exclude, provider, m.ID) && !matchAny(o.Include, provider, m.ID) {
+				excluded++
+				continue
+			}
+			if patch, ok := o.Providers[provider]; ok {
+				patch.apply(&m)
+			}
+			for _, key := range []string{m.ID, provider + "/" + m.ID} {
+				if patch, ok := o.Models[key]; ok {
+					patch.apply(&m)
+					matched[key] = true
+				}
+			}
+			kept = append(kept, m)
+		}
+		if len(kept) == 0 {
+			delete(models, provider)
+		} else {
+			models[provider] = kept
+		}
+	}
+
+	for key := range o.Models {
+		if !matched[key] {
+			unmatched = append(unmatched, key)
+		}
+	}
+	sort.Strings(unmatched)
+	return unmatched, excluded
+}
+
+func matchAny(patterns []string, provider, id string) bool {
+	for _, pattern := range patterns {
+		name := id
+		if strings.Contains(pattern, "/") {
+			name = provider + "/" + id
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// apply sets the patched fields on m and marks them as overrides in its
+// provenance.
+func (p ModelPatch) apply(m *Model) {
+	set := func(field string) {
+		m.setSource(field, sourceOverride)
+	}
+
+	if p.DisplayName != nil {
+		m.DisplayName = *p.DisplayName
+		set("display_name")
+	}
+	if p.ContextLength != nil {
+		m.ContextLength = *p.ContextLength
+		set("context_length")
+	}
+	if p.MaxCompletionTokens != nil {
+		m.MaxCompletionTokens = *p.MaxCompletionTokens
+		set("max_completion_tokens")
+	}
+	if p.Modalities != nil {
+		modalities := *p.Modalities
+		m.Modalities = &modalities
+		set("modalities")
+	}
+
+	if c := p.Cost; c != nil {
+		cost := Cost{}
+		if m.Cost != nil {
+			cost = *m.Cost
+		}
+		for field, v := range map[string]struct {
+			src *float64
+			dst *float64
+		}{
+			"input":       {c.Input, &cost.Input},
+			"output":      {c.Output, &cost.Output},
+			"cache_read":  {c.CacheRead, &cost.CacheRead},
+			"cache_write": {c.CacheWrite, &cost.CacheWrite},
+		} {
+			if v.src != nil {
+				*v.dst = *v.src
+				set("cost." + field)
+			}
+		}
+		m.Cost = &cost
+	}
+
+	if t := p.Thinking; t != nil {
+		thinking := Thinking{Supported: true}
+		if m.Thinking != nil {
+			thinking = *m.Thinking
+		}
+		if t.Supported != nil {
+			thinking.Supported = *t.Supported
+			set("thinking.supported")
+		}
+		if t.Min != nil {
+			thinking.Min = *t.Min
+			set("thinking.min")
+		}
+		if t.Max != nil {
+			thinking.Max = *t.Max
+			set("thinking.max")
+		}
+		if t.ZeroAllowed != nil {
+			thinking.ZeroAllowed = *t.ZeroAllowed
+			set("thinking.zero_allowed")
+		}
+		if t.Levels != nil {
+			thinking.Levels = append([]string(nil), t.Levels...)
+			set("thinking.levels")
+		}
+		if thinking.Supported {
+			m.Thinking = &thinking
+		} else {
+			m.Thinking = nil
+		}
+	}
+
+	if c := p.Capabilities; c != nil {
+		caps := Capabilities{}
+		if m.Capabilities != nil {
+			caps = *m.Capabilities
+		}
+		for field, v := range map[string]struct {
+			src *bool
+			dst *bool
+		}{
+			"reasoning":         {c.Reasoning, &caps.Reasoning},
+			"tool_call":         {c.ToolCall, &caps.ToolCall},
+			"structured_output": {c.StructuredOutput, &caps.StructuredOutput},
+			"attachment":        {c.Attachment, &caps.Attachment},
+			"temperature":       {c.Temperature, &caps.Temperature},
+		} {
+			if v.src != nil {
+				*v.dst = *v.src
+				set("capabilities." + field)
+			}
+		}
+		m.Capabilities = &caps
+	}
+}
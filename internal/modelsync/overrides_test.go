@@ -0,0 +1,110 @@
+package modelsync
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestOverridesApply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.overrides.json")
+	os.WriteFile(path, []byte(`{
+		"exclude": ["kiro/*", "*-preview"],
+		"include": ["kiro/claude-sonnet-4-5"],
+		"providers": {
+			"antigravity": {"capabilities": {"structured_output": false}}
+		},
+		"models": {
+			"claude-sonnet-4-5": {"context_length": 1000000, "thinking": {"max": 128000}},
+			"antigravity/claude-sonnet-4-5": {"display_name": "AG Sonnet", "cost": {"input": 0}},
+			"gpt-9": {"context_length": 1}
+		}
+	}`), 0o644)
+	o, err := LoadOverrides(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	models := map[string][]Model{
+		"claude": {
+			{ID: "claude-sonnet-4-5", ContextLength: 200000, Thinking: &Thinking{Supported: true, Min: 1024, Max: 64000}},
+		},
+		"antigravity": {
+			{ID: "claude-sonnet-4-5", DisplayName: "Claude Sonnet 4 5", Cost: &Cost{Input: 3, Output: 15}, Capabilities: &Capabilities{StructuredOutput: true, ToolCall: true}},
+			{ID: "gemini-3-pro-preview"},
+		},
+		"kiro": {
+			{ID: "claude-sonnet-4-5"},
+			{ID: "claude-haiku-4-5"},
+		},
+	}
+	unmatched, excluded := o.Apply(models)
+
+	if excluded != 2 || !reflect.DeepEqual(unmatched, []string{"gpt-9"}) {
+		t.Errorf("excluded = %d, unmatched = %v", excluded, unmatched)
+	}
+	if len(models["kiro"]) != 1 || len(models["antigravity"]) != 1 {
+		t.Errorf("include/exclude: %v", models)
+	}
+
+	claude := models["claude"][0]
+	if claude.ContextLength != 1000000 || claude.Thinking.Max != 128000 || claude.Thinking.Min != 1024 {
+		t.Errorf("claude = %+v, thinking %+v", claude, claude.Thinking)
+	}
+	if want := []string{"context_length", "thinking.max"}; !reflect.DeepEqual(overridden(claude), want) {
+		t.Errorf("claude overrides = %v, want %v", overridden(claude), want)
+	}
+
+	ag := models["antigravity"][0]
+	if ag.DisplayName != "AG Sonnet" || ag.ContextLength != 1000000 || ag.Cost.Input != 0 || ag.Cost.Output != 15 {
+		t.Errorf("antigravity sonnet = %+v, cost %+v", ag, ag.Cost)
+	}
+	if ag.Capabilities.StructuredOutput || !ag.Capabilities.ToolCall {
+		t.Errorf("antigravity capabilities = %+v", ag.Capabilities)
+	}
+	want := []string{"capabilities.structured_output", "context_length", "cost.input", "display_name", "thinking.max"}
+	if !reflect.DeepEqual(overridden(ag), want) {
+		t.Errorf("antigravity overrides = %v, want %v", overridden(ag), want)
+	}
+}
+
+// overridden lists the fields m's provenance attributes to overrides.
+func overridden(m Model) []string {
+	var fields []string
+	for field, source := range m.Provenance {
+		if source == sourceOverride {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func TestOverridesDisableThinking(t *testing.T) {
+	off := false
+	models := map[string][]Model{"claude": {{ID: "a", Thinking: &Thinking{Supported: true, Max: 1}}}}
+	o := &Overrides{Models: map[string]ModelPatch{"a": {Thinking: &ThinkingPatch{Supported: &off}}}}
+	o.Apply(models)
+	if models["claude"][0].Thinking != nil {
+		t.Errorf("thinking = %+v, want nil", models["claude"][0].Thinking)
+	}
+}
+
+func TestLoadOverridesErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown field": `{"models": {"a": {"context": 1}}}`,
+		"bad pattern":   `{"exclude": ["["]}`,
+		"not JSON":      `exclude: ["a"]`,
+	}
+	dir := t.TempDir()
+	for name, body := range tests {
+		path := filepath.Join(dir, strings.ReplaceAll(name, " ", "-")+".json")
+		os.WriteFile(path, []byte(body), 0o644)
+		if _, err := LoadOverrides(path); err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package modelsync
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// defaultProviders maps upstream model functions to catalog provider keys.
+// An empty key skips the function.
+var defaultProviders = map[string]string{
+	"GetClaudeModels":           "claude",
+	"GetOpenAIModels":           "codex",
+	"GetGeminiModels":           "gemini",
+	"GetGeminiCLIModels":        "gemini-cli",
+	"GetGeminiVertexModels":     "vertex",
+	"GetAIStudioModels":         "aistudio",
+	"GetQwenModels":             "qwen",
+	"GetIFlowModels":            "iflow",
+	"GetGitHubCopilotModels":    "github-copilot",
+	"GetKiroModels":             "kiro",
+	"GetAmazonQModels":          "amazonq",
+	"GetAntigravityModelConfig": "antigravity",
+}
+
+// LoadProviderMap reads a JSON object of function name → provider key over
+// the defaults. Map a function to "" to skip it.
+func LoadProviderMap(path string) (map[string]string, error) {
+	providers := make(map[string]string, len(defaultProviders))
+	for fn, key := range defaultProviders {
+		providers[fn] = key
+	}
+	if path == "" {
+		return providers, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var overrides map[string]string
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	for fn, key := range overrides {
+		providers[fn] = key
+	}
+	return providers, nil
+}
+
+// deriveProvider names the provider of a function missing from the map:
+// GetFooBarModels becomes "foo-bar".
+func deriveProvider(funcName string) string {
+	name := strings.TrimPrefix(funcName, "Get")
+	for _, suffix := range []string{"ModelConfig", "Models"} {
+		if trimmed := strings.TrimSuffix(name, suffix); trimmed != name {
+			name = trimmed
+			break
+		}
+	}
+
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		// Break before an upper-case letter that starts a word: after a
+		// lower-case letter or digit, or ending an acronym ("AIStudio").
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				sb.WriteByte('-')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
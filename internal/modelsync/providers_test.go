@@ -0,0 +1,89 @@
+package modelsync
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const discoveryDefs = `package registry
+
+func GetClaudeModels() []*ModelInfo {
+	return []*ModelInfo{{ID: "claude-sonnet-4-5"}}
+}
+
+func GetNewCloudModels() []*ModelInfo {
+	return []*ModelInfo{{ID: "cloud-1"}}
+}
+
+func GetLegacyModels() []*ModelInfo {
+	return []*ModelInfo{{ID: "old-1"}}
+}
+
+func GetOrbitModelConfig() map[string]*OrbitModelConfig {
+	return map[string]*OrbitModelConfig{"orbit-1": {MaxCompletionTokens: 8192}}
+}
+
+// Not model sources: unexported, takes arguments, other result types
+func getInternalModels() []*ModelInfo { return nil }
+func GetModelsFor(provider string) []*ModelInfo { return nil }
+func GetModelNames() []string { return nil }
+`
+
+func TestDiscoverModelSources(t *testing.T) {
+	dir := t.TempDir()
+	mapFile := filepath.Join(dir, "model-providers.json")
+	os.WriteFile(mapFile, []byte(`{"GetLegacyModels": "", "GetClaudeModels": "anthropic"}`), 0o644)
+	providers, err := LoadProviderMap(mapFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if providers["GetOpenAIModels"] != "codex" {
+		t.Error("defaults lost when loading the provider map")
+	}
+
+	models, unmapped, err := parseAndEnrichModels([]SourceFile{{Name: "defs.go", Data: []byte(discoveryDefs)}}, providers, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string][]string)
+	for provider, list := range models {
+		for _, m := range list {
+			got[provider] = append(got[provider], m.ID)
+		}
+	}
+	want := map[string][]string{
+		"anthropic": {"claude-sonnet-4-5"},
+		"new-cloud": {"cloud-1"},
+		"orbit":     {"orbit-1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("models = %v, want %v", got, want)
+	}
+	if orbit := models["orbit"][0]; orbit.MaxCompletionTokens != 8192 || orbit.OwnedBy != "orbit" {
+		t.Errorf("orbit = %+v", orbit)
+	}
+
+	wantUnmapped := []string{"GetNewCloudModels → new-cloud", "GetOrbitModelConfig → orbit"}
+	if !reflect.DeepEqual(unmapped, wantUnmapped) {
+		t.Errorf("unmapped = %v, want %v", unmapped, wantUnmapped)
+	}
+}
+
+func TestDeriveProvider(t *testing.T) {
+	tests := map[string]string{
+		"GetClaudeModels":           "claude",
+		"GetGitHubCopilotModels":    "git-hub-copilot",
+		"GetAIStudioModels":         "ai-studio",
+		"GetOpenAIModels":           "open-ai",
+		"GetGemini3Models":          "gemini3",
+		"GetAntigravityModelConfig": "antigravity",
+	}
+	for fn, want := range tests {
+		if got := deriveProvider(fn); got != want {
+			t.Errorf("deriveProvider(%s) = %q, want %q", fn, got, want)
+		}
+	}
+}
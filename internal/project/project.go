@@ -0,0 +1,284 @@
+// Package project reads config/vibeproxy.json, the one file describing a
+// VibeProxy setup, and generates the backend's config/cliproxy.yaml from it
+// and the canonical model catalog.
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/theadriann/vibeproxyplus/internal/thinking"
+)
+
+// Config is config/vibeproxy.json. Missing fields keep Default's values.
+type Config struct {
+	Proxy   ProxyConfig   `json:"proxy"`
+	Backend BackendConfig `json:"backend"`
+	// ModelAliases are per backend provider, e.g. "antigravity".
+	ModelAliases map[string]AliasRules `json:"model_aliases,omitempty"`
+	Clients      ClientsConfig         `json:"clients"`
+	// ThinkingTiers replace thinking.DefaultTiers for model-sync's client
+	// configs and ThinkingProxy's -thinking-<tier> suffix.
+	ThinkingTiers []thinking.Tier `json:"thinking_tiers,omitempty"`
+}
+
+type ProxyConfig struct {
+	Port int `json:"port"`
+}
+
+// BackendConfig holds the CLIProxyAPIPlus settings written to cliproxy.yaml.
+type BackendConfig struct {
+	Host            string      `json:"host"`
+	Port            int         `json:"port"`
+	AuthDir         string      `json:"auth_dir"`
+	Debug           bool        `json:"debug"`
+	LoggingToFile   bool        `json:"logging_to_file"`
+	RequestRetry    int         `json:"request_retry"`
+	RequestTimeout  string      `json:"request_timeout"`
+	UsageStatistics bool        `json:"usage_statistics"`
+	QuotaExceeded   QuotaConfig `json:"quota_exceeded"`
+}
+
+// ClientsConfig says how the generated Factory and OpenCode configs reach
+// ThinkingProxy. An empty BaseURL is http://localhost:<proxy port>.
+// ProviderURLs override it per catalog provider. APIKeyEnv names a variable
+// the clients read the key from instead of writing APIKey into the files.
+type ClientsConfig struct {
+	BaseURL      string            `json:"base_url,omitempty"`
+	ProviderURLs map[string]string `json:"provider_urls,omitempty"`
+	APIKey       string            `json:"api_key,omitempty"`
+	APIKeyEnv    string            `json:"api_key_env,omitempty"`
+}
+
+// ClientBaseURL is the proxy URL written to client configs.
+func (c Config) ClientBaseURL() string {
+	if c.Clients.BaseURL != "" {
+		return c.Clients.BaseURL
+	}
+	return "http://localhost:" + strconv.Itoa(c.Proxy.Port)
+}
+
+// QuotaConfig is what the backend does when an account runs out of quota.
+type QuotaConfig struct {
+	SwitchProject      bool `json:"switch_project"`
+	SwitchPreviewModel bool `json:"switch_preview_model"`
+}
+
+// AliasRules name a provider's models for clients. Prefix maps an ID prefix
+// to a string prepended to matching IDs (e.g. "claude-" → "gemini-", so
+// antigravity's Claude models don't shadow the Claude provider's). Names
+// gives single models an explicit alias and wins over Prefix.
+type AliasRules struct {
+	Prefix map[string]string `json:"prefix,omitempty"`
+	Names  map[string]string `json:"names,omitempty"`
+}
+
+// Alias is one oauth-model-alias entry.
+type Alias struct {
+	Name  string
+	Alias string
+}
+
+// Default is the stock setup.
+func Default() Config {
+	return Config{
+		Proxy: ProxyConfig{Port: 8317},
+		Backend: BackendConfig{
+			Host:           "127.0.0.1",
+			Port:           8318,
+			AuthDir:        "~/.cli-proxy-api",
+			RequestRetry:   3,
+			RequestTimeout: "10m",
+			QuotaExceeded:  QuotaConfig{SwitchProject: true, SwitchPreviewModel: true},
+		},
+	}
+}
+
+// Load reads a project config over Default.
+func Load(path string) (Config, error) {
+	cfg := Default()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Aliases resolves the alias rules against the catalog (provider key →
+// model IDs). Named models missing from the catalog are dropped with a
+// warning, since the backend would not serve them. A nil catalog keeps
+// every named alias.
+func (c Config) Aliases(catalog map[string][]string) (map[string][]Alias, []string) {
+	aliases := make(map[string][]Alias)
+	var warnings []string
+
+	providers := make([]string, 0, len(c.ModelAliases))
+	for provider := range c.ModelAliases {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	for _, provider := range providers {
+		rules := c.ModelAliases[provider]
+		known := make(map[string]bool)
+		for _, id := range catalog[provider] {
+			known[id] = true
+		}
+
+		byName := make(map[string]string)
+		for _, id := range catalog[provider] {
+			if alias := prefixAlias(rules.Prefix, id); alias != "" {
+				byName[id] = alias
+			}
+		}
+		for name, alias := range rules.Names {
+			if catalog != nil && !known[name] {
+				warnings = append(warnings, fmt.Sprintf("%s alias %s → %s: %s is not in the model catalog", provider, name, alias, name))
+				continue
+			}
+			byName[name] = alias
+		}
+
+		for name, alias := range byName {
+			aliases[provider] = append(aliases[provider], Alias{Name: name, Alias: alias})
+		}
+		sort.Slice(aliases[provider], func(i, j int) bool {
+			return aliases[provider][i].Name < aliases[provider][j].Name
+		})
+	}
+	return aliases, warnings
+}
+
+// prefixAlias applies the longest matching prefix rule to id.
+func prefixAlias(rules map[string]string, id string) string {
+	best := ""
+	for prefix := range rules {
+		if strings.HasPrefix(id, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return rules[best] + id
+}
+
+// GeneratedHeader starts every generated cliproxy.yaml.
+const GeneratedHeader = "# Generated by `vibeproxy gen-config` from config/vibeproxy.json and\n# config/models.json. Edit those and regenerate instead of this file.\n"
+
+// CLIProxyYAML renders cliproxy.yaml, returning alias warnings alongside.
+func (c Config) CLIProxyYAML(catalog map[string][]string) ([]byte, []string) {
+	aliases, warnings := c.Aliases(catalog)
+	b := c.Backend
+
+	var sb strings.Builder
+	sb.WriteString(GeneratedHeader)
+	sb.WriteString("\n")
+	fmt.Fprintf(&sb, "host: %s\n", yamlString(b.Host))
+	fmt.Fprintf(&sb, "port: %d\n", b.Port)
+	fmt.Fprintf(&sb, "auth-dir: %s\n", yamlString(b.AuthDir))
+	fmt.Fprintf(&sb, "debug: %t\n", b.Debug)
+	fmt.Fprintf(&sb, "logging-to-file: %t\n", b.LoggingToFile)
+	fmt.Fprintf(&sb, "request-retry: %d\n", b.RequestRetry)
+	fmt.Fprintf(&sb, "request-timeout: %s\n", yamlString(b.RequestTimeout))
+	sb.WriteString("quota-exceeded:\n")
+	fmt.Fprintf(&sb, "  switch-project: %t\n", b.QuotaExceeded.SwitchProject)
+	fmt.Fprintf(&sb, "  switch-preview-model: %t\n", b.QuotaExceeded.SwitchPreviewModel)
+	fmt.Fprintf(&sb, "usage-statistics-enabled: %t\n", b.UsageStatistics)
+
+	if len(aliases) > 0 {
+		providers := make([]string, 0, len(aliases))
+		for provider := range aliases {
+			providers = append(providers, provider)
+		}
+		sort.Strings(providers)
+		sb.WriteString("oauth-model-alias:\n")
+		for _, provider := range providers {
+			fmt.Fprintf(&sb, "  %s:\n", yamlString(provider))
+			for _, a := range aliases[provider] {
+				fmt.Fprintf(&sb, "    - name: %s\n      alias: %s\n", yamlString(a.Name), yamlString(a.Alias))
+			}
+		}
+	}
+	return []byte(sb.String()), warnings
+}
+
+// yamlString writes s bare when it is plainly a string to YAML, and quoted
+// otherwise.
+func yamlString(s string) string {
+	if s == "" {
+		return `""`
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("._-/", r)) {
+			return strconv.Quote(s)
+		}
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null":
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// Drift lists how existing differs from generated, ignoring comments and
+// blank lines, as an ordered line diff: "-" lines only in existing, "+"
+// lines only in generated. Moved lines show as removed and added, so
+// reordered entries and aliases swapped between models count as drift.
+func Drift(existing, generated []byte) []string {
+	have, want := significantLines(existing), significantLines(generated)
+
+	// common[i][j] is the longest common subsequence of have[i:] and want[j:]
+	common := make([][]int, len(have)+1)
+	for i := range common {
+		common[i] = make([]int, len(want)+1)
+	}
+	for i := len(have) - 1; i >= 0; i-- {
+		for j := len(want) - 1; j >= 0; j-- {
+			if have[i] == want[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var drift []string
+	i, j := 0, 0
+	for i < len(have) || j < len(want) {
+		switch {
+		case i < len(have) && j < len(want) && have[i] == want[j]:
+			i++
+			j++
+		case j == len(want) || (i < len(have) && common[i+1][j] >= common[i][j+1]):
+			drift = append(drift, "- "+have[i])
+			i++
+		default:
+			drift = append(drift, "+ "+want[j])
+			j++
+		}
+	}
+	return drift
+}
+
+func significantLines(data []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
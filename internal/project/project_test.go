@@ -0,0 +1,151 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vibeproxy.json")
+	os.WriteFile(path, []byte(`{"proxy":{"port":9317},"backend":{"request_retry":5},"thinking_tiers":[{"name":"deep","level":0.8}]}`), 0o644)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Proxy.Port != 9317 || cfg.Backend.RequestRetry != 5 || len(cfg.ThinkingTiers) != 1 || cfg.ThinkingTiers[0].Level != 0.8 {
+		t.Errorf("overrides not applied: %+v", cfg)
+	}
+	if cfg.Backend.Port != 8318 || cfg.Backend.Host != "127.0.0.1" || !cfg.Backend.QuotaExceeded.SwitchProject {
+		t.Errorf("defaults lost: %+v", cfg.Backend)
+	}
+}
+
+func TestClientBaseURL(t *testing.T) {
+	cfg := Default()
+	cfg.Proxy.Port = 9317
+	if got := cfg.ClientBaseURL(); got != "http://localhost:9317" {
+		t.Errorf("from port: %s", got)
+	}
+	cfg.Clients.BaseURL = "https://proxy.example.com"
+	if got := cfg.ClientBaseURL(); got != "https://proxy.example.com" {
+		t.Errorf("explicit: %s", got)
+	}
+}
+
+func TestAliases(t *testing.T) {
+	cfg := Config{ModelAliases: map[string]AliasRules{
+		"antigravity": {
+			Prefix: map[string]string{"claude-": "gemini-", "claude-opus-": "ag-"},
+			Names:  map[string]string{"gemini-3-flash": "gemini-3-flash-preview", "claude-sonnet-4-5": "sonnet", "gone": "gone-alias"},
+		},
+	}}
+	catalog := map[string][]string{
+		"antigravity": {"claude-sonnet-4-5", "claude-opus-4-5-thinking", "gemini-3-flash", "gemini-2.5-pro"},
+	}
+
+	aliases, warnings := cfg.Aliases(catalog)
+	want := []Alias{
+		{"claude-opus-4-5-thinking", "ag-claude-opus-4-5-thinking"},
+		{"claude-sonnet-4-5", "sonnet"},
+		{"gemini-3-flash", "gemini-3-flash-preview"},
+	}
+	if !reflect.DeepEqual(aliases["antigravity"], want) {
+		t.Errorf("aliases = %v, want %v", aliases["antigravity"], want)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "gone") {
+		t.Errorf("warnings = %v, want one about gone", warnings)
+	}
+
+	// Without a catalog every named alias is kept
+	aliases, warnings = cfg.Aliases(nil)
+	if len(aliases["antigravity"]) != 3 || len(warnings) != 0 {
+		t.Errorf("nil catalog: aliases = %v, warnings = %v", aliases["antigravity"], warnings)
+	}
+}
+
+func TestCLIProxyYAML(t *testing.T) {
+	cfg := Default()
+	cfg.ModelAliases = map[string]AliasRules{"antigravity": {Names: map[string]string{"gemini-3-flash": "gemini-3-flash-preview"}}}
+
+	data, _ := cfg.CLIProxyYAML(map[string][]string{"antigravity": {"gemini-3-flash"}})
+	out := string(data)
+	if !strings.HasPrefix(out, GeneratedHeader) {
+		t.Error("missing generated header")
+	}
+	for _, line := range []string{
+		"host: 127.0.0.1\n",
+		"port: 8318\n",
+		`auth-dir: "~/.cli-proxy-api"` + "\n",
+		"request-timeout: 10m\n",
+		"  switch-project: true\n",
+		"oauth-model-alias:\n  antigravity:\n    - name: gemini-3-flash\n      alias: gemini-3-flash-preview\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("output missing %q:\n%s", line, out)
+		}
+	}
+}
+
+func TestYAMLString(t *testing.T) {
+	tests := map[string]string{
+		"":           `""`,
+		"10m":        "10m",
+		"127.0.0.1":  "127.0.0.1",
+		"8318":       `"8318"`,
+		"true":       `"true"`,
+		"has space":  `"has space"`,
+		"a: b":       `"a: b"`,
+		"claude-4-5": "claude-4-5",
+	}
+	for in, want := range tests {
+		if got := yamlString(in); got != want {
+			t.Errorf("yamlString(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestDrift(t *testing.T) {
+	existing := []byte("# hand edited\nport: 8318\ndebug: true\n\nrequest-retry: 3\n")
+	generated := []byte("# generated\nport: 8318\nrequest-retry: 3\ndebug: false\n")
+
+	got := Drift(existing, generated)
+	want := []string{"- debug: true", "+ debug: false"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Drift = %v, want %v", got, want)
+	}
+	if drift := Drift(generated, generated); len(drift) != 0 {
+		t.Errorf("identical files drift: %v", drift)
+	}
+}
+
+func TestDrift_Order(t *testing.T) {
+	generated := "oauth-model-alias:\n  kiro:\n    - name: a\n      alias: x\n    - name: b\n      alias: y\n  codex:\n    - name: c\n      alias: z\n"
+	tests := []struct {
+		name     string
+		existing string
+	}{
+		{"entries reordered", "oauth-model-alias:\n  kiro:\n    - name: b\n      alias: y\n    - name: a\n      alias: x\n  codex:\n    - name: c\n      alias: z\n"},
+		{"aliases swapped", "oauth-model-alias:\n  kiro:\n    - name: a\n      alias: y\n    - name: b\n      alias: x\n  codex:\n    - name: c\n      alias: z\n"},
+		{"alias moved between models", "oauth-model-alias:\n  kiro:\n    - name: a\n      alias: x\n    - name: b\n      alias: z\n  codex:\n    - name: c\n      alias: y\n"},
+		{"name and alias swapped", "oauth-model-alias:\n  kiro:\n    - name: x\n      alias: a\n    - name: b\n      alias: y\n  codex:\n    - name: c\n      alias: z\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if drift := Drift([]byte(tt.existing), []byte(generated)); len(drift) == 0 {
+				t.Error("no drift reported")
+			}
+		})
+	}
+
+	// A move is reported once, where it happened
+	existing := []byte("a: 1\nb: 2\nc: 3\n")
+	got := Drift(existing, []byte("b: 2\nc: 3\na: 1\n"))
+	want := []string{"- a: 1", "+ a: 1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Drift = %v, want %v", got, want)
+	}
+}
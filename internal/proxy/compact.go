@@ -0,0 +1,246 @@
+package proxy
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/theadriann/vibeproxyplus/internal/translate"
+)
+
+const (
+	compactSummaryPrefix = "Another language model started to solve this problem and produced a summary of its thinking process. Use it to continue the work:\n\n"
+
+	compactPrompt = `You are compacting a coding-agent conversation so it can continue in a fresh context window.
+Write a concise but complete summary covering: the user's goals and constraints, decisions made,
+files and code touched, commands run and their outcomes, errors and how they were resolved,
+and the exact next steps. Preserve identifiers, paths and values verbatim. Output only the summary.`
+
+	// compactUserBudget caps how much recent user text is kept verbatim
+	// alongside the summary (roughly 20K tokens).
+	compactUserBudget = 80000
+
+	// compactToolOutputLimit truncates tool outputs in the transcript sent
+	// to the summariser.
+	compactToolOutputLimit = 2000
+)
+
+// DefaultCompactProviders lists the backend providers (the `owned_by` of
+// their models) that implement /v1/responses/compact natively.
+var DefaultCompactProviders = []string{"openai"}
+
+// supportsCompact reports whether the provider serving the request's model
+// implements /v1/responses/compact natively. Models the backend does not
+// list are emulated, which works with any model.
+func (tp *ThinkingProxy) supportsCompact(r *http.Request, body []byte) bool {
+	var data struct {
+		Model string `json:"model"`
+	}
+	if json.Unmarshal(body, &data) != nil {
+		return true
+	}
+
+	providers := tp.opts.CompactProviders
+	if providers == nil {
+		providers = DefaultCompactProviders
+	}
+	owner := tp.modelOwner(r, data.Model)
+	for _, p := range providers {
+		if owner != "" && owner == p {
+			return true
+		}
+	}
+	return false
+}
+
+// emulateCompact answers /v1/responses/compact by summarising the input with
+// a chat completion request and returning the compacted input list.
+func (tp *ThinkingProxy) emulateCompact(w http.ResponseWriter, r *http.Request, body []byte) {
+	var req struct {
+		Model        string        `json:"model"`
+		Input        []interface{} `json:"input"`
+		Instructions string        `json:"instructions"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid compact request: "+err.Error())
+		return
+	}
+
+	model := tp.opts.CompactModel
+	if model == "" {
+		model = req.Model
+	}
+
+	summaryBody, err := json.Marshal(map[string]interface{}{
+		"model":  model,
+		"stream": false,
+		"messages": []interface{}{
+			map[string]interface{}{"role": "system", "content": compactPrompt},
+			map[string]interface{}{"role": "user", "content": compactTranscript(req.Instructions, req.Input)},
+		},
+	})
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Let the summarisation model use the usual thinking suffixes
+	summaryBody, needsBetaHeader, err := transformRequestBody("/v1/chat/completions", summaryBody, tp.thinkingTiers())
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	upstream, err := http.NewRequestWithContext(r.Context(), http.MethodPost, tp.target.String()+"/v1/chat/completions", bytes.NewReader(summaryBody))
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	upstream.Header.Set("Content-Type", "application/json")
+	for _, h := range []string{"Authorization", "X-Api-Key"} {
+		if v := r.Header.Get(h); v != "" {
+			upstream.Header.Set(h, v)
+		}
+	}
+	if needsBetaHeader {
+		upstream.Header.Set(BetaHeader, BetaInterleaved)
+	}
+
+	resp, err := tp.client.Do(upstream)
+	if err != nil {
+		writeJSONError(w, http.StatusBadGateway, "compact summarisation failed: "+err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		writeJSONError(w, http.StatusBadGateway, "compact summarisation failed: "+err.Error())
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+		w.WriteHeader(resp.StatusCode)
+		w.Write(respBody)
+		return
+	}
+
+	var completion struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+			TotalTokens      int `json:"total_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(respBody, &completion); err != nil || len(completion.Choices) == 0 {
+		writeJSONError(w, http.StatusBadGateway, "compact summarisation returned no choices")
+		return
+	}
+	summary := strings.TrimSpace(completion.Choices[0].Message.Content)
+
+	output := append(recentUserMessages(req.Input, compactUserBudget),
+		responsesMessage("user", "input_text", compactSummaryPrefix+summary))
+
+	log.Printf("Emulated compact for %s using %s (%d items -> %d)", req.Model, model, len(req.Input), len(output))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":         "cmp_" + randomHex(12),
+		"object":     "response.compaction",
+		"created_at": time.Now().Unix(),
+		"output":     output,
+		"usage": map[string]interface{}{
+			"input_tokens":  completion.Usage.PromptTokens,
+			"output_tokens": completion.Usage.CompletionTokens,
+			"total_tokens":  completion.Usage.TotalTokens,
+		},
+	})
+}
+
+// compactTranscript renders Responses input items as plain text for the
+// summarisation model.
+func compactTranscript(instructions string, input []interface{}) string {
+	var b strings.Builder
+	if instructions != "" {
+		fmt.Fprintf(&b, "SYSTEM INSTRUCTIONS:\n%s\n\n", instructions)
+	}
+	b.WriteString("CONVERSATION:\n")
+
+	for _, raw := range input {
+		item, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch item["type"] {
+		case "message", nil:
+			role, _ := item["role"].(string)
+			if text := translate.TextFromContent(item["content"]); text != "" {
+				fmt.Fprintf(&b, "\n%s: %s\n", strings.ToUpper(role), text)
+			}
+		case "function_call":
+			fmt.Fprintf(&b, "\nTOOL CALL %v(%v)\n", item["name"], item["arguments"])
+		case "function_call_output":
+			output := translate.TextFromContent(item["output"])
+			if len(output) > compactToolOutputLimit {
+				output = output[:compactToolOutputLimit] + "\n[truncated]"
+			}
+			fmt.Fprintf(&b, "\nTOOL RESULT: %s\n", output)
+		}
+	}
+	return b.String()
+}
+
+// recentUserMessages returns the most recent user text messages, oldest
+// first, whose combined length fits in budget.
+func recentUserMessages(input []interface{}, budget int) []interface{} {
+	var kept []interface{}
+	for i := len(input) - 1; i >= 0; i-- {
+		item, ok := input[i].(map[string]interface{})
+		if !ok || item["role"] != "user" {
+			continue
+		}
+		text := translate.TextFromContent(item["content"])
+		if text == "" || strings.HasPrefix(text, compactSummaryPrefix) {
+			continue
+		}
+		if len(text) > budget {
+			break
+		}
+		budget -= len(text)
+		kept = append(kept, responsesMessage("user", "input_text", text))
+	}
+
+	for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+		kept[i], kept[j] = kept[j], kept[i]
+	}
+	return kept
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": message,
+			"type":    "proxy_error",
+		},
+	})
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,190 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/theadriann/vibeproxyplus/internal/translate"
+)
+
+func newTestProxy(t *testing.T, backend *httptest.Server, opts Options) *ThinkingProxy {
+	t.Helper()
+	u, _ := url.Parse(backend.URL)
+	port, _ := strconv.Atoi(u.Port())
+	return NewThinkingProxyWithOptions(port, opts)
+}
+
+func TestEmulateCompact(t *testing.T) {
+	var summaryReq map[string]interface{}
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/models" {
+			io.WriteString(w, modelListing)
+			return
+		}
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected backend path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("Authorization not forwarded")
+		}
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &summaryReq)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"did things"}}],"usage":{"prompt_tokens":10,"completion_tokens":3,"total_tokens":13}}`))
+	}))
+	defer backend.Close()
+
+	tp := newTestProxy(t, backend, Options{CompactModel: "gemini-2.5-flash"})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/responses/compact", strings.NewReader(`{
+		"model":"claude-sonnet-4-5-20250929",
+		"input":[
+			{"type":"message","role":"user","content":[{"type":"input_text","text":"fix the bug"}]},
+			{"type":"function_call","name":"shell","arguments":"{\"cmd\":\"ls\"}","call_id":"c1"},
+			{"type":"function_call_output","call_id":"c1","output":"main.go"},
+			{"type":"message","role":"assistant","content":[{"type":"output_text","text":"done"}]}
+		]
+	}`))
+	req.Header.Set("Authorization", "Bearer key")
+	rec := httptest.NewRecorder()
+	tp.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if summaryReq["model"] != "gemini-2.5-flash" {
+		t.Errorf("summarisation model = %v, want configured compact model", summaryReq["model"])
+	}
+	messages := summaryReq["messages"].([]interface{})
+	transcript := messages[1].(map[string]interface{})["content"].(string)
+	for _, want := range []string{"USER: fix the bug", "TOOL CALL shell", "TOOL RESULT: main.go", "ASSISTANT: done"} {
+		if !strings.Contains(transcript, want) {
+			t.Errorf("transcript missing %q:\n%s", want, transcript)
+		}
+	}
+
+	var resp struct {
+		Object string                   `json:"object"`
+		Output []map[string]interface{} `json:"output"`
+		Usage  map[string]int           `json:"usage"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if resp.Object != "response.compaction" || resp.Usage["total_tokens"] != 13 {
+		t.Errorf("unexpected response: %s", rec.Body.String())
+	}
+	if len(resp.Output) != 2 {
+		t.Fatalf("expected kept user message + summary, got %d items", len(resp.Output))
+	}
+	if text := translate.TextFromContent(resp.Output[0]["content"]); text != "fix the bug" {
+		t.Errorf("first item = %q, want original user message", text)
+	}
+	if text := translate.TextFromContent(resp.Output[1]["content"]); !strings.HasSuffix(text, "did things") {
+		t.Errorf("last item = %q, want summary", text)
+	}
+}
+
+const modelListing = `{"object":"list","data":[
+	{"id":"gpt-5.2-codex","owned_by":"openai"},
+	{"id":"o3","owned_by":"openai"},
+	{"id":"codex-mini-latest","owned_by":"openai"},
+	{"id":"claude-sonnet-4-5-20250929","owned_by":"anthropic"},
+	{"id":"gpt-5","owned_by":"github-copilot"}]}`
+
+func TestCompactRoutingByProvider(t *testing.T) {
+	tests := []struct {
+		model     string
+		providers []string
+		native    bool
+	}{
+		{"gpt-5.2-codex", nil, true},
+		{"o3", nil, true},
+		{"codex-mini-latest", nil, true},
+		{"claude-sonnet-4-5-20250929", nil, false},
+		{"unlisted-model", nil, false},
+		{"gpt-5", nil, false},
+		{"gpt-5", []string{"openai", "github-copilot"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			var gotPath string
+			backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/v1/models" {
+					io.WriteString(w, modelListing)
+					return
+				}
+				gotPath = r.URL.Path
+				if r.URL.Path == "/v1/chat/completions" {
+					w.Write([]byte(`{"choices":[{"message":{"content":"summary"}}]}`))
+					return
+				}
+				w.Write([]byte(`{"object":"response.compaction","output":[]}`))
+			}))
+			defer backend.Close()
+
+			tp := newTestProxy(t, backend, Options{CompactProviders: tt.providers})
+			req := httptest.NewRequest(http.MethodPost, "/v1/responses/compact", strings.NewReader(`{"model":"`+tt.model+`","input":"hi"}`))
+			tp.ServeHTTP(httptest.NewRecorder(), req)
+
+			want := "/v1/chat/completions"
+			if tt.native {
+				want = "/v1/responses/compact"
+			}
+			if gotPath != want {
+				t.Errorf("backend saw %q, want %q", gotPath, want)
+			}
+		})
+	}
+}
+
+func TestModelOwnerFetches(t *testing.T) {
+	var mu sync.Mutex
+	fetches := 0
+	release := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetches++
+		n := fetches
+		mu.Unlock()
+		if n == 1 {
+			<-release
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, modelListing)
+	}))
+	defer backend.Close()
+	tp := newTestProxy(t, backend, Options{})
+
+	// Concurrent lookups share one fetch
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tp.modelOwner(httptest.NewRequest(http.MethodGet, "/", nil), "gpt-5.2-codex")
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// The failure is remembered instead of refetched per request
+	if owner := tp.modelOwner(httptest.NewRequest(http.MethodGet, "/", nil), "gpt-5.2-codex"); owner != "" || fetches != 1 {
+		t.Fatalf("after a failed fetch: owner %q, %d fetches, want 1", owner, fetches)
+	}
+
+	tp.owners.failed = time.Now().Add(-modelOwnersRetry)
+	if owner := tp.modelOwner(httptest.NewRequest(http.MethodGet, "/", nil), "gpt-5.2-codex"); owner == "" || fetches != 2 {
+		t.Errorf("after the retry delay: owner %q, %d fetches, want 2", owner, fetches)
+	}
+}
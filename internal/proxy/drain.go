@@ -0,0 +1,202 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/theadriann/vibeproxyplus/internal/translate"
+)
+
+// drainAbortGrace is how long Drain waits, after ending open streams, for
+// their handlers to write the final error event.
+const drainAbortGrace = 2 * time.Second
+
+const drainMessage = "ThinkingProxy is restarting; retry the request"
+
+// drainState tracks in-flight requests so a shutdown can let them finish.
+type drainState struct {
+	mu       sync.Mutex
+	draining bool
+	deadline time.Time
+	active   int
+	idle     chan struct{}
+	streams  map[*drainStream]struct{}
+}
+
+// begin registers a request, reporting false once draining has started.
+func (d *drainState) begin() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return false
+	}
+	d.active++
+	return true
+}
+
+func (d *drainState) end() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.active--
+	if d.draining && d.active == 0 {
+		d.closeIdle()
+	}
+}
+
+func (d *drainState) closeIdle() {
+	select {
+	case <-d.idle:
+	default:
+		close(d.idle)
+	}
+}
+
+// retryAfter is the Retry-After value sent while draining: the time left
+// until the drain deadline, at least one second.
+func (d *drainState) retryAfter() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	secs := 1
+	if !d.deadline.IsZero() {
+		if left := int(math.Ceil(time.Until(d.deadline).Seconds())); left > secs {
+			secs = left
+		}
+	}
+	return strconv.Itoa(secs)
+}
+
+// Drain stops accepting requests and waits for in-flight ones, including
+// SSE streams, to finish. Streams still open when ctx is done are ended with
+// an error event in the client's protocol, and ctx's error is returned.
+// New requests receive 503 with Retry-After for the rest of the process.
+func (tp *ThinkingProxy) Drain(ctx context.Context) error {
+	d := &tp.drain
+	d.mu.Lock()
+	d.draining = true
+	d.deadline, _ = ctx.Deadline()
+	d.idle = make(chan struct{})
+	if d.active == 0 {
+		d.closeIdle()
+	}
+	idle := d.idle
+	d.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+	}
+
+	d.mu.Lock()
+	streams := make([]*drainStream, 0, len(d.streams))
+	for s := range d.streams {
+		streams = append(streams, s)
+	}
+	d.mu.Unlock()
+	for _, s := range streams {
+		s.abort()
+	}
+
+	select {
+	case <-idle:
+	case <-time.After(drainAbortGrace):
+	}
+	return ctx.Err()
+}
+
+func (tp *ThinkingProxy) writeDraining(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", tp.drain.retryAfter())
+	w.Header().Set("Connection", "close")
+	writeJSONError(w, http.StatusServiceUnavailable, drainMessage)
+}
+
+type cancelKey struct{}
+
+// withCancel gives the request a context a drain can cancel, which aborts
+// the backend request and unblocks any pending body read.
+func withCancel(r *http.Request) (*http.Request, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(r.Context())
+	return r.WithContext(context.WithValue(ctx, cancelKey{}, cancel)), cancel
+}
+
+// drainStream wraps an SSE body so a drain can end it cleanly: once
+// aborted, the rest of the backend stream is dropped and the client gets an
+// error event in its own protocol instead of a dropped connection.
+type drainStream struct {
+	body     io.ReadCloser
+	cancel   context.CancelFunc
+	protocol translate.Protocol
+	state    *drainState
+	aborted  atomic.Bool
+	final    []byte
+	finished bool
+	tail     []byte
+	close    sync.Once
+}
+
+func (tp *ThinkingProxy) newDrainStream(r *http.Request, body io.ReadCloser, protocol translate.Protocol) io.ReadCloser {
+	cancel, _ := r.Context().Value(cancelKey{}).(context.CancelFunc)
+	if cancel == nil {
+		cancel = func() {}
+	}
+	s := &drainStream{body: body, cancel: cancel, protocol: protocol, state: &tp.drain}
+	tp.drain.mu.Lock()
+	if tp.drain.streams == nil {
+		tp.drain.streams = make(map[*drainStream]struct{})
+	}
+	tp.drain.streams[s] = struct{}{}
+	tp.drain.mu.Unlock()
+	return s
+}
+
+func (s *drainStream) Read(p []byte) (int, error) {
+	if !s.aborted.Load() {
+		n, err := s.body.Read(p)
+		if !s.aborted.Load() {
+			s.tail = append(s.tail, p[:n]...)
+			if len(s.tail) > 4 {
+				s.tail = s.tail[len(s.tail)-4:]
+			}
+			return n, err
+		}
+		// Whatever arrived after the abort is dropped.
+	}
+
+	if s.finished {
+		return 0, io.EOF
+	}
+	if s.final == nil {
+		if !atEventBoundary(s.tail) {
+			s.final = []byte("\n\n")
+		}
+		s.final = append(s.final, translate.ErrorEvent(s.protocol, drainMessage)...)
+	}
+	n := copy(p, s.final)
+	s.final = s.final[n:]
+	if len(s.final) == 0 {
+		s.finished = true
+	}
+	return n, nil
+}
+
+// abort ends the stream; cancelling the backend request unblocks a pending
+// Read.
+func (s *drainStream) abort() {
+	s.aborted.Store(true)
+	s.cancel()
+}
+
+func (s *drainStream) Close() error {
+	s.close.Do(func() {
+		s.state.mu.Lock()
+		delete(s.state.streams, s)
+		s.state.mu.Unlock()
+	})
+	return s.body.Close()
+}
@@ -0,0 +1,105 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDrain_RejectsNewRequests(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request must not reach the backend")
+	}))
+	defer backend.Close()
+
+	tp := newTestProxy(t, backend, Options{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := tp.Drain(ctx); err != nil {
+		t.Fatalf("idle drain returned %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	tp.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "10" {
+		t.Errorf("Retry-After = %q, want the remaining drain time", got)
+	}
+}
+
+// drainScenario starts a streaming request through the proxy and drains
+// while it is in flight. The backend sends parts, pausing before each one.
+func drainScenario(t *testing.T, path string, drain, pause time.Duration, parts ...string) (string, error) {
+	t.Helper()
+	backend := httptest.NewServer(slowStream(pause, parts...))
+	defer backend.Close()
+
+	tp := newTestProxy(t, backend, Options{})
+	server := httptest.NewServer(tp)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+path, "application/json", strings.NewReader(`{"model":"m","stream":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), drain)
+	defer cancel()
+	drained := make(chan error, 1)
+	go func() { drained <- tp.Drain(ctx) }()
+
+	body, _ := io.ReadAll(resp.Body)
+	return string(body), <-drained
+}
+
+func TestDrain_LetsStreamsFinish(t *testing.T) {
+	out, err := drainScenario(t, "/v1/chat/completions", 5*time.Second, 30*time.Millisecond,
+		"data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n", "data: [DONE]\n\n")
+	if err != nil {
+		t.Errorf("drain returned %v", err)
+	}
+	if !strings.HasSuffix(out, "data: [DONE]\n\n") || strings.Contains(out, "error") {
+		t.Errorf("stream did not complete normally:\n%s", out)
+	}
+}
+
+func TestDrain_EndsStreamsAtDeadline(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/v1/messages", "event: error\ndata: {\"error\":{\"message\":\"" + drainMessage + "\",\"type\":\"api_error\"},\"type\":\"error\"}\n\n"},
+		{"/v1/chat/completions", "data: {\"error\":{\"message\":\"" + drainMessage + "\",\"type\":\"api_error\"}}\n\n"},
+		{"/v1/responses", "\"type\":\"error\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			out, err := drainScenario(t, tt.path, 100*time.Millisecond, 300*time.Millisecond, "data: {}\n\n", "data: {}\n\n")
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("drain returned %v, want deadline exceeded", err)
+			}
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("stream not ended with an error event:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestDrain_ErrorEventStartsOnEventBoundary(t *testing.T) {
+	out, err := drainScenario(t, "/v1/chat/completions", 100*time.Millisecond, 60*time.Millisecond,
+		"data: {\"choices\":", "[]}\n\n")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("drain returned %v, want deadline exceeded", err)
+	}
+	if !strings.Contains(out, "data: {\"choices\":\n\ndata: {\"error\"") {
+		t.Errorf("error event not separated from the partial event:\n%q", out)
+	}
+}
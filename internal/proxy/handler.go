@@ -8,6 +8,10 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"strconv"
+	"time"
+
+	"github.com/theadriann/vibeproxyplus/internal/thinking"
+	"github.com/theadriann/vibeproxyplus/internal/translate"
 )
 
 const (
@@ -15,21 +19,77 @@ const (
 	BetaInterleaved = "interleaved-thinking-2025-05-14"
 )
 
+// Options configures optional ThinkingProxy behaviour.
+type Options struct {
+	// CompactModel summarises conversations when /v1/responses/compact is
+	// emulated. Empty uses the model from the request.
+	CompactModel string
+	// CompactProviders lists providers with native compaction, matched
+	// against `owned_by` in the backend's /v1/models. Nil uses
+	// DefaultCompactProviders.
+	CompactProviders []string
+	// Translate converts requests between the Anthropic, Chat Completions
+	// and Responses protocols so any client can reach any model.
+	Translate bool
+	// Reasoning is the default way thinking content reaches clients.
+	// Empty means passthrough.
+	Reasoning translate.ReasoningMode
+	// ReasoningClients overrides Reasoning by User-Agent; first match wins.
+	ReasoningClients []ReasoningRule
+	// KeepAlive injects a keep-alive event into SSE streams that have been
+	// idle this long, e.g. during long thinking phases. Zero disables it.
+	KeepAlive time.Duration
+	// AuthDir is the backend's credential directory, summarised on
+	// /status. ModelsFile (config/models.json) maps each credential to the
+	// models it unlocks. Empty AuthDir leaves credentials out.
+	AuthDir    string
+	ModelsFile string
+	// ThinkingTiers name the budgets a -thinking-<tier> model suffix asks
+	// for. Nil uses thinking.DefaultTiers.
+	ThinkingTiers []thinking.Tier
+}
+
 type ThinkingProxy struct {
 	target *url.URL
 	proxy  *httputil.ReverseProxy
+	client *http.Client
+	opts   Options
+	owners modelOwners
+	drain  drainState
 }
 
 func NewThinkingProxy(targetPort int) *ThinkingProxy {
+	return NewThinkingProxyWithOptions(targetPort, Options{})
+}
+
+func NewThinkingProxyWithOptions(targetPort int, opts Options) *ThinkingProxy {
 	target, _ := url.Parse("http://127.0.0.1:" + strconv.Itoa(targetPort))
 
-	tp := &ThinkingProxy{target: target}
+	tp := &ThinkingProxy{target: target, client: &http.Client{}, opts: opts}
 	tp.proxy = &httputil.ReverseProxy{
-		Director: tp.director,
+		Director:       tp.director,
+		ModifyResponse: tp.modifyResponse,
+		// Flush every write so SSE events and keep-alives are never held
+		// back, whatever the response's content type says.
+		FlushInterval: -1,
 	}
 	return tp
 }
 
+func (tp *ThinkingProxy) modifyResponse(resp *http.Response) error {
+	if err := tp.translateResponse(resp); err != nil {
+		return err
+	}
+	if isEventStream(resp) && resp.StatusCode < 400 {
+		protocol := clientProtocol(resp.Request)
+		if tp.opts.KeepAlive > 0 {
+			resp.Body = newKeepAliveReader(resp.Body, protocol, tp.opts.KeepAlive)
+		}
+		resp.Body = tp.newDrainStream(resp.Request, resp.Body, protocol)
+	}
+	return nil
+}
+
 func (tp *ThinkingProxy) director(req *http.Request) {
 	req.URL.Scheme = tp.target.Scheme
 	req.URL.Host = tp.target.Host
@@ -37,11 +97,24 @@ func (tp *ThinkingProxy) director(req *http.Request) {
 }
 
 func (tp *ThinkingProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Refuse new work while draining for shutdown
+	if !tp.drain.begin() {
+		tp.writeDraining(w)
+		return
+	}
+	defer tp.drain.end()
+	r, cancel := withCancel(r)
+	defer cancel()
+
 	// Health check endpoint
 	if r.URL.Path == "/health" {
 		tp.handleHealth(w, r)
 		return
 	}
+	if r.URL.Path == "/status" {
+		tp.handleStatus(w, r)
+		return
+	}
 
 	// Only transform POST requests with body
 	if r.Method != http.MethodPost || r.Body == nil {
@@ -57,13 +130,37 @@ func (tp *ThinkingProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Convert to the backend's protocol before any model-specific patching
+	if tp.opts.Translate {
+		r, body, err = tp.translateRequest(r, body)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "failed to translate request: "+err.Error())
+			return
+		}
+	}
+
+	// Rewrite thinking content for clients that cannot render it
+	mode, err := tp.reasoningModeFor(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	r = applyReasoningMode(r, mode)
+
 	// Transform if needed
-	newBody, needsBetaHeader, err := TransformRequestBody(r.URL.Path, body)
+	newBody, needsBetaHeader, err := transformRequestBody(r.URL.Path, body, tp.thinkingTiers())
 	if err != nil {
 		log.Printf("Warning: failed to transform body: %v", err)
 		newBody = body
 	}
 
+	if needsBetaHeader && mode.HidesThinking() {
+		if out, dropped := dropInjectedThinking(body, newBody); dropped {
+			newBody, needsBetaHeader = out, false
+			log.Printf("Thinking disabled: %s reasoning mode cannot return signed thinking blocks with tool use", mode)
+		}
+	}
+
 	// Add beta header when Claude thinking is enabled
 	if needsBetaHeader {
 		existing := r.Header.Get(BetaHeader)
@@ -75,6 +172,12 @@ func (tp *ThinkingProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Transformed request: thinking enabled")
 	}
 
+	// Backends without native compaction get a local emulation
+	if r.URL.Path == "/v1/responses/compact" && !tp.supportsCompact(r, newBody) {
+		tp.emulateCompact(w, r, newBody)
+		return
+	}
+
 	// Update request
 	r.Body = io.NopCloser(bytes.NewReader(newBody))
 	r.ContentLength = int64(len(newBody))
@@ -0,0 +1,156 @@
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/theadriann/vibeproxyplus/internal/translate"
+)
+
+var (
+	anthropicPing = []byte("event: ping\ndata: {\"type\": \"ping\"}\n\n")
+	sseComment    = []byte(": keep-alive\n\n")
+)
+
+func isEventStream(resp *http.Response) bool {
+	return strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+}
+
+// clientProtocol returns the protocol the client is speaking, which differs
+// from the forwarded path when the request was translated.
+func clientProtocol(r *http.Request) translate.Protocol {
+	if t, ok := r.Context().Value(translationKey{}).(translation); ok {
+		return t.client
+	}
+	return translate.ProtocolForPath(r.URL.Path)
+}
+
+type chunk struct {
+	data []byte
+	err  error
+}
+
+// keepAliveReader forwards an SSE body and, whenever the backend has been
+// silent for interval, injects a keep-alive event between events. Anthropic
+// clients get a `ping` event once the message has started; everything else
+// gets an SSE comment, which every client ignores.
+type keepAliveReader struct {
+	body     io.ReadCloser
+	interval time.Duration
+	ping     []byte
+	chunks   chan chunk
+	done     chan struct{}
+	close    sync.Once
+	pending  []byte
+	// unsentPing is the rest of a keep-alive that didn't fit in p.
+	unsentPing []byte
+	err        error
+	started    bool
+	// tail holds the last bytes returned, to find event boundaries.
+	tail []byte
+}
+
+func newKeepAliveReader(body io.ReadCloser, protocol translate.Protocol, interval time.Duration) io.ReadCloser {
+	k := &keepAliveReader{
+		body:     body,
+		interval: interval,
+		ping:     sseComment,
+		chunks:   make(chan chunk),
+		done:     make(chan struct{}),
+	}
+	if protocol == translate.Anthropic {
+		k.ping = anthropicPing
+	}
+	go k.pump()
+	return k
+}
+
+func (k *keepAliveReader) pump() {
+	for {
+		buf := make([]byte, 32*1024)
+		n, err := k.body.Read(buf)
+		select {
+		case k.chunks <- chunk{data: buf[:n], err: err}:
+		case <-k.done:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (k *keepAliveReader) Read(p []byte) (int, error) {
+	if len(k.unsentPing) > 0 {
+		n := k.emit(p, k.unsentPing)
+		k.unsentPing = k.unsentPing[n:]
+		return n, nil
+	}
+
+	timer := time.NewTimer(k.interval)
+	defer timer.Stop()
+
+	for len(k.pending) == 0 && k.err == nil {
+		select {
+		case c := <-k.chunks:
+			k.pending, k.err = c.data, c.err
+		case <-k.done:
+			return 0, io.ErrClosedPipe
+		case <-timer.C:
+			if k.atBoundary() {
+				ping := sseComment
+				if k.started {
+					ping = k.ping
+				}
+				n := k.emit(p, ping)
+				k.unsentPing = ping[n:]
+				return n, nil
+			}
+			timer.Reset(k.interval)
+		}
+	}
+
+	if len(k.pending) > 0 {
+		n := k.emit(p, k.pending)
+		k.pending = k.pending[n:]
+		k.started = true
+		return n, nil
+	}
+	return 0, k.err
+}
+
+// emit copies what fits of data into p and remembers the tail of what
+// was sent.
+func (k *keepAliveReader) emit(p, data []byte) int {
+	n := copy(p, data)
+	k.tail = append(k.tail, p[:n]...)
+	if len(k.tail) > 4 {
+		k.tail = k.tail[len(k.tail)-4:]
+	}
+	return n
+}
+
+// atBoundary reports whether the output so far ends between events, so a
+// keep-alive cannot split one.
+func (k *keepAliveReader) atBoundary() bool {
+	return atEventBoundary(k.tail)
+}
+
+// atEventBoundary reports whether an SSE stream ending in tail is between
+// events.
+func atEventBoundary(tail []byte) bool {
+	return len(tail) == 0 || bytes.HasSuffix(tail, []byte("\n\n")) || bytes.HasSuffix(tail, []byte("\r\n\r\n"))
+}
+
+func (k *keepAliveReader) Close() error {
+	var err error
+	k.close.Do(func() {
+		close(k.done)
+		err = k.body.Close()
+	})
+	return err
+}
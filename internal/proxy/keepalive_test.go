@@ -0,0 +1,106 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/theadriann/vibeproxyplus/internal/translate"
+)
+
+// slowStream writes parts with a pause before each one, flushing as it goes.
+func slowStream(pause time.Duration, parts ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		for _, part := range parts {
+			time.Sleep(pause)
+			io.WriteString(w, part)
+			w.(http.Flusher).Flush()
+		}
+	}
+}
+
+func TestKeepAlive(t *testing.T) {
+	const messageStart = "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"m\"}}\n\n"
+	const messageStop = "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"
+	const chatChunk = "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n"
+
+	tests := []struct {
+		name    string
+		path    string
+		parts   []string
+		want    string
+		notWant string
+	}{
+		{"anthropic ping", "/v1/messages", []string{messageStart, messageStop}, messageStart + string(anthropicPing), ""},
+		{"anthropic comment before start", "/v1/messages", []string{messageStart}, string(sseComment) + messageStart, "event: ping"},
+		{"chat comment", "/v1/chat/completions", []string{chatChunk, "data: [DONE]\n\n"}, chatChunk + string(sseComment), "event: ping"},
+		{"never inside an event", "/v1/chat/completions", []string{"data: {\"choices\":", "[]}\n\n"}, "data: {\"choices\":[]}\n\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := httptest.NewServer(slowStream(60*time.Millisecond, tt.parts...))
+			defer backend.Close()
+
+			tp := newTestProxy(t, backend, Options{KeepAlive: 20 * time.Millisecond})
+			server := httptest.NewServer(tp)
+			defer server.Close()
+
+			resp, err := http.Post(server.URL+tt.path, "application/json", strings.NewReader(`{"model":"m","stream":true}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			out := string(body)
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("stream missing %q:\n%s", tt.want, out)
+			}
+			if tt.notWant != "" && strings.Contains(out, tt.notWant) {
+				t.Errorf("stream contains %q:\n%s", tt.notWant, out)
+			}
+		})
+	}
+}
+
+func TestKeepAliveDisabledByDefault(t *testing.T) {
+	backend := httptest.NewServer(slowStream(40*time.Millisecond, "data: [DONE]\n\n"))
+	defer backend.Close()
+
+	tp := newTestProxy(t, backend, Options{})
+	rec := httptest.NewRecorder()
+	tp.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"m"}`)))
+
+	if got := rec.Body.String(); got != "data: [DONE]\n\n" {
+		t.Errorf("unexpected stream: %q", got)
+	}
+}
+
+// A keep-alive larger than the caller's buffer arrives whole over several
+// reads.
+func TestKeepAliveSmallReads(t *testing.T) {
+	const messageStart = "event: message_start\ndata: {}\n\n"
+	body, w := io.Pipe()
+	r := newKeepAliveReader(body, translate.Anthropic, 10*time.Millisecond)
+	defer r.Close()
+
+	go io.WriteString(w, messageStart)
+	var out []byte
+	buf := make([]byte, 5)
+	for len(out) < len(messageStart)+len(anthropicPing) {
+		n, err := r.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, buf[:n]...)
+	}
+	if want := messageStart + string(anthropicPing); string(out) != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
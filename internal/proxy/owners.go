@@ -0,0 +1,107 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// modelOwnersTTL is how long the backend's model listing is reused before
+// it is fetched again.
+const modelOwnersTTL = 5 * time.Minute
+
+// modelOwnersRetry is how long a failed fetch is remembered, so requests
+// don't each wait on a backend that is down.
+const modelOwnersRetry = 10 * time.Second
+
+// modelOwners caches the `owned_by` provider of each model the backend
+// lists on /v1/models.
+type modelOwners struct {
+	mu      sync.Mutex
+	owners  map[string]string
+	fetched time.Time
+	failed  time.Time
+	// inflight is closed when the running fetch finishes; nil when none is.
+	inflight chan struct{}
+}
+
+// modelOwner returns the provider serving model, or "" when the backend
+// does not list it or cannot be reached. Credentials from r are forwarded.
+// One request fetches at a time, outside the lock; the others use the
+// stale listing, or wait for the fetch when there is none yet.
+func (tp *ThinkingProxy) modelOwner(r *http.Request, model string) string {
+	o := &tp.owners
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	stale := o.owners == nil || time.Since(o.fetched) > modelOwnersTTL
+	if !stale || time.Since(o.failed) < modelOwnersRetry {
+		return o.owners[model]
+	}
+
+	if o.inflight == nil {
+		done := make(chan struct{})
+		o.inflight = done
+		o.mu.Unlock()
+		owners, err := tp.fetchModelOwners(r)
+		o.mu.Lock()
+		if err == nil {
+			o.owners, o.fetched = owners, time.Now()
+		} else {
+			o.failed = time.Now()
+		}
+		o.inflight = nil
+		close(done)
+	} else if o.owners == nil {
+		wait := o.inflight
+		o.mu.Unlock()
+		select {
+		case <-wait:
+		case <-r.Context().Done():
+		}
+		o.mu.Lock()
+	}
+	return o.owners[model]
+}
+
+func (tp *ThinkingProxy) fetchModelOwners(r *http.Request) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tp.target.String()+"/v1/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range []string{"Authorization", "X-Api-Key"} {
+		if v := r.Header.Get(h); v != "" {
+			req.Header.Set(h, v)
+		}
+	}
+
+	resp, err := tp.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("backend /v1/models returned %s", resp.Status)
+	}
+
+	var listing struct {
+		Data []struct {
+			ID      string `json:"id"`
+			OwnedBy string `json:"owned_by"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return nil, err
+	}
+	owners := make(map[string]string, len(listing.Data))
+	for _, m := range listing.Data {
+		owners[m.ID] = m.OwnedBy
+	}
+	return owners, nil
+}
@@ -0,0 +1,111 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/theadriann/vibeproxyplus/internal/translate"
+)
+
+// ReasoningModeHeader lets a client pick its reasoning mode per request. It
+// is consumed by the proxy and never forwarded.
+const ReasoningModeHeader = "X-Reasoning-Mode"
+
+// ReasoningRule selects a reasoning mode for clients whose User-Agent
+// contains UserAgent (case-insensitive).
+type ReasoningRule struct {
+	UserAgent string
+	Mode      translate.ReasoningMode
+}
+
+// ParseReasoningRules parses a comma-separated list of agent=mode pairs,
+// e.g. "opencode=think-tags,factory-cli=strip".
+func ParseReasoningRules(s string) ([]ReasoningRule, error) {
+	var rules []ReasoningRule
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		agent, name, ok := strings.Cut(part, "=")
+		agent = strings.TrimSpace(agent)
+		if !ok || agent == "" {
+			return nil, fmt.Errorf("invalid reasoning rule %q (want agent=mode)", part)
+		}
+		mode, err := translate.ParseReasoningMode(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, ReasoningRule{UserAgent: agent, Mode: mode})
+	}
+	return rules, nil
+}
+
+// reasoningModeFor resolves the mode for a request: the request header
+// first, then the first matching client rule, then the default.
+func (tp *ThinkingProxy) reasoningModeFor(r *http.Request) (translate.ReasoningMode, error) {
+	if name := r.Header.Get(ReasoningModeHeader); name != "" {
+		r.Header.Del(ReasoningModeHeader)
+		return translate.ParseReasoningMode(name)
+	}
+	agent := strings.ToLower(r.UserAgent())
+	for _, rule := range tp.opts.ReasoningClients {
+		if strings.Contains(agent, strings.ToLower(rule.UserAgent)) {
+			return rule.Mode, nil
+		}
+	}
+	return translate.ParseReasoningMode(string(tp.opts.Reasoning))
+}
+
+// applyReasoningMode records the request's reasoning mode so modifyResponse
+// rewrites thinking content on the way back. Modes that leave the events
+// unchanged never force a native response through the translator.
+func applyReasoningMode(r *http.Request, mode translate.ReasoningMode) *http.Request {
+	if translate.NewReasoningFilter(mode) == nil {
+		return r
+	}
+
+	t, ok := r.Context().Value(translationKey{}).(translation)
+	if !ok {
+		client := translate.ProtocolForPath(r.URL.Path)
+		if client == translate.Unknown {
+			return r
+		}
+		t = translation{client: client, backend: client}
+	}
+	t.reasoning = mode
+
+	// Responses must arrive uncompressed so they can be rewritten.
+	r.Header.Del("Accept-Encoding")
+	return r.WithContext(context.WithValue(r.Context(), translationKey{}, t))
+}
+
+// dropInjectedThinking removes a thinking parameter added by the model
+// suffix from requests that use tools. A client whose reasoning mode hides
+// thinking blocks cannot send the signed block back with its tool results,
+// which Anthropic rejects on the next turn.
+func dropInjectedThinking(original, body []byte) ([]byte, bool) {
+	var before, data map[string]interface{}
+	if json.Unmarshal(original, &before) != nil || json.Unmarshal(body, &data) != nil {
+		return body, false
+	}
+	if _, ok := before["thinking"]; ok {
+		return body, false
+	}
+	if _, ok := data["thinking"]; !ok {
+		return body, false
+	}
+	if tools, _ := data["tools"].([]interface{}); len(tools) == 0 {
+		return body, false
+	}
+
+	delete(data, "thinking")
+	out, err := json.Marshal(data)
+	if err != nil {
+		return body, false
+	}
+	return out, true
+}
@@ -0,0 +1,192 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/theadriann/vibeproxyplus/internal/translate"
+)
+
+const anthropicThinkingStream = "event: message_start\n" +
+	`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":5,"output_tokens":1}}}` + "\n\n" +
+	"event: ping\ndata: {\"type\":\"ping\"}\n\n" +
+	"event: content_block_start\n" +
+	`data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}` + "\n\n" +
+	"event: content_block_delta\n" +
+	`data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"hmm"}}` + "\n\n" +
+	"event: content_block_stop\n" +
+	`data: {"type":"content_block_stop","index":0}` + "\n\n" +
+	"event: content_block_start\n" +
+	`data: {"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}` + "\n\n" +
+	"event: content_block_delta\n" +
+	`data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"done"}}` + "\n\n" +
+	"event: content_block_stop\n" +
+	`data: {"type":"content_block_stop","index":1}` + "\n\n" +
+	"event: message_delta\n" +
+	`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":2}}` + "\n\n" +
+	"event: message_stop\n" +
+	`data: {"type":"message_stop"}` + "\n\n"
+
+func TestParseReasoningRules(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []ReasoningRule
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"opencode=think-tags, factory-cli=strip", []ReasoningRule{
+			{UserAgent: "opencode", Mode: translate.ReasoningThinkTags},
+			{UserAgent: "factory-cli", Mode: translate.ReasoningStrip},
+		}, false},
+		{"opencode", nil, true},
+		{"=strip", nil, true},
+		{"opencode=hide", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseReasoningRules(tt.in)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseReasoningRules(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestReasoningModeFor(t *testing.T) {
+	tp := NewThinkingProxyWithOptions(8318, Options{
+		Reasoning: translate.ReasoningStrip,
+		ReasoningClients: []ReasoningRule{
+			{UserAgent: "OpenCode", Mode: translate.ReasoningThinkTags},
+		},
+	})
+	tests := []struct {
+		agent, header string
+		want          translate.ReasoningMode
+		wantErr       bool
+	}{
+		{"opencode/1.0 ai-sdk", "", translate.ReasoningThinkTags, false},
+		{"factory-cli/0.22", "", translate.ReasoningStrip, false},
+		{"opencode/1.0", "passthrough", translate.ReasoningPassthrough, false},
+		{"opencode/1.0", "bogus", "", true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+		r.Header.Set("User-Agent", tt.agent)
+		if tt.header != "" {
+			r.Header.Set(ReasoningModeHeader, tt.header)
+		}
+		got, err := tp.reasoningModeFor(r)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("agent %q header %q: got %q, %v", tt.agent, tt.header, got, err)
+		}
+		if r.Header.Get(ReasoningModeHeader) != "" {
+			t.Errorf("%s header must not be forwarded", ReasoningModeHeader)
+		}
+	}
+}
+
+func TestReasoningMode_UnknownHeaderIsRejected(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request must not reach the backend")
+	}))
+	defer backend.Close()
+
+	tp := newTestProxy(t, backend, Options{})
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(`{"model":"claude-sonnet-4-5"}`))
+	req.Header.Set(ReasoningModeHeader, "bogus")
+	rec := httptest.NewRecorder()
+	tp.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestReasoningMode_StreamRewrite(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, anthropicThinkingStream)
+	}))
+	defer backend.Close()
+
+	tests := []struct {
+		mode    translate.ReasoningMode
+		want    []string
+		notWant []string
+	}{
+		{translate.ReasoningPassthrough, []string{"event: ping", "thinking_delta"}, nil},
+		{translate.ReasoningContent, []string{"event: ping", "thinking_delta"}, nil},
+		{translate.ReasoningStrip, []string{`"text":"done"`}, []string{"thinking"}},
+		{translate.ReasoningThinkTags, []string{`\u003cthink\u003e\nhmm`, `"text":"done"`}, []string{"thinking_delta"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			tp := newTestProxy(t, backend, Options{Reasoning: tt.mode})
+			req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(
+				`{"model":"claude-sonnet-4-5","max_tokens":100,"stream":true,"messages":[{"role":"user","content":"hi"}]}`))
+			rec := httptest.NewRecorder()
+			tp.ServeHTTP(rec, req)
+
+			out := rec.Body.String()
+			if tt.mode == translate.ReasoningPassthrough || tt.mode == translate.ReasoningContent {
+				if out != anthropicThinkingStream {
+					t.Errorf("native stream was rewritten:\n%s", out)
+				}
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("stream missing %q:\n%s", want, out)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(out, notWant) {
+					t.Errorf("stream contains %q:\n%s", notWant, out)
+				}
+			}
+		})
+	}
+}
+
+func TestReasoningMode_DropsInjectedThinkingWithTools(t *testing.T) {
+	var gotBody map[string]interface{}
+	var gotBeta string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &gotBody)
+		gotBeta = r.Header.Get(BetaHeader)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id":"msg_1","content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn"}`)
+	}))
+	defer backend.Close()
+
+	tests := []struct {
+		name         string
+		mode         translate.ReasoningMode
+		tools        string
+		wantThinking bool
+	}{
+		{"strip with tools", translate.ReasoningStrip, `,"tools":[{"name":"ls","input_schema":{"type":"object"}}]`, false},
+		{"think-tags with tools", translate.ReasoningThinkTags, `,"tools":[{"name":"ls","input_schema":{"type":"object"}}]`, false},
+		{"strip without tools", translate.ReasoningStrip, "", true},
+		{"passthrough with tools", translate.ReasoningPassthrough, `,"tools":[{"name":"ls","input_schema":{"type":"object"}}]`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := newTestProxy(t, backend, Options{Reasoning: tt.mode})
+			req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(
+				`{"model":"claude-sonnet-4-5-thinking-4000","max_tokens":100,"messages":[{"role":"user","content":"hi"}]`+tt.tools+`}`))
+			tp.ServeHTTP(httptest.NewRecorder(), req)
+
+			_, hasThinking := gotBody["thinking"]
+			if hasThinking != tt.wantThinking || (gotBeta != "") != tt.wantThinking {
+				t.Errorf("thinking = %v, beta = %q, want thinking %v", hasThinking, gotBeta, tt.wantThinking)
+			}
+			if gotBody["model"] != "claude-sonnet-4-5" {
+				t.Errorf("model = %v, want suffix removed", gotBody["model"])
+			}
+		})
+	}
+}
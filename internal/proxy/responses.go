@@ -0,0 +1,140 @@
+package proxy
+
+import (
+	"strings"
+
+	"github.com/theadriann/vibeproxyplus/internal/translate"
+)
+
+// ResponsesCapabilities describes which non-canonical request shapes a
+// backend accepts on the Responses API. Anything not accepted natively is
+// rewritten into the canonical Responses shape before forwarding.
+type ResponsesCapabilities struct {
+	// StringInput: `input` may be a plain string instead of a list of items.
+	StringInput bool
+	// Messages: Chat Completions-style `messages` are accepted in place of `input`.
+	Messages bool
+	// System: a top-level `system` field is accepted in place of `instructions`.
+	System bool
+	// MaxTokens: `max_tokens` is accepted in place of `max_output_tokens`.
+	MaxTokens bool
+}
+
+type responsesModelRule struct {
+	match func(model string) bool
+	caps  ResponsesCapabilities
+}
+
+// responsesCapabilityTable is checked in order; the first matching rule wins.
+// Models without a rule get defaultResponsesCapabilities.
+var responsesCapabilityTable = []responsesModelRule{
+	// Codex backend rejects string input with "Input must be a list".
+	{match: isCodexModel},
+	// Other GPT models are served by the same Codex backend.
+	{match: hasPrefix("gpt-")},
+	// Claude and Gemini reach /v1/responses through CLIProxyAPIPlus
+	// translators, which only read list input and `instructions`.
+	{match: hasPrefix("claude-", "gemini-")},
+}
+
+// Unknown models are normalised fully: the canonical shape is accepted by
+// every backend, so rewriting is always safe.
+var defaultResponsesCapabilities = ResponsesCapabilities{}
+
+func responsesCapabilitiesFor(model string) ResponsesCapabilities {
+	for _, rule := range responsesCapabilityTable {
+		if rule.match(model) {
+			return rule.caps
+		}
+	}
+	return defaultResponsesCapabilities
+}
+
+func isCodexResponsesPath(path string) bool {
+	switch path {
+	case "/v1/responses", "/v1/responses/compact":
+		return true
+	default:
+		return false
+	}
+}
+
+func isCodexModel(model string) bool {
+	return strings.HasPrefix(model, "gpt-") && strings.Contains(model, "codex")
+}
+
+// normalizeResponsesRequest rewrites the parts of a Responses API request
+// that the backend for its model doesn't accept into the canonical shape:
+// list `input`, `instructions` and `max_output_tokens`. Returns true if
+// data was modified.
+func normalizeResponsesRequest(data map[string]interface{}, path string) bool {
+	if !isCodexResponsesPath(path) {
+		return false
+	}
+
+	model, _ := data["model"].(string)
+	caps := responsesCapabilitiesFor(model)
+	modified := false
+
+	if input, ok := data["input"].(string); ok && !caps.StringInput {
+		data["input"] = []interface{}{responsesMessage("user", "input_text", input)}
+		modified = true
+	}
+
+	if messages, ok := data["messages"].([]interface{}); ok && !caps.Messages {
+		items, instructions := translate.MessagesToInput(messages)
+		// Messages sent alongside input continue the conversation.
+		existing, _ := data["input"].([]interface{})
+		data["input"] = append(existing, items...)
+		if instructions != "" {
+			appendInstructions(data, instructions)
+		}
+		delete(data, "messages")
+		modified = true
+	}
+
+	if system, ok := data["system"]; ok && !caps.System {
+		if text := translate.TextFromContent(system); text != "" {
+			prependInstructions(data, text)
+		}
+		delete(data, "system")
+		modified = true
+	}
+
+	if maxTokens, ok := data["max_tokens"]; ok && !caps.MaxTokens {
+		if _, exists := data["max_output_tokens"]; !exists {
+			data["max_output_tokens"] = maxTokens
+		}
+		delete(data, "max_tokens")
+		modified = true
+	}
+
+	return modified
+}
+
+func responsesMessage(role, partType, text string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "message",
+		"role": role,
+		"content": []interface{}{
+			map[string]interface{}{
+				"type": partType,
+				"text": text,
+			},
+		},
+	}
+}
+
+func appendInstructions(data map[string]interface{}, text string) {
+	if existing, _ := data["instructions"].(string); existing != "" {
+		text = existing + "\n\n" + text
+	}
+	data["instructions"] = text
+}
+
+func prependInstructions(data map[string]interface{}, text string) {
+	if existing, _ := data["instructions"].(string); existing != "" {
+		text = text + "\n\n" + existing
+	}
+	data["instructions"] = text
+}
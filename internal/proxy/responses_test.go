@@ -0,0 +1,143 @@
+package proxy
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func transformResponses(t *testing.T, input string) map[string]interface{} {
+	t.Helper()
+	output, _, err := TransformRequestBody("/v1/responses", []byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(output, &body); err != nil {
+		t.Fatalf("invalid output json: %v", err)
+	}
+	return body
+}
+
+func TestNormalizeResponsesRequest_StringInputAnyModel(t *testing.T) {
+	for _, model := range []string{"gpt-5.2", "claude-sonnet-4-5-20250929", "gemini-3-pro-preview", "qwen3-coder-plus"} {
+		t.Run(model, func(t *testing.T) {
+			body := transformResponses(t, `{"model":"`+model+`","input":"hello"}`)
+			items, ok := body["input"].([]interface{})
+			if !ok || len(items) != 1 {
+				t.Fatalf("expected input list, got: %v", body["input"])
+			}
+		})
+	}
+}
+
+func TestNormalizeResponsesRequest_MessagesToInput(t *testing.T) {
+	body := transformResponses(t, `{
+		"model":"gpt-5.2",
+		"messages":[
+			{"role":"system","content":"be brief"},
+			{"role":"user","content":[{"type":"text","text":"look"},{"type":"image_url","image_url":{"url":"data:image/png;base64,AA"}}]},
+			{"role":"assistant","content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"ls","arguments":"{}"}}]},
+			{"role":"tool","tool_call_id":"call_1","content":"a.txt"}
+		]
+	}`)
+
+	if _, ok := body["messages"]; ok {
+		t.Fatal("messages should be removed")
+	}
+	if body["instructions"] != "be brief" {
+		t.Errorf("instructions = %v, want system message text", body["instructions"])
+	}
+
+	items, ok := body["input"].([]interface{})
+	if !ok || len(items) != 3 {
+		t.Fatalf("expected 3 input items, got: %v", body["input"])
+	}
+
+	user := items[0].(map[string]interface{})
+	parts := user["content"].([]interface{})
+	if parts[0].(map[string]interface{})["type"] != "input_text" || parts[1].(map[string]interface{})["type"] != "input_image" {
+		t.Errorf("unexpected user content: %v", parts)
+	}
+	if parts[1].(map[string]interface{})["image_url"] != "data:image/png;base64,AA" {
+		t.Errorf("image url not flattened: %v", parts[1])
+	}
+
+	call := items[1].(map[string]interface{})
+	if call["type"] != "function_call" || call["call_id"] != "call_1" || call["name"] != "ls" {
+		t.Errorf("unexpected function call: %v", call)
+	}
+
+	output := items[2].(map[string]interface{})
+	if output["type"] != "function_call_output" || output["output"] != "a.txt" {
+		t.Errorf("unexpected function call output: %v", output)
+	}
+}
+
+func TestNormalizeResponsesRequest_MessagesAlongsideInput(t *testing.T) {
+	body := transformResponses(t, `{
+		"model":"gpt-5.2",
+		"input":"first question",
+		"messages":[{"role":"assistant","content":"first answer"},{"role":"user","content":"follow-up"}]
+	}`)
+
+	items, ok := body["input"].([]interface{})
+	if !ok || len(items) != 3 {
+		t.Fatalf("expected input followed by both messages, got: %v", body["input"])
+	}
+	last := items[2].(map[string]interface{})
+	if text := last["content"].([]interface{})[0].(map[string]interface{})["text"]; text != "follow-up" {
+		t.Errorf("last item = %v, want the follow-up message", last)
+	}
+}
+
+func TestNormalizeResponsesRequest_SystemAndMaxTokens(t *testing.T) {
+	body := transformResponses(t, `{"model":"gpt-5.2","input":[],"system":[{"type":"text","text":"rules"}],"instructions":"more","max_tokens":1000}`)
+
+	if body["instructions"] != "rules\n\nmore" {
+		t.Errorf("instructions = %q", body["instructions"])
+	}
+	if _, ok := body["system"]; ok {
+		t.Error("system should be removed")
+	}
+	if body["max_output_tokens"] != float64(1000) {
+		t.Errorf("max_output_tokens = %v, want 1000", body["max_output_tokens"])
+	}
+	if _, ok := body["max_tokens"]; ok {
+		t.Error("max_tokens should be removed")
+	}
+}
+
+func TestNormalizeResponsesRequest_ListInputUnchanged(t *testing.T) {
+	input := `{"model":"gpt-5.2","input":[{"type":"message","role":"user","content":[{"type":"input_text","text":"hi"}]}]}`
+
+	output, _, err := TransformRequestBody("/v1/responses", []byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(output) != input {
+		t.Errorf("canonical request should pass through unchanged: %s", output)
+	}
+}
+
+func TestResponsesCapabilitiesFor(t *testing.T) {
+	saved := responsesCapabilityTable
+	defer func() { responsesCapabilityTable = saved }()
+	responsesCapabilityTable = append([]responsesModelRule{
+		{match: hasPrefix("lenient-"), caps: ResponsesCapabilities{StringInput: true, MaxTokens: true}},
+	}, saved...)
+
+	// A backend that accepts a shape keeps it; the rest is still normalised
+	body := transformResponses(t, `{"model":"lenient-1","input":"hello","max_tokens":100,"system":"rules"}`)
+	if body["input"] != "hello" || body["max_tokens"] != float64(100) {
+		t.Errorf("accepted shapes rewritten: %v", body)
+	}
+	if body["instructions"] != "rules" || body["system"] != nil {
+		t.Errorf("system not moved to instructions: %v", body)
+	}
+
+	for _, model := range []string{"gpt-5.1-codex", "gpt-5.2", "claude-sonnet-4-5", "unknown-model"} {
+		if caps := responsesCapabilitiesFor(model); caps != (ResponsesCapabilities{}) {
+			t.Errorf("%s: caps = %+v, want canonical only", model, caps)
+		}
+	}
+}
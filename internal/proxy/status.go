@@ -0,0 +1,42 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/theadriann/vibeproxyplus/internal/credentials"
+	"github.com/theadriann/vibeproxyplus/internal/modelsync"
+)
+
+// handleStatus reports backend reachability and, when the auth-dir is
+// known, the logged-in accounts. Token values are never included.
+func (tp *ThinkingProxy) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status := map[string]interface{}{"status": "healthy"}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, tp.target.String()+"/health", nil)
+	if resp, err := tp.client.Do(req); err != nil {
+		status["status"] = "unhealthy"
+		status["error"] = "backend unreachable"
+	} else {
+		resp.Body.Close()
+	}
+
+	if tp.opts.AuthDir != "" {
+		models, _ := modelsync.LoadModelIDs(tp.opts.ModelsFile)
+		accounts, err := credentials.Inspect(tp.opts.AuthDir, models, time.Now())
+		if err != nil {
+			status["credentials_error"] = err.Error()
+		} else {
+			if accounts == nil {
+				accounts = []credentials.Account{}
+			}
+			status["credentials"] = accounts
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,50 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStatus_ListsCredentialsWithoutTokens(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer backend.Close()
+
+	dir := t.TempDir()
+	authDir := filepath.Join(dir, "auth")
+	os.Mkdir(authDir, 0o700)
+	os.WriteFile(filepath.Join(authDir, "claude-me@example.com.json"), []byte(`{"type":"claude","email":"me@example.com","access_token":"sk-secret","refresh_token":"rt-secret","expired":"2099-01-01T00:00:00Z"}`), 0o600)
+	modelsFile := filepath.Join(dir, "models.json")
+	os.WriteFile(modelsFile, []byte(`{"models":{"claude":[{"id":"claude-sonnet-4-5"}]}}`), 0o644)
+
+	tp := newTestProxy(t, backend, Options{AuthDir: authDir, ModelsFile: modelsFile})
+	rec := httptest.NewRecorder()
+	tp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	body := rec.Body.String()
+	if strings.Contains(body, "secret") {
+		t.Fatalf("token leaked into /status: %s", body)
+	}
+	var status struct {
+		Status      string `json:"status"`
+		Credentials []struct {
+			Account string   `json:"account"`
+			State   string   `json:"state"`
+			Models  []string `json:"models"`
+		} `json:"credentials"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("invalid /status body %s: %v", body, err)
+	}
+	if status.Status != "healthy" || len(status.Credentials) != 1 {
+		t.Fatalf("status = %s", body)
+	}
+	c := status.Credentials[0]
+	if c.Account != "me@example.com" || c.State != "valid" || len(c.Models) != 1 {
+		t.Errorf("credential = %+v", c)
+	}
+}
@@ -4,16 +4,23 @@ import (
 	"encoding/json"
 	"strconv"
 	"strings"
+
+	"github.com/theadriann/vibeproxyplus/internal/thinking"
 )
 
 const (
-	MaxThinkingBudget = 32768
+	MaxThinkingBudget = thinking.MaxBudget
 	ThinkingSuffix    = "-thinking-"
 )
 
-// ParseThinkingSuffix extracts thinking budget from model name.
+// ParseThinkingSuffix extracts thinking budget from model name, given as
+// tokens or as one of thinking.DefaultTiers (e.g. -thinking-high).
 // Returns: cleanModel, budgetTokens, hasThinking
 func ParseThinkingSuffix(model string) (string, int, bool) {
+	return parseThinkingSuffix(model, thinking.DefaultTiers)
+}
+
+func parseThinkingSuffix(model string, tiers []thinking.Tier) (string, int, bool) {
 	idx := strings.LastIndex(model, ThinkingSuffix)
 	if idx == -1 {
 		return model, 0, false
@@ -21,8 +28,13 @@ func ParseThinkingSuffix(model string) (string, int, bool) {
 
 	budgetStr := model[idx+len(ThinkingSuffix):]
 	budget, err := strconv.Atoi(budgetStr)
+	if tier, ok := thinking.Find(tiers, budgetStr); ok {
+		// The proxy doesn't know the model's range; tiers span the default
+		budget, _ = tier.Resolve(thinking.Range{})
+		err = nil
+	}
 	if err != nil || budget <= 0 {
-		// Invalid budget - strip suffix but don't enable thinking
+		// Invalid budget or an off tier - strip suffix but don't enable thinking
 		return model[:idx], 0, false
 	}
 
@@ -55,61 +67,29 @@ func HasThinkingPattern(model string) bool {
 	return false
 }
 
-func isCodexResponsesPath(path string) bool {
-	switch path {
-	case "/v1/responses", "/v1/responses/compact":
-		return true
-	default:
-		return false
-	}
-}
-
-func isCodexModel(model string) bool {
-	return strings.HasPrefix(model, "gpt-") && strings.Contains(model, "codex")
-}
-
-func normalizeCodexResponsesInput(data map[string]interface{}, path string) bool {
-	if !isCodexResponsesPath(path) {
-		return false
-	}
-
-	model, ok := data["model"].(string)
-	if !ok || !isCodexModel(model) {
-		return false
-	}
-
-	input, ok := data["input"].(string)
-	if !ok {
-		return false
-	}
-
-	data["input"] = []interface{}{
-		map[string]interface{}{
-			"type": "message",
-			"role": "user",
-			"content": []interface{}{
-				map[string]interface{}{
-					"type": "input_text",
-					"text": input,
-				},
-			},
-		},
-	}
-	return true
-}
-
 // TransformRequestBody modifies the JSON body when needed.
 // Returns: transformedBody, needsBetaHeader, error
 // needsBetaHeader is true if either:
 // - Body was transformed with thinking parameter
 // - Model has a thinking pattern that backend will handle (needs beta header)
 func TransformRequestBody(path string, body []byte) ([]byte, bool, error) {
+	return transformRequestBody(path, body, thinking.DefaultTiers)
+}
+
+func (tp *ThinkingProxy) thinkingTiers() []thinking.Tier {
+	if tp.opts.ThinkingTiers != nil {
+		return tp.opts.ThinkingTiers
+	}
+	return thinking.DefaultTiers
+}
+
+func transformRequestBody(path string, body []byte, tiers []thinking.Tier) ([]byte, bool, error) {
 	var data map[string]interface{}
 	if err := json.Unmarshal(body, &data); err != nil {
 		return body, false, err
 	}
 
-	modified := normalizeCodexResponsesInput(data, path)
+	modified := normalizeResponsesRequest(data, path)
 
 	model, ok := data["model"].(string)
 	if !ok {
@@ -130,7 +110,7 @@ func TransformRequestBody(path string, body []byte) ([]byte, bool, error) {
 	}
 
 	// Check for -thinking-NUMBER suffix that we handle ourselves
-	cleanModel, budget, hasThinkingSuffix := ParseThinkingSuffix(model)
+	cleanModel, budget, hasThinkingSuffix := parseThinkingSuffix(model, tiers)
 	if hasThinkingSuffix {
 		// Update model name
 		data["model"] = cleanModel
@@ -147,8 +127,12 @@ func TransformRequestBody(path string, body []byte) ([]byte, bool, error) {
 			minMaxTokens = MaxThinkingBudget
 		}
 
-		if maxTokens, ok := data["max_tokens"].(float64); !ok || int(maxTokens) <= budget {
-			data["max_tokens"] = minMaxTokens
+		maxTokensKey := "max_tokens"
+		if isCodexResponsesPath(path) {
+			maxTokensKey = "max_output_tokens"
+		}
+		if maxTokens, ok := data[maxTokensKey].(float64); !ok || int(maxTokens) <= budget {
+			data[maxTokensKey] = minMaxTokens
 		}
 
 		output, err := json.Marshal(data)
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+
+	"github.com/theadriann/vibeproxyplus/internal/thinking"
 )
 
 func TestParseThinkingSuffix(t *testing.T) {
@@ -56,6 +58,13 @@ func TestParseThinkingSuffix(t *testing.T) {
 			wantBudget:      32768,
 			wantHasThinking: true,
 		},
+		{
+			name:            "named tier",
+			model:           "claude-sonnet-4-5-20250929-thinking-high",
+			wantModel:       "claude-sonnet-4-5-20250929",
+			wantBudget:      13312,
+			wantHasThinking: true,
+		},
 		{
 			name:            "invalid budget ignored",
 			model:           "claude-opus-4-5-20251101-thinking-abc",
@@ -81,6 +90,19 @@ func TestParseThinkingSuffix(t *testing.T) {
 	}
 }
 
+func TestParseThinkingSuffixCustomTiers(t *testing.T) {
+	tiers := []thinking.Tier{{Name: "off"}, {Name: "deep", Level: 1}}
+	if model, budget, ok := parseThinkingSuffix("claude-opus-4-6-thinking-deep", tiers); model != "claude-opus-4-6" || budget != MaxThinkingBudget || !ok {
+		t.Errorf("deep = %s %d %v", model, budget, ok)
+	}
+	if model, budget, ok := parseThinkingSuffix("claude-opus-4-6-thinking-off", tiers); model != "claude-opus-4-6" || budget != 0 || ok {
+		t.Errorf("off = %s %d %v", model, budget, ok)
+	}
+	if _, _, ok := parseThinkingSuffix("claude-opus-4-6-thinking-high", tiers); ok {
+		t.Error("default tier accepted with custom tiers")
+	}
+}
+
 func TestTransformRequestBody(t *testing.T) {
 	input := `{"model":"claude-opus-4-5-20251101-thinking-10000","messages":[{"role":"user","content":"hi"}]}`
 
@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/theadriann/vibeproxyplus/internal/translate"
+)
+
+// backendProtocolTable maps models to the protocol their backend speaks
+// natively; the first match wins and everything else uses Chat Completions.
+// Only consulted when Options.Translate is set.
+var backendProtocolTable = []struct {
+	match    func(string) bool
+	protocol translate.Protocol
+}{
+	{match: hasPrefix("claude-"), protocol: translate.Anthropic},
+	{match: hasPrefix("gpt-"), protocol: translate.Responses},
+}
+
+func hasPrefix(prefixes ...string) func(string) bool {
+	return func(model string) bool {
+		for _, p := range prefixes {
+			if strings.HasPrefix(model, p) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func backendProtocolFor(model string) translate.Protocol {
+	for _, rule := range backendProtocolTable {
+		if rule.match(model) {
+			return rule.protocol
+		}
+	}
+	return translate.Chat
+}
+
+type translationKey struct{}
+
+// translation records how a request was rewritten so the response can be
+// converted back into the client's protocol.
+type translation struct {
+	client    translate.Protocol
+	backend   translate.Protocol
+	reasoning translate.ReasoningMode
+}
+
+// translateRequest converts the request into the protocol native to its
+// model's backend. Requests that need no translation are returned unchanged.
+func (tp *ThinkingProxy) translateRequest(r *http.Request, body []byte) (*http.Request, []byte, error) {
+	client := translate.ProtocolForPath(r.URL.Path)
+	if client == translate.Unknown {
+		return r, body, nil
+	}
+
+	var data struct {
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return r, body, nil
+	}
+	backend := backendProtocolFor(data.Model)
+	if backend == client {
+		return r, body, nil
+	}
+
+	out, err := translate.Request(client, backend, body, tp.thinkingTiers())
+	if err != nil {
+		return r, body, err
+	}
+	log.Printf("Translating %s -> %s for %s", client, backend, data.Model)
+
+	r = r.WithContext(context.WithValue(r.Context(), translationKey{}, translation{client: client, backend: backend}))
+	r.URL.Path = backend.Path()
+	// Responses must arrive uncompressed so they can be rewritten.
+	r.Header.Del("Accept-Encoding")
+	if backend == translate.Anthropic && r.Header.Get("anthropic-version") == "" {
+		r.Header.Set("anthropic-version", "2023-06-01")
+	}
+	return r, out, nil
+}
+
+// translateResponse converts translated responses back into the client's
+// protocol and applies its reasoning mode. Event streams are converted
+// incrementally.
+func (tp *ThinkingProxy) translateResponse(resp *http.Response) error {
+	t, ok := resp.Request.Context().Value(translationKey{}).(translation)
+	if !ok {
+		return nil
+	}
+
+	filter := translate.NewReasoningFilter(t.reasoning)
+	if isEventStream(resp) && resp.StatusCode < 400 {
+		resp.Body = translate.NewStreamReader(t.backend, t.client, resp.Body, filter)
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		return nil
+	}
+
+	if resp.StatusCode >= 400 && t.backend == t.client {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	var out []byte
+	if resp.StatusCode >= 400 {
+		out = translate.ErrorBody(t.client, translate.ErrorMessage(body))
+	} else if out, err = translate.Response(t.backend, t.client, body, filter); err != nil {
+		log.Printf("Warning: failed to translate response: %v", err)
+		out = body
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(out))
+	resp.ContentLength = int64(len(out))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(out)))
+	resp.Header.Set("Content-Type", "application/json")
+	return nil
+}
@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTranslate_AnthropicClientToChatBackendStream(t *testing.T) {
+	var gotPath string
+	var gotBody map[string]interface{}
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &gotBody)
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, `data: {"id":"c1","model":"gemini-2.5-pro","choices":[{"index":0,"delta":{"content":"hi"}}]}`+"\n\n")
+		io.WriteString(w, `data: {"id":"c1","model":"gemini-2.5-pro","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`+"\n\n")
+		io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer backend.Close()
+
+	tp := newTestProxy(t, backend, Options{Translate: true})
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(
+		`{"model":"gemini-2.5-pro","max_tokens":100,"stream":true,"system":"be brief","messages":[{"role":"user","content":"hello"}]}`))
+	rec := httptest.NewRecorder()
+	tp.ServeHTTP(rec, req)
+
+	if gotPath != "/v1/chat/completions" {
+		t.Fatalf("backend path = %q, want /v1/chat/completions", gotPath)
+	}
+	messages, _ := gotBody["messages"].([]interface{})
+	if len(messages) != 2 {
+		t.Fatalf("expected system + user messages, got %v", gotBody["messages"])
+	}
+
+	out := rec.Body.String()
+	for _, want := range []string{"event: message_start", `"text":"hi"`, `"stop_reason":"end_turn"`, "event: message_stop"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("client stream missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestTranslate_ChatClientToAnthropicBackendWithThinking(t *testing.T) {
+	var gotPath string
+	var gotBody map[string]interface{}
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &gotBody)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id":"msg_1","model":"claude-opus-4-5-20251101","content":[{"type":"thinking","thinking":"hmm","signature":"s"},{"type":"text","text":"done"}],"stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":2}}`)
+	}))
+	defer backend.Close()
+
+	tp := newTestProxy(t, backend, Options{Translate: true})
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(
+		`{"model":"claude-opus-4-5-20251101-thinking-4000","messages":[{"role":"user","content":"hello"}]}`))
+	rec := httptest.NewRecorder()
+	tp.ServeHTTP(rec, req)
+
+	if gotPath != "/v1/messages" {
+		t.Fatalf("backend path = %q, want /v1/messages", gotPath)
+	}
+	if gotBody["model"] != "claude-opus-4-5-20251101" {
+		t.Errorf("thinking suffix not handled after translation: %v", gotBody["model"])
+	}
+	if thinking, _ := gotBody["thinking"].(map[string]interface{}); thinking["budget_tokens"] != float64(4000) {
+		t.Errorf("thinking budget not set: %v", gotBody["thinking"])
+	}
+
+	var resp struct {
+		Object  string `json:"object"`
+		Choices []struct {
+			Message struct {
+				Content          string `json:"content"`
+				ReasoningContent string `json:"reasoning_content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v\n%s", err, rec.Body.String())
+	}
+	if resp.Object != "chat.completion" || resp.Choices[0].Message.Content != "done" || resp.Choices[0].Message.ReasoningContent != "hmm" {
+		t.Errorf("unexpected chat response: %s", rec.Body.String())
+	}
+}
+
+func TestTranslate_ErrorBodyUsesClientShape(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"detail":"Input must be a list"}`)
+	}))
+	defer backend.Close()
+
+	tp := newTestProxy(t, backend, Options{Translate: true})
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(`{"model":"gpt-5.2","max_tokens":10,"messages":[{"role":"user","content":"x"}]}`))
+	rec := httptest.NewRecorder()
+	tp.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if got := rec.Body.String(); !strings.Contains(got, `"type":"error"`) || !strings.Contains(got, "Input must be a list") {
+		t.Errorf("expected Anthropic error shape, got %s", got)
+	}
+}
+
+func TestTranslate_UnsupportedFieldIsRejected(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request must not reach the backend")
+	}))
+	defer backend.Close()
+
+	tp := newTestProxy(t, backend, Options{Translate: true})
+	req := httptest.NewRequest(http.MethodPost, "/v1/responses", strings.NewReader(`{"model":"gemini-2.5-pro","input":"next","previous_response_id":"resp_1"}`))
+	rec := httptest.NewRecorder()
+	tp.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "previous_response_id") {
+		t.Errorf("got %d %s, want 400 naming previous_response_id", rec.Code, rec.Body.String())
+	}
+}
+
+func TestTranslate_DisabledByDefault(t *testing.T) {
+	var gotPath string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+	}))
+	defer backend.Close()
+
+	tp := newTestProxy(t, backend, Options{})
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(`{"model":"gemini-2.5-pro","messages":[]}`))
+	tp.ServeHTTP(httptest.NewRecorder(), req)
+
+	if gotPath != "/v1/messages" {
+		t.Errorf("path rewritten without -translate: %q", gotPath)
+	}
+}
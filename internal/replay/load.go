@@ -0,0 +1,173 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/theadriann/vibeproxyplus/internal/errorlog"
+)
+
+// Request is a single recorded request/response pair that can be replayed.
+type Request struct {
+	Source   string
+	Method   string
+	Path     string
+	Model    string
+	Headers  http.Header
+	Body     []byte
+	Status   int
+	Response []byte
+}
+
+// captureRecord is one line of a capture JSONL file.
+type captureRecord struct {
+	Method   string            `json:"method"`
+	Path     string            `json:"path"`
+	Headers  map[string]string `json:"headers"`
+	Body     json.RawMessage   `json:"body"`
+	Status   int               `json:"status"`
+	Response json.RawMessage   `json:"response"`
+}
+
+// Load reads recorded requests from a capture JSONL file, an error log file
+// or a directory containing either.
+func Load(path string) ([]Request, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return loadFile(path)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if ext := filepath.Ext(e.Name()); ext == ".log" || ext == ".jsonl" {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var requests []Request
+	for _, name := range names {
+		reqs, err := loadFile(filepath.Join(path, name))
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, reqs...)
+	}
+	return requests, nil
+}
+
+func loadFile(path string) ([]Request, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if errorlog.IsErrorLog(data) {
+		entry, err := errorlog.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		entry.File = path
+		req := fromErrorLog(entry)
+		if req.Method == "" {
+			req.Method = http.MethodPost
+		}
+		return []Request{req}, nil
+	}
+
+	return parseCapture(path, data)
+}
+
+func parseCapture(path string, data []byte) ([]Request, error) {
+	var requests []Request
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		var rec captureRecord
+		if err := json.Unmarshal(text, &rec); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+
+		req := Request{
+			Source:   fmt.Sprintf("%s:%d", path, line),
+			Method:   rec.Method,
+			Path:     rec.Path,
+			Headers:  make(http.Header),
+			Body:     rawPayload(rec.Body),
+			Status:   rec.Status,
+			Response: rawPayload(rec.Response),
+		}
+		if req.Method == "" {
+			req.Method = http.MethodPost
+		}
+		for k, v := range rec.Headers {
+			req.Headers.Set(k, v)
+		}
+		req.Model = modelFromBody(req.Body)
+		requests = append(requests, req)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return requests, nil
+}
+
+// rawPayload returns captured bodies as bytes. Bodies may be recorded either
+// as embedded JSON or as a JSON string holding the raw payload.
+func rawPayload(raw json.RawMessage) []byte {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var s string
+	if raw[0] == '"' && json.Unmarshal(raw, &s) == nil {
+		return []byte(s)
+	}
+	return []byte(raw)
+}
+
+func fromErrorLog(entry *errorlog.Entry) Request {
+	return Request{
+		Source:   entry.File,
+		Method:   entry.Method,
+		Path:     entry.URL,
+		Model:    entry.Model(),
+		Headers:  entry.Headers,
+		Body:     entry.RequestBody,
+		Status:   entry.Response.Status,
+		Response: entry.Response.Body,
+	}
+}
+
+func modelFromBody(body []byte) string {
+	var data struct {
+		Model string `json:"model"`
+	}
+	if json.Unmarshal(body, &data) != nil {
+		return ""
+	}
+	return data.Model
+}
@@ -0,0 +1,178 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Filter selects which recorded requests to replay. Empty fields match all.
+type Filter struct {
+	Path   string
+	Model  string
+	Status int
+}
+
+func (f Filter) Match(req Request) bool {
+	if f.Path != "" && req.Path != f.Path {
+		return false
+	}
+	if f.Model != "" && !strings.Contains(req.Model, f.Model) {
+		return false
+	}
+	if f.Status != 0 && req.Status != f.Status {
+		return false
+	}
+	return true
+}
+
+// skippedHeaders are recorded headers that must not be replayed verbatim.
+var skippedHeaders = map[string]bool{
+	"Content-Length":  true,
+	"Accept-Encoding": true,
+	"Connection":      true,
+	"Host":            true,
+	"X-Forwarded-For": true,
+}
+
+// Replayer re-sends recorded requests to a target base URL.
+type Replayer struct {
+	Target *url.URL
+	Client *http.Client
+	// APIKey replaces the recorded Authorization header, which error logs redact.
+	APIKey string
+}
+
+// Result is the outcome of replaying a single request.
+type Result struct {
+	Status int
+	Body   []byte
+}
+
+func (rp *Replayer) Replay(ctx context.Context, req Request) (*Result, error) {
+	target := *rp.Target
+	target.Path = strings.TrimSuffix(target.Path, "/") + req.Path
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, target.String(), bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range req.Headers {
+		if skippedHeaders[http.CanonicalHeaderKey(key)] {
+			continue
+		}
+		for _, v := range values {
+			httpReq.Header.Add(key, v)
+		}
+	}
+	if rp.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+rp.APIKey)
+	}
+
+	client := rp.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Status: resp.StatusCode, Body: body}, nil
+}
+
+// DiffBodies compares two response bodies. JSON bodies are compared field by
+// field; anything else is compared as text. Returns one line per difference.
+func DiffBodies(recorded, replayed []byte) []string {
+	var a, b interface{}
+	if json.Unmarshal(recorded, &a) != nil || json.Unmarshal(replayed, &b) != nil {
+		if bytes.Equal(bytes.TrimSpace(recorded), bytes.TrimSpace(replayed)) {
+			return nil
+		}
+		return []string{fmt.Sprintf("body: %s -> %s", truncate(string(recorded)), truncate(string(replayed)))}
+	}
+
+	var diffs []string
+	diffValues("$", a, b, &diffs)
+	return diffs
+}
+
+func diffValues(path string, a, b interface{}, diffs *[]string) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make(map[string]bool)
+		for k := range av {
+			keys[k] = true
+		}
+		for k := range bv {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			left, inA := av[k]
+			right, inB := bv[k]
+			switch {
+			case !inA:
+				*diffs = append(*diffs, fmt.Sprintf("%s.%s: added %s", path, k, encode(right)))
+			case !inB:
+				*diffs = append(*diffs, fmt.Sprintf("%s.%s: removed (was %s)", path, k, encode(left)))
+			default:
+				diffValues(path+"."+k, left, right, diffs)
+			}
+		}
+		return
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(av) || i < len(bv); i++ {
+			elem := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(av):
+				*diffs = append(*diffs, fmt.Sprintf("%s: added %s", elem, encode(bv[i])))
+			case i >= len(bv):
+				*diffs = append(*diffs, fmt.Sprintf("%s: removed (was %s)", elem, encode(av[i])))
+			default:
+				diffValues(elem, av[i], bv[i], diffs)
+			}
+		}
+		return
+	}
+
+	if ea, eb := encode(a), encode(b); ea != eb {
+		*diffs = append(*diffs, fmt.Sprintf("%s: %s -> %s", path, ea, eb))
+	}
+}
+
+func encode(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return truncate(string(data))
+}
+
+func truncate(s string) string {
+	const max = 200
+	if len(s) > max {
+		return s[:max] + "..."
+	}
+	return s
+}
@@ -0,0 +1,173 @@
+package replay
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_ErrorLog(t *testing.T) {
+	requests, err := Load(filepath.Join("..", "..", "testdata", "error-v1-responses.log"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+
+	req := requests[0]
+	if req.Method != "POST" || req.Path != "/v1/responses" {
+		t.Errorf("got %s %s, want POST /v1/responses", req.Method, req.Path)
+	}
+	if req.Model != "gpt-5.2-codex" {
+		t.Errorf("model = %q, want gpt-5.2-codex", req.Model)
+	}
+	if req.Status != 400 {
+		t.Errorf("status = %d, want 400", req.Status)
+	}
+	if !strings.HasPrefix(string(req.Body), `{"model":"gpt-5.2-codex","input":"Please summarize`) {
+		t.Errorf("unexpected body: %s", req.Body)
+	}
+	if string(req.Response) != `{"detail":"Input must be a list"}` {
+		t.Errorf("unexpected response: %s", req.Response)
+	}
+	if req.Headers.Get("Content-Type") != "application/json" {
+		t.Errorf("missing Content-Type header: %v", req.Headers)
+	}
+}
+
+func TestLoad_CaptureJSONL(t *testing.T) {
+	content := `{"method":"POST","path":"/v1/messages","headers":{"Content-Type":"application/json"},"body":{"model":"claude-opus-4-5"},"status":200,"response":"ok"}
+{"path":"/v1/responses","body":"{\"model\":\"gpt-5.2-codex\"}","status":400}
+`
+	path := writeFile(t, t.TempDir(), "capture.jsonl", content)
+
+	requests, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+	if requests[0].Model != "claude-opus-4-5" || string(requests[0].Response) != "ok" {
+		t.Errorf("unexpected first request: %+v", requests[0])
+	}
+	if requests[1].Method != "POST" || requests[1].Model != "gpt-5.2-codex" {
+		t.Errorf("unexpected second request: %+v", requests[1])
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	req := Request{Path: "/v1/responses", Model: "gpt-5.2-codex", Status: 400}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty filter", Filter{}, true},
+		{"path match", Filter{Path: "/v1/responses"}, true},
+		{"path mismatch", Filter{Path: "/v1/messages"}, false},
+		{"model substring", Filter{Model: "codex"}, true},
+		{"status mismatch", Filter{Status: 500}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(req); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplayer_Replay(t *testing.T) {
+	var gotAuth, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/responses" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		gotAuth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"resp_1"}`))
+	}))
+	defer server.Close()
+
+	target, _ := url.Parse(server.URL)
+	rp := &Replayer{Target: target, APIKey: "secret"}
+
+	req := Request{
+		Method:   "POST",
+		Path:     "/v1/responses",
+		Headers:  http.Header{"Authorization": {"Bearer du...my"}},
+		Body:     []byte(`{"model":"gpt-5.2-codex","input":"hello"}`),
+		Status:   400,
+		Response: []byte(`{"detail":"Input must be a list"}`),
+	}
+
+	result, err := rp.Replay(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != 200 {
+		t.Errorf("status = %d, want 200", result.Status)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want override", gotAuth)
+	}
+	if gotBody != string(req.Body) {
+		t.Errorf("body not forwarded: %s", gotBody)
+	}
+
+	diffs := DiffBodies(req.Response, result.Body)
+	want := []string{`$.detail: removed (was "Input must be a list")`, `$.id: added "resp_1"`}
+	if len(diffs) != len(want) {
+		t.Fatalf("got diffs %v, want %v", diffs, want)
+	}
+	for i := range want {
+		if diffs[i] != want[i] {
+			t.Errorf("diff[%d] = %q, want %q", i, diffs[i], want[i])
+		}
+	}
+}
+
+func TestDiffBodies(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []string
+	}{
+		{"equal", `{"a":1}`, `{"a":1}`, nil},
+		{"nested", `{"error":{"message":"bad","code":1}}`, `{"error":{"message":"worse","code":1}}`, []string{`$.error.message: "bad" -> "worse"`}},
+		{"longer array", `{"items":[1]}`, `{"items":[1,2]}`, []string{`$.items[1]: added 2`}},
+		{"shorter array", `{"items":[1,{"x":true}]}`, `{"items":[1]}`, []string{`$.items[1]: removed (was {"x":true})`}},
+		{"type change", `{"a":[1]}`, `{"a":"1"}`, []string{`$.a: [1] -> "1"`}},
+		{"non-JSON", `plain error`, `other error`, []string{`body: plain error -> other error`}},
+		{"non-JSON equal", `same`, `same`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffBodies([]byte(tt.old), []byte(tt.new)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffBodies() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package supervisor
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the backend in its own process group, so a Ctrl+C
+// in the terminal reaches only the proxy, which then stops the backend after
+// draining.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
@@ -0,0 +1,13 @@
+package supervisor
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the backend in its own process group, so a Ctrl+C
+// in the console reaches only the proxy, which then stops the backend after
+// draining.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
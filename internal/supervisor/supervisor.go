@@ -0,0 +1,241 @@
+// Package supervisor runs CLIProxyAPIPlus as a child process: it waits for
+// the backend to accept requests, restarts it with backoff when it exits
+// unexpectedly and stops it on shutdown.
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Config describes the backend process and how it is supervised. Zero
+// durations take the defaults below.
+type Config struct {
+	Binary string
+	Args   []string
+	Dir    string
+	// Env is added to the proxy's own environment.
+	Env []string
+
+	// ReadyURL is polled until it answers; any HTTP response counts as
+	// ready. Empty skips the readiness check.
+	ReadyURL     string
+	ReadyTimeout time.Duration
+
+	// Restarts wait MinBackoff, doubling up to MaxBackoff. A process that
+	// ran longer than MaxBackoff resets the backoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// StopTimeout is how long Stop waits after an interrupt before killing.
+	StopTimeout time.Duration
+
+	// Output receives the backend's stdout and stderr, one Prefix per line.
+	Output io.Writer
+	Prefix string
+}
+
+const (
+	defaultReadyTimeout = 30 * time.Second
+	defaultMinBackoff   = time.Second
+	defaultMaxBackoff   = 30 * time.Second
+	defaultStopTimeout  = 10 * time.Second
+	readyPollInterval   = 100 * time.Millisecond
+)
+
+// Supervisor keeps one backend process running between Start and Stop.
+type Supervisor struct {
+	cfg      Config
+	out      *prefixWriter
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+
+	mu       sync.Mutex
+	restarts int
+}
+
+type process struct {
+	cmd     *exec.Cmd
+	started time.Time
+	exited  chan struct{}
+	err     error
+}
+
+func New(cfg Config) *Supervisor {
+	if cfg.ReadyTimeout == 0 {
+		cfg.ReadyTimeout = defaultReadyTimeout
+	}
+	if cfg.MinBackoff == 0 {
+		cfg.MinBackoff = defaultMinBackoff
+	}
+	if cfg.MaxBackoff == 0 {
+		cfg.MaxBackoff = defaultMaxBackoff
+	}
+	if cfg.StopTimeout == 0 {
+		cfg.StopTimeout = defaultStopTimeout
+	}
+	if cfg.Output == nil {
+		cfg.Output = os.Stderr
+	}
+	return &Supervisor{
+		cfg:  cfg,
+		out:  &prefixWriter{out: cfg.Output, prefix: cfg.Prefix},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Start launches the backend and waits until it is ready, then keeps it
+// running in the background. A backend that cannot start or exits before it
+// is ready is reported as an error rather than restarted.
+func (s *Supervisor) Start(ctx context.Context) error {
+	p, err := s.spawn()
+	if err != nil {
+		close(s.done)
+		return err
+	}
+	if err := s.waitReady(ctx, p); err != nil {
+		s.terminate(p)
+		close(s.done)
+		return err
+	}
+	go s.run(p)
+	return nil
+}
+
+// Stop ends supervision and shuts the backend down: an interrupt first, then
+// a kill after StopTimeout.
+func (s *Supervisor) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+}
+
+// Restarts reports how many times the backend has been restarted.
+func (s *Supervisor) Restarts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.restarts
+}
+
+func (s *Supervisor) run(p *process) {
+	defer close(s.done)
+	backoff := s.cfg.MinBackoff
+	for {
+		select {
+		case <-p.exited:
+		case <-s.stop:
+			s.terminate(p)
+			return
+		}
+
+		if time.Since(p.started) > s.cfg.MaxBackoff {
+			backoff = s.cfg.MinBackoff
+		}
+		log.Printf("Backend exited (%v); restarting in %s", p.err, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-s.stop:
+			return
+		}
+		backoff = min(backoff*2, s.cfg.MaxBackoff)
+
+		next, err := s.spawn()
+		if err != nil {
+			// Retry on the next round, as if the process had exited at once
+			next = &process{started: time.Now(), exited: make(chan struct{}), err: err}
+			close(next.exited)
+		}
+		p = next
+		s.mu.Lock()
+		s.restarts++
+		s.mu.Unlock()
+	}
+}
+
+func (s *Supervisor) spawn() (*process, error) {
+	cmd := exec.Command(s.cfg.Binary, s.cfg.Args...)
+	cmd.Dir = s.cfg.Dir
+	if len(s.cfg.Env) > 0 {
+		cmd.Env = append(os.Environ(), s.cfg.Env...)
+	}
+	cmd.Stdout = s.out
+	cmd.Stderr = s.out
+	// Don't let a backend's own children hold Wait open forever
+	cmd.WaitDelay = time.Second
+	setProcessGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start backend: %w", err)
+	}
+	p := &process{cmd: cmd, started: time.Now(), exited: make(chan struct{})}
+	go func() {
+		p.err = cmd.Wait()
+		s.out.Flush()
+		close(p.exited)
+	}()
+	return p, nil
+}
+
+// waitReady polls ReadyURL until the backend answers, p exits, or the ready
+// timeout or ctx ends.
+func (s *Supervisor) waitReady(ctx context.Context, p *process) error {
+	if s.cfg.ReadyURL == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.ReadyTimeout)
+	defer cancel()
+
+	client := &http.Client{Timeout: time.Second}
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.cfg.ReadyURL, nil)
+		if err != nil {
+			return err
+		}
+		if resp, err := client.Do(req); err == nil {
+			resp.Body.Close()
+			return nil
+		}
+
+		select {
+		case <-p.exited:
+			return fmt.Errorf("backend exited before becoming ready: %v", p.err)
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("backend not ready at %s after %s", s.cfg.ReadyURL, s.cfg.ReadyTimeout)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// terminate interrupts p and kills it if it is still running after
+// StopTimeout. Platforms without interrupts are killed straight away.
+func (s *Supervisor) terminate(p *process) {
+	// A backend that failed to spawn has nothing to stop
+	if p.cmd == nil {
+		return
+	}
+	if err := p.cmd.Process.Signal(os.Interrupt); err != nil {
+		p.cmd.Process.Kill()
+	}
+	select {
+	case <-p.exited:
+		return
+	case <-time.After(s.cfg.StopTimeout):
+	}
+	log.Printf("Backend did not stop within %s; killing it", s.cfg.StopTimeout)
+	p.cmd.Process.Kill()
+	<-p.exited
+}
@@ -0,0 +1,183 @@
+package supervisor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestMain doubles as the fake backend: the supervisor under test runs this
+// test binary with FAKE_BACKEND set.
+func TestMain(m *testing.M) {
+	if mode := os.Getenv("FAKE_BACKEND"); mode != "" {
+		fakeBackend(mode)
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// fakeBackend serves on FAKE_BACKEND_ADDR. In "crash-once" mode it exits
+// with an error shortly after its first start; "exit" fails before serving.
+func fakeBackend(mode string) {
+	fmt.Println("fake backend starting")
+	fmt.Fprint(os.Stderr, "warning without newline")
+	if mode == "exit" {
+		os.Exit(2)
+	}
+	if mode == "crash-once" {
+		marker := os.Getenv("FAKE_BACKEND_MARKER")
+		if _, err := os.Stat(marker); os.IsNotExist(err) {
+			os.WriteFile(marker, nil, 0o644)
+			go func() {
+				time.Sleep(300 * time.Millisecond)
+				os.Exit(1)
+			}()
+		}
+	}
+	http.ListenAndServe(os.Getenv("FAKE_BACKEND_ADDR"), http.NotFoundHandler())
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func newFakeSupervisor(t *testing.T, mode string, out *syncBuffer) (*Supervisor, string) {
+	t.Helper()
+	addr := freeAddr(t)
+	return New(Config{
+		Binary: os.Args[0],
+		Env: []string{
+			"FAKE_BACKEND=" + mode,
+			"FAKE_BACKEND_ADDR=" + addr,
+			"FAKE_BACKEND_MARKER=" + filepath.Join(t.TempDir(), "crashed"),
+		},
+		ReadyURL:     "http://" + addr + "/",
+		ReadyTimeout: 5 * time.Second,
+		MinBackoff:   50 * time.Millisecond,
+		StopTimeout:  2 * time.Second,
+		Output:       out,
+		Prefix:       "[cliproxy] ",
+	}), "http://" + addr + "/"
+}
+
+func reachable(url string) bool {
+	resp, err := http.Get(url)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return true
+}
+
+func TestSupervisor_StartWaitsForReadyAndStopEndsBackend(t *testing.T) {
+	out := &syncBuffer{}
+	s, url := newFakeSupervisor(t, "serve", out)
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if !reachable(url) {
+		t.Fatal("backend not reachable after Start returned")
+	}
+
+	s.Stop()
+	if reachable(url) {
+		t.Error("backend still reachable after Stop")
+	}
+	for _, want := range []string{"[cliproxy] fake backend starting\n", "[cliproxy] warning without newline\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestSupervisor_RestartsCrashedBackend(t *testing.T) {
+	s, url := newFakeSupervisor(t, "crash-once", &syncBuffer{})
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer s.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Restarts() == 0 || !reachable(url) {
+		if time.Now().After(deadline) {
+			t.Fatalf("backend not back after crash (restarts=%d)", s.Restarts())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestSupervisor_StartFailsWhenBackendExitsEarly(t *testing.T) {
+	s, _ := newFakeSupervisor(t, "exit", &syncBuffer{})
+	err := s.Start(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "exited before becoming ready") {
+		t.Fatalf("Start error = %v, want early exit", err)
+	}
+	s.Stop()
+}
+
+func TestSupervisor_StartFailsForMissingBinary(t *testing.T) {
+	s := New(Config{Binary: filepath.Join(t.TempDir(), "missing")})
+	if err := s.Start(context.Background()); err == nil {
+		t.Fatal("Start succeeded for a missing binary")
+	}
+}
+
+func TestSupervisor_StopWhileRespawnFails(t *testing.T) {
+	// Stop may find the stand-in for a failed spawn; it has no process
+	failed := &process{exited: make(chan struct{})}
+	close(failed.exited)
+	New(Config{}).terminate(failed)
+
+	bin := filepath.Join(t.TempDir(), "backend")
+	if err := os.Symlink(os.Args[0], bin); err != nil {
+		t.Skip(err)
+	}
+	s, _ := newFakeSupervisor(t, "crash-once", &syncBuffer{})
+	s.cfg.Binary = bin
+	s.cfg.MaxBackoff = 50 * time.Millisecond
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	// The backend crashes and can no longer be spawned
+	os.Remove(bin)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Restarts() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("restarts = %d, want failed respawns", s.Restarts())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.Stop()
+}
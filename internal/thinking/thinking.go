@@ -0,0 +1,129 @@
+// Package thinking holds the named thinking tiers ("low", "high", ...)
+// shared by model-sync's client configs and ThinkingProxy's
+// -thinking-<tier> model suffix, and scales them to a model's budget range.
+package thinking
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+const (
+	// MinBudget is the least budget a tier resolves to, Anthropic's minimum.
+	MinBudget = 1024
+	// MaxBudget caps the budgets ThinkingProxy sets from a model suffix.
+	MaxBudget = 32768
+)
+
+// Tier is a named thinking level. Level places it between a model's
+// smallest (0) and largest (1) budget on a log scale; a Level of 0 turns
+// thinking off, and applies only to models that allow it.
+type Tier struct {
+	Name  string  `json:"name"`
+	Level float64 `json:"level"`
+}
+
+// DefaultTiers are used unless config/vibeproxy.json sets thinking_tiers.
+var DefaultTiers = []Tier{
+	{Name: "low", Level: 0.3},
+	{Name: "medium", Level: 0.5},
+	{Name: "high", Level: 0.75},
+	{Name: "max", Level: 1},
+}
+
+// Range is a model's thinking budget range. A zero Max means unknown and
+// is treated as MaxBudget.
+type Range struct {
+	Min, Max    int
+	ZeroAllowed bool
+}
+
+// Budget is a tier resolved against a Range. A zero Tokens turns thinking
+// off.
+type Budget struct {
+	Tier   string
+	Tokens int
+}
+
+// Validate checks that tiers have distinct, non-numeric names and levels
+// between 0 and 1.
+func Validate(tiers []Tier) error {
+	seen := make(map[string]bool)
+	for _, t := range tiers {
+		if t.Name == "" {
+			return fmt.Errorf("thinking tier without a name")
+		}
+		if _, err := strconv.Atoi(t.Name); err == nil {
+			return fmt.Errorf("thinking tier %q: numeric names are budgets", t.Name)
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("thinking tier %q: defined twice", t.Name)
+		}
+		if t.Level < 0 || t.Level > 1 {
+			return fmt.Errorf("thinking tier %q: level %v is outside 0..1", t.Name, t.Level)
+		}
+		seen[t.Name] = true
+	}
+	return nil
+}
+
+// Find returns the tier called name.
+func Find(tiers []Tier, name string) (Tier, bool) {
+	for _, t := range tiers {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Tier{}, false
+}
+
+// Resolve returns the tier's budget in r, rounded to a multiple of 1024.
+// ok is false for an off tier on a model that cannot turn thinking off.
+func (t Tier) Resolve(r Range) (tokens int, ok bool) {
+	if t.Level == 0 {
+		return 0, r.ZeroAllowed
+	}
+	lo, hi := r.bounds()
+	tokens = int(float64(lo) * math.Pow(float64(hi)/float64(lo), t.Level))
+	tokens = int(math.Round(float64(tokens)/1024)) * 1024
+	if tokens < lo {
+		tokens = lo
+	}
+	if tokens > hi {
+		tokens = hi
+	}
+	return tokens, true
+}
+
+func (r Range) bounds() (lo, hi int) {
+	hi = r.Max
+	if hi <= 0 {
+		hi = MaxBudget
+	}
+	lo = r.Min
+	if lo < MinBudget {
+		lo = MinBudget
+	}
+	if lo > hi {
+		lo = hi
+	}
+	return lo, hi
+}
+
+// Budgets resolves tiers against r in order, dropping tiers that don't
+// apply and those whose budget an earlier tier already has, as in a range
+// too narrow to tell them apart.
+func Budgets(tiers []Tier, r Range) []Budget {
+	var budgets []Budget
+	seen := make(map[int]bool)
+	for _, t := range tiers {
+		tokens, ok := t.Resolve(r)
+		if !ok || seen[tokens] {
+			continue
+		}
+		seen[tokens] = true
+		budgets = append(budgets, Budget{Tier: t.Name, Tokens: tokens})
+	}
+	return budgets
+}
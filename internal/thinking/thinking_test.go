@@ -0,0 +1,49 @@
+package thinking
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBudgets(t *testing.T) {
+	off := append([]Tier{{Name: "off"}}, DefaultTiers...)
+	tests := []struct {
+		name  string
+		tiers []Tier
+		r     Range
+		want  []Budget
+	}{
+		{"claude", DefaultTiers, Range{Min: 1024, Max: 128000}, []Budget{{"low", 4096}, {"medium", 11264}, {"high", 37888}, {"max", 128000}}},
+		{"unknown range", DefaultTiers, Range{}, []Budget{{"low", 3072}, {"medium", 6144}, {"high", 13312}, {"max", 32768}}},
+		{"small min raised", DefaultTiers, Range{Min: 128, Max: 32768}, []Budget{{"low", 3072}, {"medium", 6144}, {"high", 13312}, {"max", 32768}}},
+		{"high min kept", DefaultTiers, Range{Min: 8192, Max: 16384}, []Budget{{"low", 10240}, {"medium", 11264}, {"high", 13312}, {"max", 16384}}},
+		{"narrow range", DefaultTiers, Range{Min: 1024, Max: 1024}, []Budget{{"low", 1024}}},
+		{"off allowed", off, Range{Max: 24576, ZeroAllowed: true}, []Budget{{"off", 0}, {"low", 3072}, {"medium", 5120}, {"high", 11264}, {"max", 24576}}},
+		{"off not allowed", off, Range{Min: 128, Max: 32768}, []Budget{{"low", 3072}, {"medium", 6144}, {"high", 13312}, {"max", 32768}}},
+	}
+	for _, tt := range tests {
+		if got := Budgets(tt.tiers, tt.r); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Budgets = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		tiers   []Tier
+		wantErr string
+	}{
+		{DefaultTiers, ""},
+		{[]Tier{{Name: "", Level: 0.5}}, "without a name"},
+		{[]Tier{{Name: "4000", Level: 0.5}}, "numeric"},
+		{[]Tier{{Name: "low", Level: 0.2}, {Name: "low", Level: 0.3}}, "twice"},
+		{[]Tier{{Name: "huge", Level: 1.5}}, "outside"},
+	}
+	for _, tt := range tests {
+		err := Validate(tt.tiers)
+		if (tt.wantErr == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("Validate(%v) = %v, want %q", tt.tiers, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package translate
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// sseEvent is one parsed server-sent event.
+type sseEvent struct {
+	name string
+	data string
+}
+
+// decoder turns a backend's SSE events into protocol-neutral events.
+type decoder interface {
+	decode(ev sseEvent) []Event
+	// finish closes a completed response whose stop event is still pending.
+	finish() []Event
+	// complete reports whether the backend signalled the end of the
+	// response, so a stream that ends without it was cut off.
+	complete() bool
+}
+
+func newDecoder(p Protocol) decoder {
+	switch p {
+	case Anthropic:
+		return &anthropicDecoder{tools: make(map[int]int)}
+	case Responses:
+		return &responsesDecoder{tools: make(map[int]int)}
+	default:
+		return &chatDecoder{}
+	}
+}
+
+// decodeBody turns a complete, non-streaming response body into events.
+func decodeBody(p Protocol, body []byte) ([]Event, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+	switch p {
+	case Anthropic:
+		return decodeAnthropicBody(data), nil
+	case Chat:
+		return decodeChatBody(data), nil
+	case Responses:
+		return decodeResponsesBody(data), nil
+	default:
+		return nil, fmt.Errorf("unsupported protocol %s", p)
+	}
+}
+
+func object(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func str(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// --- Anthropic ---
+
+func anthropicStopReason(reason string) string {
+	switch reason {
+	case "max_tokens":
+		return StopLength
+	case "tool_use":
+		return StopToolCall
+	default:
+		return StopEnd
+	}
+}
+
+type anthropicDecoder struct {
+	started    bool
+	stopped    bool
+	tools      map[int]int
+	nextTool   int
+	stopReason string
+	usage      Usage
+}
+
+func (d *anthropicDecoder) decode(ev sseEvent) []Event {
+	var data map[string]interface{}
+	if json.Unmarshal([]byte(ev.data), &data) != nil {
+		return nil
+	}
+
+	switch data["type"] {
+	case "message_start":
+		msg := object(data["message"])
+		d.started = true
+		d.usage.InputTokens = intValue(object(msg["usage"])["input_tokens"])
+		return []Event{{Type: EventStart, ID: str(msg["id"]), Model: str(msg["model"]), Usage: d.usage}}
+
+	case "content_block_start":
+		cb := object(data["content_block"])
+		switch cb["type"] {
+		case "tool_use":
+			tool := d.nextTool
+			d.nextTool++
+			d.tools[intValue(data["index"])] = tool
+			return []Event{{Type: EventToolStart, Tool: tool, ToolID: str(cb["id"]), ToolName: str(cb["name"])}}
+		case "text":
+			if text := str(cb["text"]); text != "" {
+				return []Event{{Type: EventText, Text: text}}
+			}
+		case "thinking":
+			if text := str(cb["thinking"]); text != "" {
+				return []Event{{Type: EventThinking, Text: text}}
+			}
+		}
+
+	case "content_block_delta":
+		delta := object(data["delta"])
+		switch delta["type"] {
+		case "text_delta":
+			return []Event{{Type: EventText, Text: str(delta["text"])}}
+		case "thinking_delta":
+			return []Event{{Type: EventThinking, Text: str(delta["thinking"])}}
+		case "signature_delta":
+			return []Event{{Type: EventThinking, Signature: str(delta["signature"])}}
+		case "input_json_delta":
+			tool, ok := d.tools[intValue(data["index"])]
+			if !ok {
+				return nil
+			}
+			return []Event{{Type: EventToolArgs, Tool: tool, Text: str(delta["partial_json"])}}
+		}
+
+	case "message_delta":
+		d.stopReason = anthropicStopReason(str(object(data["delta"])["stop_reason"]))
+		usage := object(data["usage"])
+		if n := intValue(usage["output_tokens"]); n > 0 {
+			d.usage.OutputTokens = n
+		}
+		if n := intValue(usage["input_tokens"]); n > 0 {
+			d.usage.InputTokens = n
+		}
+
+	case "message_stop":
+		return d.finish()
+
+	case "error":
+		return []Event{{Type: EventError, Text: str(object(data["error"])["message"])}}
+	}
+	return nil
+}
+
+func (d *anthropicDecoder) complete() bool { return d.stopped }
+
+func (d *anthropicDecoder) finish() []Event {
+	if !d.started || d.stopped {
+		return nil
+	}
+	d.stopped = true
+	return []Event{{Type: EventStop, StopReason: d.stopReason, Usage: d.usage}}
+}
+
+func decodeAnthropicBody(data map[string]interface{}) []Event {
+	if errObj := object(data["error"]); errObj != nil {
+		return []Event{{Type: EventError, Text: str(errObj["message"])}}
+	}
+
+	usage := object(data["usage"])
+	events := []Event{{
+		Type:  EventStart,
+		ID:    str(data["id"]),
+		Model: str(data["model"]),
+		Usage: Usage{InputTokens: intValue(usage["input_tokens"])},
+	}}
+
+	tool := 0
+	content, _ := data["content"].([]interface{})
+	for _, raw := range content {
+		cb := object(raw)
+		switch cb["type"] {
+		case "text":
+			events = append(events, Event{Type: EventText, Text: str(cb["text"])})
+		case "thinking":
+			events = append(events, Event{Type: EventThinking, Text: str(cb["thinking"]), Signature: str(cb["signature"])})
+		case "tool_use":
+			args, _ := json.Marshal(cb["input"])
+			events = append(events,
+				Event{Type: EventToolStart, Tool: tool, ToolID: str(cb["id"]), ToolName: str(cb["name"])},
+				Event{Type: EventToolArgs, Tool: tool, Text: string(args)})
+			tool++
+		}
+	}
+
+	return append(events, Event{
+		Type:       EventStop,
+		StopReason: anthropicStopReason(str(data["stop_reason"])),
+		Usage:      Usage{InputTokens: intValue(usage["input_tokens"]), OutputTokens: intValue(usage["output_tokens"])},
+	})
+}
+
+// --- Chat Completions ---
+
+type chatDecoder struct {
+	started      bool
+	stopped      bool
+	tools        map[int]bool
+	finishReason string
+	usage        Usage
+}
+
+func (d *chatDecoder) decode(ev sseEvent) []Event {
+	if ev.data == "[DONE]" {
+		return d.finish()
+	}
+
+	var data map[string]interface{}
+	if json.Unmarshal([]byte(ev.data), &data) != nil {
+		return nil
+	}
+	if errObj := object(data["error"]); errObj != nil {
+		return []Event{{Type: EventError, Text: str(errObj["message"])}}
+	}
+
+	var events []Event
+	if !d.started {
+		d.started = true
+		d.tools = make(map[int]bool)
+		events = append(events, Event{Type: EventStart, ID: str(data["id"]), Model: str(data["model"])})
+	}
+
+	if usage := object(data["usage"]); usage != nil {
+		d.usage = Usage{InputTokens: intValue(usage["prompt_tokens"]), OutputTokens: intValue(usage["completion_tokens"])}
+	}
+
+	choices, _ := data["choices"].([]interface{})
+	if len(choices) == 0 {
+		return events
+	}
+	choice := object(choices[0])
+	delta := object(choice["delta"])
+
+	for _, key := range []string{"reasoning_content", "reasoning"} {
+		if text := str(delta[key]); text != "" {
+			events = append(events, Event{Type: EventThinking, Text: text})
+		}
+	}
+	if text := str(delta["content"]); text != "" {
+		events = append(events, Event{Type: EventText, Text: text})
+	}
+
+	toolCalls, _ := delta["tool_calls"].([]interface{})
+	for _, raw := range toolCalls {
+		tc := object(raw)
+		idx := intValue(tc["index"])
+		fn := object(tc["function"])
+		if !d.tools[idx] {
+			d.tools[idx] = true
+			events = append(events, Event{Type: EventToolStart, Tool: idx, ToolID: str(tc["id"]), ToolName: str(fn["name"])})
+		}
+		if args := str(fn["arguments"]); args != "" {
+			events = append(events, Event{Type: EventToolArgs, Tool: idx, Text: args})
+		}
+	}
+
+	if reason := str(choice["finish_reason"]); reason != "" {
+		d.finishReason = reason
+	}
+	return events
+}
+
+// complete treats a finish_reason as terminal since some backends omit
+// the closing [DONE].
+func (d *chatDecoder) complete() bool { return d.stopped || d.finishReason != "" }
+
+func (d *chatDecoder) finish() []Event {
+	if !d.started || d.stopped {
+		return nil
+	}
+	d.stopped = true
+	reason := d.finishReason
+	if reason == "function_call" {
+		reason = StopToolCall
+	}
+	return []Event{{Type: EventStop, StopReason: reason, Usage: d.usage}}
+}
+
+func decodeChatBody(data map[string]interface{}) []Event {
+	if errObj := object(data["error"]); errObj != nil {
+		return []Event{{Type: EventError, Text: str(errObj["message"])}}
+	}
+
+	events := []Event{{Type: EventStart, ID: str(data["id"]), Model: str(data["model"])}}
+
+	var reason string
+	if choices, _ := data["choices"].([]interface{}); len(choices) > 0 {
+		choice := object(choices[0])
+		msg := object(choice["message"])
+		reason = str(choice["finish_reason"])
+
+		for _, key := range []string{"reasoning_content", "reasoning"} {
+			if text := str(msg[key]); text != "" {
+				events = append(events, Event{Type: EventThinking, Text: text})
+			}
+		}
+		if text := textFromContent(msg["content"]); text != "" {
+			events = append(events, Event{Type: EventText, Text: text})
+		}
+		toolCalls, _ := msg["tool_calls"].([]interface{})
+		for i, raw := range toolCalls {
+			tc := object(raw)
+			fn := object(tc["function"])
+			events = append(events,
+				Event{Type: EventToolStart, Tool: i, ToolID: str(tc["id"]), ToolName: str(fn["name"])},
+				Event{Type: EventToolArgs, Tool: i, Text: str(fn["arguments"])})
+		}
+	}
+
+	usage := object(data["usage"])
+	return append(events, Event{
+		Type:       EventStop,
+		StopReason: reason,
+		Usage:      Usage{InputTokens: intValue(usage["prompt_tokens"]), OutputTokens: intValue(usage["completion_tokens"])},
+	})
+}
+
+// --- Responses ---
+
+func responsesStopReason(resp map[string]interface{}, sawTool bool) string {
+	if str(resp["status"]) == "incomplete" {
+		return StopLength
+	}
+	if sawTool {
+		return StopToolCall
+	}
+	return StopEnd
+}
+
+func responsesUsage(resp map[string]interface{}) Usage {
+	usage := object(resp["usage"])
+	return Usage{InputTokens: intValue(usage["input_tokens"]), OutputTokens: intValue(usage["output_tokens"])}
+}
+
+type responsesDecoder struct {
+	started  bool
+	stopped  bool
+	tools    map[int]int
+	nextTool int
+}
+
+func (d *responsesDecoder) decode(ev sseEvent) []Event {
+	var data map[string]interface{}
+	if json.Unmarshal([]byte(ev.data), &data) != nil {
+		return nil
+	}
+
+	switch data["type"] {
+	case "response.created":
+		resp := object(data["response"])
+		d.started = true
+		return []Event{{Type: EventStart, ID: str(resp["id"]), Model: str(resp["model"])}}
+
+	case "response.output_item.added":
+		item := object(data["item"])
+		if item["type"] != "function_call" {
+			return nil
+		}
+		tool := d.nextTool
+		d.nextTool++
+		d.tools[intValue(data["output_index"])] = tool
+		return []Event{{Type: EventToolStart, Tool: tool, ToolID: str(item["call_id"]), ToolName: str(item["name"])}}
+
+	case "response.output_text.delta":
+		return []Event{{Type: EventText, Text: str(data["delta"])}}
+
+	case "response.reasoning_summary_text.delta", "response.reasoning_text.delta":
+		return []Event{{Type: EventThinking, Text: str(data["delta"])}}
+
+	case "response.function_call_arguments.delta":
+		tool, ok := d.tools[intValue(data["output_index"])]
+		if !ok {
+			return nil
+		}
+		return []Event{{Type: EventToolArgs, Tool: tool, Text: str(data["delta"])}}
+
+	case "response.completed", "response.incomplete":
+		if d.stopped {
+			return nil
+		}
+		d.stopped = true
+		resp := object(data["response"])
+		return []Event{{Type: EventStop, StopReason: responsesStopReason(resp, d.nextTool > 0), Usage: responsesUsage(resp)}}
+
+	case "response.failed":
+		return []Event{{Type: EventError, Text: str(object(object(data["response"])["error"])["message"])}}
+
+	case "error":
+		return []Event{{Type: EventError, Text: str(data["message"])}}
+	}
+	return nil
+}
+
+func (d *responsesDecoder) complete() bool { return d.stopped }
+
+func (d *responsesDecoder) finish() []Event {
+	if !d.started || d.stopped {
+		return nil
+	}
+	d.stopped = true
+	reason := StopEnd
+	if d.nextTool > 0 {
+		reason = StopToolCall
+	}
+	return []Event{{Type: EventStop, StopReason: reason}}
+}
+
+func decodeResponsesBody(data map[string]interface{}) []Event {
+	if errObj := object(data["error"]); errObj != nil {
+		return []Event{{Type: EventError, Text: str(errObj["message"])}}
+	}
+
+	events := []Event{{Type: EventStart, ID: str(data["id"]), Model: str(data["model"])}}
+
+	tool := 0
+	output, _ := data["output"].([]interface{})
+	for _, raw := range output {
+		item := object(raw)
+		switch item["type"] {
+		case "reasoning":
+			if text := textFromContent(item["summary"]); text != "" {
+				events = append(events, Event{Type: EventThinking, Text: text})
+			}
+		case "message":
+			if text := textFromContent(item["content"]); text != "" {
+				events = append(events, Event{Type: EventText, Text: text})
+			}
+		case "function_call":
+			events = append(events,
+				Event{Type: EventToolStart, Tool: tool, ToolID: str(item["call_id"]), ToolName: str(item["name"])},
+				Event{Type: EventToolArgs, Tool: tool, Text: str(item["arguments"])})
+			tool++
+		}
+	}
+
+	return append(events, Event{Type: EventStop, StopReason: responsesStopReason(data, tool > 0), Usage: responsesUsage(data)})
+}
@@ -0,0 +1,658 @@
+package translate
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Overridden in tests for deterministic output.
+var (
+	nowUnix = func() int64 { return time.Now().Unix() }
+	newID   = func(prefix string) string {
+		buf := make([]byte, 12)
+		rand.Read(buf)
+		return prefix + hex.EncodeToString(buf)
+	}
+)
+
+// encoder turns protocol-neutral events into SSE bytes for a client.
+type encoder interface {
+	encode(ev Event) []byte
+}
+
+func newEncoder(p Protocol) encoder {
+	switch p {
+	case Anthropic:
+		return &anthropicEncoder{}
+	case Responses:
+		return &responsesEncoder{}
+	default:
+		return &chatEncoder{}
+	}
+}
+
+func writeSSE(buf *bytes.Buffer, name string, data interface{}) {
+	payload, _ := json.Marshal(data)
+	if name != "" {
+		fmt.Fprintf(buf, "event: %s\n", name)
+	}
+	fmt.Fprintf(buf, "data: %s\n\n", payload)
+}
+
+// --- Anthropic ---
+
+func toAnthropicStopReason(reason string) string {
+	switch reason {
+	case StopLength:
+		return "max_tokens"
+	case StopToolCall:
+		return "tool_use"
+	default:
+		return "end_turn"
+	}
+}
+
+type anthropicEncoder struct {
+	started   bool
+	index     int
+	open      bool
+	openKind  EventType
+	openTool  int
+	usage     Usage
+	messageID string
+}
+
+func (e *anthropicEncoder) encode(ev Event) []byte {
+	var buf bytes.Buffer
+	if !e.started && ev.Type != EventError {
+		e.start(&buf, ev)
+		if ev.Type == EventStart {
+			return buf.Bytes()
+		}
+	}
+
+	switch ev.Type {
+	case EventText:
+		e.ensureBlock(&buf, EventText, 0, map[string]interface{}{"type": "text", "text": ""})
+		writeSSE(&buf, "content_block_delta", map[string]interface{}{
+			"type":  "content_block_delta",
+			"index": e.index,
+			"delta": map[string]interface{}{"type": "text_delta", "text": ev.Text},
+		})
+
+	case EventThinking:
+		e.ensureBlock(&buf, EventThinking, 0, map[string]interface{}{"type": "thinking", "thinking": ""})
+		if ev.Text != "" {
+			writeSSE(&buf, "content_block_delta", map[string]interface{}{
+				"type":  "content_block_delta",
+				"index": e.index,
+				"delta": map[string]interface{}{"type": "thinking_delta", "thinking": ev.Text},
+			})
+		}
+		if ev.Signature != "" {
+			writeSSE(&buf, "content_block_delta", map[string]interface{}{
+				"type":  "content_block_delta",
+				"index": e.index,
+				"delta": map[string]interface{}{"type": "signature_delta", "signature": ev.Signature},
+			})
+		}
+
+	case EventToolStart:
+		e.ensureBlock(&buf, EventToolStart, ev.Tool, map[string]interface{}{
+			"type":  "tool_use",
+			"id":    ev.ToolID,
+			"name":  ev.ToolName,
+			"input": map[string]interface{}{},
+		})
+
+	case EventToolArgs:
+		// Anthropic blocks cannot be reopened, so arguments for a tool call
+		// that is no longer the open block are dropped.
+		if e.open && e.openKind == EventToolStart && e.openTool == ev.Tool {
+			writeSSE(&buf, "content_block_delta", map[string]interface{}{
+				"type":  "content_block_delta",
+				"index": e.index,
+				"delta": map[string]interface{}{"type": "input_json_delta", "partial_json": ev.Text},
+			})
+		}
+
+	case EventStop:
+		e.closeBlock(&buf)
+		if ev.Usage.OutputTokens > 0 {
+			e.usage.OutputTokens = ev.Usage.OutputTokens
+		}
+		usage := map[string]interface{}{"output_tokens": e.usage.OutputTokens}
+		// Backends that only report usage at the end still need input
+		// tokens accounted for; message_start went out with zero.
+		if ev.Usage.InputTokens > 0 && ev.Usage.InputTokens != e.usage.InputTokens {
+			usage["input_tokens"] = ev.Usage.InputTokens
+		}
+		writeSSE(&buf, "message_delta", map[string]interface{}{
+			"type":  "message_delta",
+			"delta": map[string]interface{}{"stop_reason": toAnthropicStopReason(ev.StopReason), "stop_sequence": nil},
+			"usage": usage,
+		})
+		writeSSE(&buf, "message_stop", map[string]interface{}{"type": "message_stop"})
+
+	case EventError:
+		writeSSE(&buf, "error", anthropicError(ev.Text))
+	}
+	return buf.Bytes()
+}
+
+func (e *anthropicEncoder) start(buf *bytes.Buffer, ev Event) {
+	e.started = true
+	e.index = -1
+	e.messageID = ev.ID
+	if e.messageID == "" {
+		e.messageID = newID("msg_")
+	}
+	e.usage.InputTokens = ev.Usage.InputTokens
+	writeSSE(buf, "message_start", map[string]interface{}{
+		"type": "message_start",
+		"message": map[string]interface{}{
+			"id":            e.messageID,
+			"type":          "message",
+			"role":          "assistant",
+			"model":         ev.Model,
+			"content":       []interface{}{},
+			"stop_reason":   nil,
+			"stop_sequence": nil,
+			"usage":         map[string]interface{}{"input_tokens": ev.Usage.InputTokens, "output_tokens": 0},
+		},
+	})
+}
+
+func (e *anthropicEncoder) ensureBlock(buf *bytes.Buffer, kind EventType, tool int, contentBlock map[string]interface{}) {
+	if e.open && e.openKind == kind && (kind != EventToolStart || e.openTool == tool) {
+		return
+	}
+	e.closeBlock(buf)
+	e.index++
+	e.open, e.openKind, e.openTool = true, kind, tool
+	writeSSE(buf, "content_block_start", map[string]interface{}{
+		"type":          "content_block_start",
+		"index":         e.index,
+		"content_block": contentBlock,
+	})
+}
+
+func (e *anthropicEncoder) closeBlock(buf *bytes.Buffer) {
+	if !e.open {
+		return
+	}
+	e.open = false
+	writeSSE(buf, "content_block_stop", map[string]interface{}{"type": "content_block_stop", "index": e.index})
+}
+
+func anthropicError(message string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":  "error",
+		"error": map[string]interface{}{"type": "api_error", "message": message},
+	}
+}
+
+func encodeAnthropicBody(m *message) map[string]interface{} {
+	content := []interface{}{}
+	for _, b := range m.blocks {
+		switch b.kind {
+		case EventText:
+			content = append(content, map[string]interface{}{"type": "text", "text": b.text})
+		case EventThinking:
+			content = append(content, map[string]interface{}{"type": "thinking", "thinking": b.text, "signature": b.signature})
+		case EventToolStart:
+			var input interface{} = map[string]interface{}{}
+			if b.text != "" {
+				var parsed interface{}
+				if json.Unmarshal([]byte(b.text), &parsed) == nil {
+					input = parsed
+				}
+			}
+			content = append(content, map[string]interface{}{"type": "tool_use", "id": b.toolID, "name": b.toolName, "input": input})
+		}
+	}
+
+	id := m.id
+	if id == "" {
+		id = newID("msg_")
+	}
+	return map[string]interface{}{
+		"id":            id,
+		"type":          "message",
+		"role":          "assistant",
+		"model":         m.model,
+		"content":       content,
+		"stop_reason":   toAnthropicStopReason(m.stopReason),
+		"stop_sequence": nil,
+		"usage":         map[string]interface{}{"input_tokens": m.usage.InputTokens, "output_tokens": m.usage.OutputTokens},
+	}
+}
+
+// --- Chat Completions ---
+
+type chatEncoder struct {
+	started bool
+	id      string
+	model   string
+	created int64
+	tools   map[int]int
+	usage   Usage
+}
+
+func (e *chatEncoder) chunk(buf *bytes.Buffer, delta map[string]interface{}, finishReason interface{}) {
+	writeSSE(buf, "", map[string]interface{}{
+		"id":      e.id,
+		"object":  "chat.completion.chunk",
+		"created": e.created,
+		"model":   e.model,
+		"choices": []interface{}{map[string]interface{}{
+			"index":         0,
+			"delta":         delta,
+			"finish_reason": finishReason,
+		}},
+	})
+}
+
+func (e *chatEncoder) encode(ev Event) []byte {
+	var buf bytes.Buffer
+	if !e.started && ev.Type != EventError {
+		e.started = true
+		e.id = ev.ID
+		if e.id == "" {
+			e.id = newID("chatcmpl-")
+		}
+		e.model = ev.Model
+		e.created = nowUnix()
+		e.tools = make(map[int]int)
+		e.usage.InputTokens = ev.Usage.InputTokens
+		e.chunk(&buf, map[string]interface{}{"role": "assistant", "content": ""}, nil)
+		if ev.Type == EventStart {
+			return buf.Bytes()
+		}
+	}
+
+	switch ev.Type {
+	case EventText:
+		e.chunk(&buf, map[string]interface{}{"content": ev.Text}, nil)
+
+	case EventThinking:
+		if ev.Text != "" {
+			e.chunk(&buf, map[string]interface{}{"reasoning_content": ev.Text}, nil)
+		}
+
+	case EventToolStart:
+		idx := len(e.tools)
+		e.tools[ev.Tool] = idx
+		e.chunk(&buf, map[string]interface{}{"tool_calls": []interface{}{map[string]interface{}{
+			"index":    idx,
+			"id":       ev.ToolID,
+			"type":     "function",
+			"function": map[string]interface{}{"name": ev.ToolName, "arguments": ""},
+		}}}, nil)
+
+	case EventToolArgs:
+		idx, ok := e.tools[ev.Tool]
+		if !ok {
+			break
+		}
+		e.chunk(&buf, map[string]interface{}{"tool_calls": []interface{}{map[string]interface{}{
+			"index":    idx,
+			"function": map[string]interface{}{"arguments": ev.Text},
+		}}}, nil)
+
+	case EventStop:
+		reason := ev.StopReason
+		if reason == "" {
+			reason = StopEnd
+		}
+		if ev.Usage.InputTokens > 0 {
+			e.usage.InputTokens = ev.Usage.InputTokens
+		}
+		e.usage.OutputTokens = ev.Usage.OutputTokens
+		e.chunk(&buf, map[string]interface{}{}, reason)
+		writeSSE(&buf, "", map[string]interface{}{
+			"id":      e.id,
+			"object":  "chat.completion.chunk",
+			"created": e.created,
+			"model":   e.model,
+			"choices": []interface{}{},
+			"usage":   chatUsage(e.usage),
+		})
+		buf.WriteString("data: [DONE]\n\n")
+
+	case EventError:
+		writeSSE(&buf, "", openAIError(ev.Text))
+	}
+	return buf.Bytes()
+}
+
+func chatUsage(u Usage) map[string]interface{} {
+	return map[string]interface{}{
+		"prompt_tokens":     u.InputTokens,
+		"completion_tokens": u.OutputTokens,
+		"total_tokens":      u.InputTokens + u.OutputTokens,
+	}
+}
+
+func openAIError(message string) map[string]interface{} {
+	return map[string]interface{}{
+		"error": map[string]interface{}{"message": message, "type": "api_error"},
+	}
+}
+
+func encodeChatBody(m *message) map[string]interface{} {
+	msg := map[string]interface{}{"role": "assistant"}
+	var text, reasoning strings.Builder
+	var toolCalls []interface{}
+	for _, b := range m.blocks {
+		switch b.kind {
+		case EventText:
+			text.WriteString(b.text)
+		case EventThinking:
+			reasoning.WriteString(b.text)
+		case EventToolStart:
+			toolCalls = append(toolCalls, map[string]interface{}{
+				"id":       b.toolID,
+				"type":     "function",
+				"function": map[string]interface{}{"name": b.toolName, "arguments": b.text},
+			})
+		}
+	}
+	msg["content"] = text.String()
+	if reasoning.Len() > 0 {
+		msg["reasoning_content"] = reasoning.String()
+	}
+	if len(toolCalls) > 0 {
+		msg["tool_calls"] = toolCalls
+	}
+
+	id := m.id
+	if id == "" {
+		id = newID("chatcmpl-")
+	}
+	return map[string]interface{}{
+		"id":      id,
+		"object":  "chat.completion",
+		"created": nowUnix(),
+		"model":   m.model,
+		"choices": []interface{}{map[string]interface{}{
+			"index":         0,
+			"message":       msg,
+			"finish_reason": m.stopReason,
+		}},
+		"usage": chatUsage(m.usage),
+	}
+}
+
+// --- Responses ---
+
+type responsesItem struct {
+	kind        EventType
+	id          string
+	outputIndex int
+	text        strings.Builder
+	tool        int
+	callID      string
+	name        string
+}
+
+type responsesEncoder struct {
+	started bool
+	id      string
+	model   string
+	created int64
+	seq     int
+	open    *responsesItem
+	output  []interface{}
+	tools   map[int]*responsesItem
+}
+
+func (e *responsesEncoder) emit(buf *bytes.Buffer, data map[string]interface{}) {
+	data["sequence_number"] = e.seq
+	e.seq++
+	writeSSE(buf, data["type"].(string), data)
+}
+
+func (e *responsesEncoder) response(status string) map[string]interface{} {
+	output := e.output
+	if output == nil {
+		output = []interface{}{}
+	}
+	return map[string]interface{}{
+		"id":         e.id,
+		"object":     "response",
+		"created_at": e.created,
+		"status":     status,
+		"model":      e.model,
+		"output":     output,
+	}
+}
+
+func (e *responsesEncoder) encode(ev Event) []byte {
+	var buf bytes.Buffer
+	if !e.started && ev.Type != EventError {
+		e.started = true
+		e.id = ev.ID
+		if e.id == "" {
+			e.id = newID("resp_")
+		}
+		e.model = ev.Model
+		e.created = nowUnix()
+		e.tools = make(map[int]*responsesItem)
+		e.emit(&buf, map[string]interface{}{"type": "response.created", "response": e.response("in_progress")})
+		e.emit(&buf, map[string]interface{}{"type": "response.in_progress", "response": e.response("in_progress")})
+		if ev.Type == EventStart {
+			return buf.Bytes()
+		}
+	}
+
+	switch ev.Type {
+	case EventText:
+		item := e.ensureItem(&buf, EventText, 0)
+		item.text.WriteString(ev.Text)
+		e.emit(&buf, map[string]interface{}{
+			"type":          "response.output_text.delta",
+			"item_id":       item.id,
+			"output_index":  item.outputIndex,
+			"content_index": 0,
+			"delta":         ev.Text,
+		})
+
+	case EventThinking:
+		if ev.Text == "" {
+			break
+		}
+		item := e.ensureItem(&buf, EventThinking, 0)
+		item.text.WriteString(ev.Text)
+		e.emit(&buf, map[string]interface{}{
+			"type":          "response.reasoning_summary_text.delta",
+			"item_id":       item.id,
+			"output_index":  item.outputIndex,
+			"summary_index": 0,
+			"delta":         ev.Text,
+		})
+
+	case EventToolStart:
+		item := e.ensureItem(&buf, EventToolStart, ev.Tool)
+		item.callID, item.name = ev.ToolID, ev.ToolName
+		e.tools[ev.Tool] = item
+		e.emit(&buf, map[string]interface{}{
+			"type":         "response.output_item.added",
+			"output_index": item.outputIndex,
+			"item":         e.itemBody(item, "in_progress"),
+		})
+
+	case EventToolArgs:
+		item := e.tools[ev.Tool]
+		if item == nil || item != e.open {
+			break
+		}
+		item.text.WriteString(ev.Text)
+		e.emit(&buf, map[string]interface{}{
+			"type":         "response.function_call_arguments.delta",
+			"item_id":      item.id,
+			"output_index": item.outputIndex,
+			"delta":        ev.Text,
+		})
+
+	case EventStop:
+		e.closeItem(&buf)
+		eventType, resp := "response.completed", e.response("completed")
+		if ev.StopReason == StopLength {
+			eventType, resp = "response.incomplete", e.response("incomplete")
+			resp["incomplete_details"] = map[string]interface{}{"reason": "max_output_tokens"}
+		}
+		resp["usage"] = responsesUsageBody(ev.Usage)
+		e.emit(&buf, map[string]interface{}{"type": eventType, "response": resp})
+
+	case EventError:
+		e.emit(&buf, map[string]interface{}{"type": "error", "code": "api_error", "message": ev.Text, "param": nil})
+	}
+	return buf.Bytes()
+}
+
+// ensureItem opens a new output item of kind unless one is already open.
+// Function call items are announced by the caller once call details are set.
+func (e *responsesEncoder) ensureItem(buf *bytes.Buffer, kind EventType, tool int) *responsesItem {
+	if e.open != nil && e.open.kind == kind && kind != EventToolStart {
+		return e.open
+	}
+	e.closeItem(buf)
+
+	item := &responsesItem{kind: kind, outputIndex: len(e.output), tool: tool}
+	switch kind {
+	case EventText:
+		item.id = newID("msg_")
+	case EventThinking:
+		item.id = newID("rs_")
+	case EventToolStart:
+		item.id = newID("fc_")
+	}
+	e.open = item
+	// Reserve the output slot so indices stay stable.
+	e.output = append(e.output, nil)
+
+	switch kind {
+	case EventText:
+		e.emit(buf, map[string]interface{}{"type": "response.output_item.added", "output_index": item.outputIndex, "item": e.itemBody(item, "in_progress")})
+		e.emit(buf, map[string]interface{}{
+			"type":          "response.content_part.added",
+			"item_id":       item.id,
+			"output_index":  item.outputIndex,
+			"content_index": 0,
+			"part":          map[string]interface{}{"type": "output_text", "text": "", "annotations": []interface{}{}},
+		})
+	case EventThinking:
+		e.emit(buf, map[string]interface{}{"type": "response.output_item.added", "output_index": item.outputIndex, "item": e.itemBody(item, "in_progress")})
+		e.emit(buf, map[string]interface{}{
+			"type":          "response.reasoning_summary_part.added",
+			"item_id":       item.id,
+			"output_index":  item.outputIndex,
+			"summary_index": 0,
+			"part":          map[string]interface{}{"type": "summary_text", "text": ""},
+		})
+	}
+	return item
+}
+
+func (e *responsesEncoder) closeItem(buf *bytes.Buffer) {
+	item := e.open
+	if item == nil {
+		return
+	}
+	e.open = nil
+	text := item.text.String()
+
+	switch item.kind {
+	case EventText:
+		e.emit(buf, map[string]interface{}{"type": "response.output_text.done", "item_id": item.id, "output_index": item.outputIndex, "content_index": 0, "text": text})
+		e.emit(buf, map[string]interface{}{
+			"type":          "response.content_part.done",
+			"item_id":       item.id,
+			"output_index":  item.outputIndex,
+			"content_index": 0,
+			"part":          map[string]interface{}{"type": "output_text", "text": text, "annotations": []interface{}{}},
+		})
+	case EventThinking:
+		e.emit(buf, map[string]interface{}{"type": "response.reasoning_summary_text.done", "item_id": item.id, "output_index": item.outputIndex, "summary_index": 0, "text": text})
+		e.emit(buf, map[string]interface{}{
+			"type":          "response.reasoning_summary_part.done",
+			"item_id":       item.id,
+			"output_index":  item.outputIndex,
+			"summary_index": 0,
+			"part":          map[string]interface{}{"type": "summary_text", "text": text},
+		})
+	case EventToolStart:
+		e.emit(buf, map[string]interface{}{"type": "response.function_call_arguments.done", "item_id": item.id, "output_index": item.outputIndex, "arguments": text})
+	}
+
+	body := e.itemBody(item, "completed")
+	e.output[item.outputIndex] = body
+	e.emit(buf, map[string]interface{}{"type": "response.output_item.done", "output_index": item.outputIndex, "item": body})
+}
+
+func (e *responsesEncoder) itemBody(item *responsesItem, status string) map[string]interface{} {
+	text := item.text.String()
+	switch item.kind {
+	case EventThinking:
+		summary := []interface{}{}
+		if status == "completed" {
+			summary = append(summary, map[string]interface{}{"type": "summary_text", "text": text})
+		}
+		return map[string]interface{}{"id": item.id, "type": "reasoning", "summary": summary}
+	case EventToolStart:
+		return map[string]interface{}{
+			"id":        item.id,
+			"type":      "function_call",
+			"status":    status,
+			"call_id":   item.callID,
+			"name":      item.name,
+			"arguments": text,
+		}
+	default:
+		content := []interface{}{}
+		if status == "completed" {
+			content = append(content, map[string]interface{}{"type": "output_text", "text": text, "annotations": []interface{}{}})
+		}
+		return map[string]interface{}{"id": item.id, "type": "message", "status": status, "role": "assistant", "content": content}
+	}
+}
+
+func responsesUsageBody(u Usage) map[string]interface{} {
+	return map[string]interface{}{
+		"input_tokens":  u.InputTokens,
+		"output_tokens": u.OutputTokens,
+		"total_tokens":  u.InputTokens + u.OutputTokens,
+	}
+}
+
+func encodeResponsesBody(m *message) map[string]interface{} {
+	// Replaying collected blocks through the stream encoder keeps the item
+	// shapes identical between streaming and non-streaming responses.
+	e := &responsesEncoder{}
+	e.encode(Event{Type: EventStart, ID: m.id, Model: m.model})
+	for _, b := range m.blocks {
+		switch b.kind {
+		case EventText, EventThinking:
+			e.encode(Event{Type: b.kind, Text: b.text})
+		case EventToolStart:
+			e.encode(Event{Type: EventToolStart, Tool: b.tool, ToolID: b.toolID, ToolName: b.toolName})
+			e.encode(Event{Type: EventToolArgs, Tool: b.tool, Text: b.text})
+		}
+	}
+	var discard bytes.Buffer
+	e.closeItem(&discard)
+
+	resp := e.response("completed")
+	if m.stopReason == StopLength {
+		resp["status"] = "incomplete"
+		resp["incomplete_details"] = map[string]interface{}{"reason": "max_output_tokens"}
+	}
+	resp["usage"] = responsesUsageBody(m.usage)
+	return resp
+}
@@ -0,0 +1,119 @@
+package translate
+
+// EventType identifies a protocol-neutral response event.
+type EventType int
+
+const (
+	// EventStart opens a response. ID, Model and Usage.InputTokens may be set.
+	EventStart EventType = iota
+	// EventText carries a visible text delta.
+	EventText
+	// EventThinking carries a reasoning/thinking delta. Signature is set
+	// when the backend signs the thinking block (Anthropic).
+	EventThinking
+	// EventToolStart opens tool call Tool with ToolID and ToolName.
+	EventToolStart
+	// EventToolArgs carries a JSON arguments delta for tool call Tool.
+	EventToolArgs
+	// EventStop ends the response with StopReason and Usage.
+	EventStop
+	// EventError reports an upstream error with message Text.
+	EventError
+)
+
+// Stop reasons use Chat Completions vocabulary.
+const (
+	StopEnd      = "stop"
+	StopLength   = "length"
+	StopToolCall = "tool_calls"
+)
+
+// Event is one protocol-neutral response event.
+type Event struct {
+	Type       EventType
+	ID         string
+	Model      string
+	Text       string
+	Signature  string
+	Tool       int
+	ToolID     string
+	ToolName   string
+	StopReason string
+	Usage      Usage
+}
+
+// Usage is token accounting reported by the backend.
+type Usage struct {
+	InputTokens  int
+	OutputTokens int
+}
+
+// block is one accumulated piece of output in a collected message.
+type block struct {
+	kind      EventType // EventText, EventThinking or EventToolStart
+	text      string
+	signature string
+	tool      int
+	toolID    string
+	toolName  string
+}
+
+// message accumulates events into a complete, non-streaming response.
+type message struct {
+	id         string
+	model      string
+	blocks     []*block
+	stopReason string
+	usage      Usage
+}
+
+func collect(events []Event) *message {
+	m := &message{stopReason: StopEnd}
+	tools := make(map[int]*block)
+
+	for _, ev := range events {
+		switch ev.Type {
+		case EventStart:
+			m.id, m.model = ev.ID, ev.Model
+			if ev.Usage.InputTokens > 0 {
+				m.usage.InputTokens = ev.Usage.InputTokens
+			}
+		case EventText, EventThinking:
+			last := m.last()
+			if last == nil || last.kind != ev.Type {
+				last = &block{kind: ev.Type}
+				m.blocks = append(m.blocks, last)
+			}
+			last.text += ev.Text
+			if ev.Signature != "" {
+				last.signature = ev.Signature
+			}
+		case EventToolStart:
+			b := &block{kind: EventToolStart, tool: ev.Tool, toolID: ev.ToolID, toolName: ev.ToolName}
+			tools[ev.Tool] = b
+			m.blocks = append(m.blocks, b)
+		case EventToolArgs:
+			if b := tools[ev.Tool]; b != nil {
+				b.text += ev.Text
+			}
+		case EventStop:
+			if ev.StopReason != "" {
+				m.stopReason = ev.StopReason
+			}
+			if ev.Usage.InputTokens > 0 {
+				m.usage.InputTokens = ev.Usage.InputTokens
+			}
+			if ev.Usage.OutputTokens > 0 {
+				m.usage.OutputTokens = ev.Usage.OutputTokens
+			}
+		}
+	}
+	return m
+}
+
+func (m *message) last() *block {
+	if len(m.blocks) == 0 {
+		return nil
+	}
+	return m.blocks[len(m.blocks)-1]
+}
@@ -0,0 +1,58 @@
+// Package translate converts requests and responses between the Anthropic
+// Messages, OpenAI Chat Completions and OpenAI Responses protocols.
+//
+// Requests are converted through Chat Completions as the pivot format.
+// Responses, streaming or not, are decoded into a protocol-neutral Event
+// sequence and re-encoded for the client.
+package translate
+
+// Protocol identifies a client-facing API protocol.
+type Protocol int
+
+const (
+	Unknown Protocol = iota
+	Anthropic
+	Chat
+	Responses
+)
+
+func (p Protocol) String() string {
+	switch p {
+	case Anthropic:
+		return "anthropic"
+	case Chat:
+		return "chat"
+	case Responses:
+		return "responses"
+	default:
+		return "unknown"
+	}
+}
+
+// Path returns the endpoint path for the protocol.
+func (p Protocol) Path() string {
+	switch p {
+	case Anthropic:
+		return "/v1/messages"
+	case Chat:
+		return "/v1/chat/completions"
+	case Responses:
+		return "/v1/responses"
+	default:
+		return ""
+	}
+}
+
+// ProtocolForPath returns the protocol served at path, or Unknown.
+func ProtocolForPath(path string) Protocol {
+	switch path {
+	case "/v1/messages":
+		return Anthropic
+	case "/v1/chat/completions":
+		return Chat
+	case "/v1/responses":
+		return Responses
+	default:
+		return Unknown
+	}
+}
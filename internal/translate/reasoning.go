@@ -0,0 +1,107 @@
+package translate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Filter rewrites protocol-neutral events between decoding and encoding.
+// Filters are stateful and used for a single response.
+type Filter interface {
+	Apply(ev Event) []Event
+}
+
+// ReasoningMode controls how thinking content reaches the client.
+type ReasoningMode string
+
+const (
+	// ReasoningPassthrough forwards thinking in the protocol's native form.
+	ReasoningPassthrough ReasoningMode = "passthrough"
+	// ReasoningStrip drops thinking entirely.
+	ReasoningStrip ReasoningMode = "strip"
+	// ReasoningContent surfaces thinking as Chat Completions
+	// `reasoning_content` deltas, which is how the Chat encoder already
+	// carries it. Other protocols keep their native form.
+	ReasoningContent ReasoningMode = "reasoning_content"
+	// ReasoningThinkTags inlines thinking into the visible text wrapped in
+	// <think></think> tags.
+	ReasoningThinkTags ReasoningMode = "think-tags"
+)
+
+const (
+	thinkOpen  = "<think>\n"
+	thinkClose = "\n</think>\n\n"
+)
+
+// ParseReasoningMode validates a mode name. Empty means passthrough.
+func ParseReasoningMode(s string) (ReasoningMode, error) {
+	switch mode := ReasoningMode(s); mode {
+	case "":
+		return ReasoningPassthrough, nil
+	case ReasoningPassthrough, ReasoningStrip, ReasoningContent, ReasoningThinkTags:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown reasoning mode %q (want passthrough, strip, reasoning_content or think-tags)", s)
+	}
+}
+
+// NewReasoningFilter returns a filter applying mode, or nil when the mode
+// leaves events unchanged so native responses need no rewrite.
+func NewReasoningFilter(mode ReasoningMode) Filter {
+	if !mode.HidesThinking() {
+		return nil
+	}
+	return &reasoningFilter{mode: mode}
+}
+
+// HidesThinking reports whether mode removes thinking blocks from the
+// response, so the client cannot send signed blocks back.
+func (m ReasoningMode) HidesThinking() bool {
+	return m == ReasoningStrip || m == ReasoningThinkTags
+}
+
+type reasoningFilter struct {
+	mode    ReasoningMode
+	inThink bool
+}
+
+func (f *reasoningFilter) Apply(ev Event) []Event {
+	if f.mode == ReasoningStrip {
+		if ev.Type == EventThinking {
+			return nil
+		}
+		return []Event{ev}
+	}
+
+	if ev.Type == EventThinking {
+		if ev.Text == "" {
+			return nil
+		}
+		text := ev.Text
+		if !f.inThink {
+			f.inThink = true
+			text = thinkOpen + strings.TrimLeft(text, "\n")
+		}
+		return []Event{{Type: EventText, Text: text}}
+	}
+
+	if f.inThink && ev.Type != EventStart && ev.Type != EventError {
+		f.inThink = false
+		return []Event{{Type: EventText, Text: thinkClose}, ev}
+	}
+	return []Event{ev}
+}
+
+func applyFilters(events []Event, filters []Filter) []Event {
+	for _, f := range filters {
+		if f == nil {
+			continue
+		}
+		var out []Event
+		for _, ev := range events {
+			out = append(out, f.Apply(ev)...)
+		}
+		events = out
+	}
+	return events
+}
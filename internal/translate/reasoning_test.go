@@ -0,0 +1,135 @@
+package translate
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseReasoningMode(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ReasoningMode
+		wantErr bool
+	}{
+		{"", ReasoningPassthrough, false},
+		{"strip", ReasoningStrip, false},
+		{"reasoning_content", ReasoningContent, false},
+		{"think-tags", ReasoningThinkTags, false},
+		{"hide", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseReasoningMode(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseReasoningMode(%q) = %q, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestReasoningModesOnStreams(t *testing.T) {
+	for _, mode := range []ReasoningMode{ReasoningStrip, ReasoningThinkTags} {
+		for _, from := range protocols {
+			for _, to := range protocols {
+				t.Run(string(mode)+"/"+from.String()+"_to_"+to.String(), func(t *testing.T) {
+					deterministicIDs(t)
+					input := readFixture(t, from.String()+"_stream.sse")
+					r := NewStreamReader(from, to, io.NopCloser(bytes.NewReader(input)), NewReasoningFilter(mode))
+					out, err := io.ReadAll(r)
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+
+					want := summarize(decodeStream(t, from, input))
+					wantText := want.Text
+					if mode == ReasoningThinkTags && want.Thinking != "" {
+						wantText = thinkOpen + want.Thinking + thinkClose + want.Text
+					}
+					want.Text, want.Thinking = wantText, ""
+
+					if got := summarize(decodeStream(t, to, out)); !reflect.DeepEqual(got, want) {
+						t.Errorf("got %+v\nwant %+v", got, want)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestNewReasoningFilterSkipsNoOpModes(t *testing.T) {
+	for _, mode := range []ReasoningMode{"", ReasoningPassthrough, ReasoningContent} {
+		if f := NewReasoningFilter(mode); f != nil {
+			t.Errorf("NewReasoningFilter(%q) = %T, want nil", mode, f)
+		}
+	}
+}
+
+func TestReasoningModeOnResponseBody(t *testing.T) {
+	out, err := Response(Anthropic, Anthropic, readFixture(t, "anthropic_response.json"), NewReasoningFilter(ReasoningThinkTags))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	events, err := decodeBody(Anthropic, out)
+	if err != nil {
+		t.Fatalf("output does not decode: %v", err)
+	}
+	s := summarize(events)
+	if s.Thinking != "" || !strings.HasPrefix(s.Text, "<think>\n") || !strings.Contains(s.Text, "\n</think>\n\n") {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+}
+
+func TestReasoningFilterIsIncremental(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	r := bufio.NewReader(NewStreamReader(Anthropic, Chat, pr, NewReasoningFilter(ReasoningThinkTags)))
+
+	go io.WriteString(pw, "event: message_start\n"+
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":1}}}`+"\n\n"+
+		"event: content_block_start\n"+
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`+"\n\n"+
+		"event: content_block_delta\n"+
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"hmm"}}`+"\n\n")
+
+	// The thinking delta must arrive while the backend stream is still open.
+	found := make(chan error, 1)
+	go func() {
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				found <- err
+				return
+			}
+			data, ok := strings.CutPrefix(strings.TrimSpace(line), "data: ")
+			if !ok {
+				continue
+			}
+			var chunk struct {
+				Choices []struct {
+					Delta struct {
+						Content string `json:"content"`
+					} `json:"delta"`
+				} `json:"choices"`
+			}
+			if json.Unmarshal([]byte(data), &chunk) == nil && len(chunk.Choices) > 0 &&
+				chunk.Choices[0].Delta.Content == thinkOpen+"hmm" {
+				found <- nil
+				return
+			}
+		}
+	}()
+
+	select {
+	case err := <-found:
+		if err != nil {
+			t.Fatalf("stream ended before the thinking delta: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		pw.CloseWithError(io.ErrUnexpectedEOF)
+		t.Fatal("thinking delta was buffered instead of streamed")
+	}
+}
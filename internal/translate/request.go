@@ -0,0 +1,1101 @@
+package translate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/theadriann/vibeproxyplus/internal/thinking"
+)
+
+// defaultMaxTokens is used when converting to Anthropic, which requires
+// max_tokens, and the source request does not set one.
+const defaultMaxTokens = 8192
+
+// Pivot-only fields carried on Chat Completions messages so thinking blocks
+// survive an Anthropic -> Chat -> Anthropic round trip. They are removed by
+// stripPivotFields before a request is sent to a Chat Completions backend.
+const (
+	fieldReasoning          = "reasoning_content"
+	fieldReasoningSignature = "reasoning_signature"
+	fieldRedactedReasoning  = "redacted_reasoning"
+	fieldToolError          = "is_error"
+)
+
+// toolErrorPrefix marks failed tool results for protocols without an
+// is_error flag.
+const toolErrorPrefix = "Error: "
+
+// Request converts a request body from one protocol to another. Reasoning
+// efforts and thinking budgets are mapped through tiers; nil uses
+// thinking.DefaultTiers.
+func Request(from, to Protocol, body []byte, tiers []thinking.Tier) ([]byte, error) {
+	if from == to {
+		return body, nil
+	}
+	if tiers == nil {
+		tiers = thinking.DefaultTiers
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+	if err := checkSupported(from, to, data); err != nil {
+		return nil, err
+	}
+
+	var chat map[string]interface{}
+	switch from {
+	case Anthropic:
+		chat = messagesToChat(data, tiers)
+	case Chat:
+		chat = data
+	case Responses:
+		chat = responsesToChat(data)
+	default:
+		return nil, fmt.Errorf("unsupported source protocol %s", from)
+	}
+
+	var out map[string]interface{}
+	switch to {
+	case Anthropic:
+		out = chatToMessages(chat, tiers)
+	case Chat:
+		out = stripPivotFields(chat)
+	case Responses:
+		out = chatToResponses(chat, tiers)
+	default:
+		return nil, fmt.Errorf("unsupported target protocol %s", to)
+	}
+
+	return json.Marshal(out)
+}
+
+// checkSupported rejects requests relying on fields that have no equivalent
+// in the target protocol, rather than silently dropping them.
+func checkSupported(from, to Protocol, data map[string]interface{}) error {
+	if from == Responses && to != Responses {
+		if id, _ := data["previous_response_id"].(string); id != "" {
+			return fmt.Errorf("previous_response_id is not supported by %s backends; send the full input instead", to)
+		}
+		if _, ok := data["conversation"]; ok {
+			return fmt.Errorf("conversation is not supported by %s backends; send the full input instead", to)
+		}
+		if store, _ := data["store"].(bool); store {
+			return fmt.Errorf("store is not supported by %s backends", to)
+		}
+	}
+	if from != to {
+		tools, _ := data["tools"].([]interface{})
+		for _, raw := range tools {
+			if t := toolType(from, object(raw)); t != "" {
+				return fmt.Errorf("%s tools are not supported by %s backends; only function tools translate", t, to)
+			}
+		}
+	}
+	if to == Anthropic {
+		format := object(data["response_format"])
+		if from == Responses {
+			format = object(object(data["text"])["format"])
+		}
+		if t := str(format["type"]); t != "" && t != "text" {
+			return fmt.Errorf("%s output format is not supported by %s backends", t, to)
+		}
+		for key := range object(data["metadata"]) {
+			if key != "user_id" {
+				return fmt.Errorf("metadata.%s is not supported by %s backends (only user_id)", key, to)
+			}
+		}
+	}
+	return nil
+}
+
+// toolType returns the type of a tool that has no function-tool
+// equivalent, such as Responses' web_search or Anthropic's server tools,
+// or "" for a function tool.
+func toolType(p Protocol, tool map[string]interface{}) string {
+	t := str(tool["type"])
+	switch p {
+	case Anthropic:
+		// Client tools have no type, or "custom"
+		if t == "" || t == "custom" {
+			return ""
+		}
+	default:
+		if t == "function" {
+			return ""
+		}
+		if t == "" {
+			return "untyped"
+		}
+	}
+	return t
+}
+
+// copyFields copies the listed keys from src to dst when present.
+func copyFields(dst, src map[string]interface{}, keys ...string) {
+	for _, k := range keys {
+		if v, ok := src[k]; ok {
+			dst[k] = v
+		}
+	}
+}
+
+// --- Anthropic Messages <-> Chat Completions ---
+
+func messagesToChat(data map[string]interface{}, tiers []thinking.Tier) map[string]interface{} {
+	chat := map[string]interface{}{}
+	copyFields(chat, data, "model", "stream", "temperature", "top_p", "max_tokens", "thinking")
+
+	if stop, ok := data["stop_sequences"]; ok {
+		chat["stop"] = stop
+	}
+	if thinking, ok := data["thinking"].(map[string]interface{}); ok && thinking["type"] == "enabled" {
+		if budget, ok := thinking["budget_tokens"].(float64); ok {
+			chat["reasoning_effort"] = effortForBudget(int(budget), tiers)
+		}
+	}
+	if stream, _ := data["stream"].(bool); stream {
+		chat["stream_options"] = map[string]interface{}{"include_usage": true}
+	}
+	if user := str(object(data["metadata"])["user_id"]); user != "" {
+		chat["user"] = user
+	}
+
+	var messages []interface{}
+	if hasCacheControl(data["system"]) {
+		messages = append(messages, map[string]interface{}{"role": "system", "content": textParts(data["system"])})
+	} else if system := textFromContent(data["system"]); system != "" {
+		messages = append(messages, map[string]interface{}{"role": "system", "content": system})
+	}
+
+	rawMessages, _ := data["messages"].([]interface{})
+	for _, raw := range rawMessages {
+		msg, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		role, _ := msg["role"].(string)
+		if role == "assistant" {
+			messages = append(messages, anthropicAssistantToChat(msg["content"]))
+			continue
+		}
+		messages = append(messages, anthropicUserToChat(msg["content"])...)
+	}
+	chat["messages"] = messages
+
+	if tools, ok := data["tools"].([]interface{}); ok {
+		var chatTools []interface{}
+		for _, raw := range tools {
+			tool, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			chatTool := map[string]interface{}{
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":        tool["name"],
+					"description": tool["description"],
+					"parameters":  tool["input_schema"],
+				},
+			}
+			copyFields(chatTool, tool, "cache_control")
+			chatTools = append(chatTools, chatTool)
+		}
+		chat["tools"] = chatTools
+	}
+
+	if choice, ok := data["tool_choice"].(map[string]interface{}); ok {
+		if disable, _ := choice["disable_parallel_tool_use"].(bool); disable {
+			chat["parallel_tool_calls"] = false
+		}
+		switch choice["type"] {
+		case "auto":
+			chat["tool_choice"] = "auto"
+		case "any":
+			chat["tool_choice"] = "required"
+		case "none":
+			chat["tool_choice"] = "none"
+		case "tool":
+			chat["tool_choice"] = map[string]interface{}{
+				"type":     "function",
+				"function": map[string]interface{}{"name": choice["name"]},
+			}
+		}
+	}
+
+	return chat
+}
+
+// anthropicUserToChat converts a user turn. Tool results become separate
+// tool messages ahead of the remaining user content.
+func anthropicUserToChat(content interface{}) []interface{} {
+	blocks, ok := content.([]interface{})
+	if !ok {
+		return []interface{}{map[string]interface{}{"role": "user", "content": textFromContent(content)}}
+	}
+
+	var messages []interface{}
+	var parts []interface{}
+	for _, raw := range blocks {
+		block, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch block["type"] {
+		case "text":
+			parts = append(parts, textPart(block))
+		case "image":
+			if part := anthropicImagePart(block); part != nil {
+				parts = append(parts, part)
+			}
+		case "tool_result":
+			msg := map[string]interface{}{
+				"role":         "tool",
+				"tool_call_id": block["tool_use_id"],
+				"content":      textFromContent(block["content"]),
+			}
+			if isError, _ := block["is_error"].(bool); isError {
+				msg[fieldToolError] = true
+			}
+			messages = append(messages, msg)
+			// Chat tool messages are text-only, so images returned by the
+			// tool follow in the user turn.
+			if results, ok := block["content"].([]interface{}); ok {
+				for _, r := range results {
+					if img, ok := r.(map[string]interface{}); ok && img["type"] == "image" {
+						if part := anthropicImagePart(img); part != nil {
+							parts = append(parts, part)
+						}
+					}
+				}
+			}
+		}
+	}
+	if len(parts) > 0 {
+		messages = append(messages, map[string]interface{}{"role": "user", "content": parts})
+	}
+	return messages
+}
+
+func anthropicAssistantToChat(content interface{}) map[string]interface{} {
+	msg := map[string]interface{}{"role": "assistant"}
+	blocks, ok := content.([]interface{})
+	if !ok {
+		msg["content"] = textFromContent(content)
+		return msg
+	}
+
+	var text, reasoning strings.Builder
+	var signature string
+	var redacted, toolCalls []interface{}
+	for _, raw := range blocks {
+		block, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch block["type"] {
+		case "text":
+			s, _ := block["text"].(string)
+			text.WriteString(s)
+		case "thinking":
+			s, _ := block["thinking"].(string)
+			reasoning.WriteString(s)
+			if sig, ok := block["signature"].(string); ok {
+				signature = sig
+			}
+		case "redacted_thinking":
+			redacted = append(redacted, block["data"])
+		case "tool_use":
+			args, _ := json.Marshal(block["input"])
+			toolCalls = append(toolCalls, map[string]interface{}{
+				"id":   block["id"],
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":      block["name"],
+					"arguments": string(args),
+				},
+			})
+		}
+	}
+
+	msg["content"] = text.String()
+	if reasoning.Len() > 0 {
+		msg[fieldReasoning] = reasoning.String()
+		if signature != "" {
+			msg[fieldReasoningSignature] = signature
+		}
+	}
+	if len(redacted) > 0 {
+		msg[fieldRedactedReasoning] = redacted
+	}
+	if len(toolCalls) > 0 {
+		msg["tool_calls"] = toolCalls
+	}
+	return msg
+}
+
+func anthropicImagePart(block map[string]interface{}) map[string]interface{} {
+	url := anthropicImageURL(block)
+	if url == "" {
+		return nil
+	}
+	return map[string]interface{}{
+		"type":      "image_url",
+		"image_url": map[string]interface{}{"url": url},
+	}
+}
+
+// textPart converts a text block, keeping any prompt caching hint.
+func textPart(block map[string]interface{}) map[string]interface{} {
+	part := map[string]interface{}{"type": "text", "text": block["text"]}
+	copyFields(part, block, "cache_control")
+	return part
+}
+
+// textParts converts a string or list of text blocks to text parts.
+func textParts(content interface{}) []interface{} {
+	blocks, ok := content.([]interface{})
+	if !ok {
+		return []interface{}{map[string]interface{}{"type": "text", "text": textFromContent(content)}}
+	}
+	var parts []interface{}
+	for _, raw := range blocks {
+		if block, ok := raw.(map[string]interface{}); ok && block["text"] != nil {
+			parts = append(parts, textPart(block))
+		}
+	}
+	return parts
+}
+
+// hasCacheControl reports whether any part of content carries a prompt
+// caching hint.
+func hasCacheControl(content interface{}) bool {
+	parts, _ := content.([]interface{})
+	for _, raw := range parts {
+		if part, ok := raw.(map[string]interface{}); ok && part["cache_control"] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func anthropicImageURL(block map[string]interface{}) string {
+	source, _ := block["source"].(map[string]interface{})
+	switch source["type"] {
+	case "base64":
+		return fmt.Sprintf("data:%v;base64,%v", source["media_type"], source["data"])
+	case "url":
+		url, _ := source["url"].(string)
+		return url
+	default:
+		return ""
+	}
+}
+
+func chatToMessages(chat map[string]interface{}, tiers []thinking.Tier) map[string]interface{} {
+	out := map[string]interface{}{}
+	copyFields(out, chat, "model", "stream", "temperature", "top_p", "thinking")
+
+	maxTokens := intValue(chat["max_completion_tokens"])
+	if maxTokens == 0 {
+		maxTokens = intValue(chat["max_tokens"])
+	}
+	if maxTokens == 0 {
+		maxTokens = defaultMaxTokens
+	}
+
+	if _, ok := out["thinking"]; !ok {
+		if effort, ok := chat["reasoning_effort"].(string); ok {
+			if budget := budgetForEffort(effort, tiers); budget > 0 {
+				out["thinking"] = map[string]interface{}{"type": "enabled", "budget_tokens": budget}
+			}
+		}
+	}
+	if thinking, ok := out["thinking"].(map[string]interface{}); ok {
+		if budget := intValue(thinking["budget_tokens"]); maxTokens <= budget {
+			maxTokens = budget + defaultMaxTokens
+		}
+		// Anthropic rejects sampling parameters while thinking is enabled.
+		delete(out, "temperature")
+		delete(out, "top_p")
+	}
+	out["max_tokens"] = maxTokens
+
+	switch stop := chat["stop"].(type) {
+	case string:
+		out["stop_sequences"] = []interface{}{stop}
+	case []interface{}:
+		out["stop_sequences"] = stop
+	}
+	if user := str(chat["user"]); user != "" {
+		out["metadata"] = map[string]interface{}{"user_id": user}
+	} else if meta, ok := chat["metadata"]; ok {
+		out["metadata"] = meta
+	}
+
+	var system []string
+	var systemParts []interface{}
+	var cached bool
+	var messages []interface{}
+	rawMessages, _ := chat["messages"].([]interface{})
+	for _, raw := range rawMessages {
+		msg, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch msg["role"] {
+		case "system", "developer":
+			if text := textFromContent(msg["content"]); text != "" {
+				system = append(system, text)
+				systemParts = append(systemParts, textParts(msg["content"])...)
+				cached = cached || hasCacheControl(msg["content"])
+			}
+		case "assistant":
+			messages = appendAnthropicTurn(messages, "assistant", chatAssistantToBlocks(msg))
+		case "tool":
+			result := map[string]interface{}{
+				"type":        "tool_result",
+				"tool_use_id": msg["tool_call_id"],
+				"content":     textFromContent(msg["content"]),
+			}
+			if isError, _ := msg[fieldToolError].(bool); isError {
+				result["is_error"] = true
+			}
+			messages = appendAnthropicTurn(messages, "user", []interface{}{result})
+		default:
+			messages = appendAnthropicTurn(messages, "user", chatContentToBlocks(msg["content"]))
+		}
+	}
+	if cached {
+		out["system"] = systemParts
+	} else if len(system) > 0 {
+		out["system"] = strings.Join(system, "\n\n")
+	}
+	out["messages"] = messages
+
+	if tools, ok := chat["tools"].([]interface{}); ok {
+		var anthropicTools []interface{}
+		for _, raw := range tools {
+			tool, _ := raw.(map[string]interface{})
+			fn, ok := tool["function"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			schema := fn["parameters"]
+			if schema == nil {
+				schema = map[string]interface{}{"type": "object"}
+			}
+			t := map[string]interface{}{"name": fn["name"], "input_schema": schema}
+			if desc, ok := fn["description"].(string); ok && desc != "" {
+				t["description"] = desc
+			}
+			copyFields(t, tool, "cache_control")
+			anthropicTools = append(anthropicTools, t)
+		}
+		out["tools"] = anthropicTools
+	}
+
+	switch choice := chat["tool_choice"].(type) {
+	case string:
+		switch choice {
+		case "auto":
+			out["tool_choice"] = map[string]interface{}{"type": "auto"}
+		case "required":
+			out["tool_choice"] = map[string]interface{}{"type": "any"}
+		case "none":
+			out["tool_choice"] = map[string]interface{}{"type": "none"}
+		}
+	case map[string]interface{}:
+		if fn, ok := choice["function"].(map[string]interface{}); ok {
+			out["tool_choice"] = map[string]interface{}{"type": "tool", "name": fn["name"]}
+		}
+	}
+	if parallel, ok := chat["parallel_tool_calls"].(bool); ok && !parallel {
+		choice, _ := out["tool_choice"].(map[string]interface{})
+		if choice == nil {
+			choice = map[string]interface{}{"type": "auto"}
+		}
+		choice["disable_parallel_tool_use"] = true
+		out["tool_choice"] = choice
+	}
+
+	return out
+}
+
+// appendAnthropicTurn appends content blocks, merging consecutive turns of
+// the same role since Anthropic expects roles to alternate.
+func appendAnthropicTurn(messages []interface{}, role string, blocks []interface{}) []interface{} {
+	if len(blocks) == 0 {
+		return messages
+	}
+	if n := len(messages); n > 0 {
+		last := messages[n-1].(map[string]interface{})
+		if last["role"] == role {
+			last["content"] = append(last["content"].([]interface{}), blocks...)
+			return messages
+		}
+	}
+	return append(messages, map[string]interface{}{"role": role, "content": blocks})
+}
+
+func chatAssistantToBlocks(msg map[string]interface{}) []interface{} {
+	var blocks []interface{}
+
+	// Thinking blocks are only valid with the signature Anthropic issued.
+	if sig, ok := msg[fieldReasoningSignature].(string); ok && sig != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type":      "thinking",
+			"thinking":  msg[fieldReasoning],
+			"signature": sig,
+		})
+	}
+	redacted, _ := msg[fieldRedactedReasoning].([]interface{})
+	for _, data := range redacted {
+		blocks = append(blocks, map[string]interface{}{"type": "redacted_thinking", "data": data})
+	}
+	if text := textFromContent(msg["content"]); text != "" {
+		blocks = append(blocks, map[string]interface{}{"type": "text", "text": text})
+	}
+
+	toolCalls, _ := msg["tool_calls"].([]interface{})
+	for _, raw := range toolCalls {
+		call, _ := raw.(map[string]interface{})
+		fn, ok := call["function"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var input interface{} = map[string]interface{}{}
+		if args, ok := fn["arguments"].(string); ok && args != "" {
+			var parsed interface{}
+			if json.Unmarshal([]byte(args), &parsed) == nil {
+				input = parsed
+			}
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type":  "tool_use",
+			"id":    call["id"],
+			"name":  fn["name"],
+			"input": input,
+		})
+	}
+	return blocks
+}
+
+func chatContentToBlocks(content interface{}) []interface{} {
+	parts, ok := content.([]interface{})
+	if !ok {
+		if text := textFromContent(content); text != "" {
+			return []interface{}{map[string]interface{}{"type": "text", "text": text}}
+		}
+		return nil
+	}
+
+	var blocks []interface{}
+	for _, raw := range parts {
+		part, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch part["type"] {
+		case "text", "input_text":
+			blocks = append(blocks, textPart(part))
+		case "image_url", "input_image":
+			if block := imageBlockFromURL(imageURL(part)); block != nil {
+				blocks = append(blocks, block)
+			}
+		}
+	}
+	return blocks
+}
+
+func imageURL(part map[string]interface{}) string {
+	switch u := part["image_url"].(type) {
+	case string:
+		return u
+	case map[string]interface{}:
+		s, _ := u["url"].(string)
+		return s
+	default:
+		return ""
+	}
+}
+
+func imageBlockFromURL(url string) map[string]interface{} {
+	if url == "" {
+		return nil
+	}
+	if rest, ok := strings.CutPrefix(url, "data:"); ok {
+		meta, data, found := strings.Cut(rest, ",")
+		if found && strings.HasSuffix(meta, ";base64") {
+			return map[string]interface{}{
+				"type": "image",
+				"source": map[string]interface{}{
+					"type":       "base64",
+					"media_type": strings.TrimSuffix(meta, ";base64"),
+					"data":       data,
+				},
+			}
+		}
+	}
+	return map[string]interface{}{
+		"type":   "image",
+		"source": map[string]interface{}{"type": "url", "url": url},
+	}
+}
+
+// --- Responses <-> Chat Completions ---
+
+func responsesToChat(data map[string]interface{}) map[string]interface{} {
+	chat := map[string]interface{}{}
+	copyFields(chat, data, "model", "stream", "temperature", "top_p", "thinking",
+		"parallel_tool_calls", "metadata", "user")
+
+	if format := object(object(data["text"])["format"]); format != nil {
+		chat["response_format"] = chatResponseFormat(format)
+	}
+	if maxTokens, ok := data["max_output_tokens"]; ok {
+		chat["max_tokens"] = maxTokens
+	}
+	if reasoning, ok := data["reasoning"].(map[string]interface{}); ok {
+		if effort, ok := reasoning["effort"].(string); ok {
+			chat["reasoning_effort"] = effort
+		}
+	}
+	if stream, _ := data["stream"].(bool); stream {
+		chat["stream_options"] = map[string]interface{}{"include_usage": true}
+	}
+
+	var messages []interface{}
+	if instructions, ok := data["instructions"].(string); ok && instructions != "" {
+		messages = append(messages, map[string]interface{}{"role": "system", "content": instructions})
+	}
+	messages = append(messages, InputToMessages(data["input"])...)
+	chat["messages"] = messages
+
+	if tools, ok := data["tools"].([]interface{}); ok {
+		var chatTools []interface{}
+		for _, raw := range tools {
+			tool, ok := raw.(map[string]interface{})
+			if !ok || tool["type"] != "function" {
+				continue
+			}
+			chatTools = append(chatTools, map[string]interface{}{
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":        tool["name"],
+					"description": tool["description"],
+					"parameters":  tool["parameters"],
+				},
+			})
+		}
+		chat["tools"] = chatTools
+	}
+
+	switch choice := data["tool_choice"].(type) {
+	case string:
+		chat["tool_choice"] = choice
+	case map[string]interface{}:
+		if name, ok := choice["name"]; ok {
+			chat["tool_choice"] = map[string]interface{}{
+				"type":     "function",
+				"function": map[string]interface{}{"name": name},
+			}
+		}
+	}
+
+	return chat
+}
+
+// InputToMessages converts a Responses `input` (a string or a list of items)
+// into Chat Completions messages.
+func InputToMessages(input interface{}) []interface{} {
+	if text, ok := input.(string); ok {
+		return []interface{}{map[string]interface{}{"role": "user", "content": text}}
+	}
+
+	items, _ := input.([]interface{})
+	var messages []interface{}
+	var pendingReasoning string
+
+	// lastAssistant returns the trailing assistant message, creating one so
+	// consecutive function calls share a single tool_calls list.
+	lastAssistant := func() map[string]interface{} {
+		if n := len(messages); n > 0 {
+			if last := messages[n-1].(map[string]interface{}); last["role"] == "assistant" {
+				return last
+			}
+		}
+		msg := map[string]interface{}{"role": "assistant", "content": ""}
+		if pendingReasoning != "" {
+			msg[fieldReasoning] = pendingReasoning
+			pendingReasoning = ""
+		}
+		messages = append(messages, msg)
+		return msg
+	}
+
+	for _, raw := range items {
+		item, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch item["type"] {
+		case "message", nil:
+			role, _ := item["role"].(string)
+			if role == "assistant" {
+				msg := map[string]interface{}{"role": "assistant", "content": textFromContent(item["content"])}
+				if pendingReasoning != "" {
+					msg[fieldReasoning] = pendingReasoning
+					pendingReasoning = ""
+				}
+				messages = append(messages, msg)
+				continue
+			}
+			if role == "developer" {
+				role = "system"
+			}
+			messages = append(messages, map[string]interface{}{"role": role, "content": responsesContentToChat(item["content"])})
+
+		case "reasoning":
+			pendingReasoning += textFromContent(item["summary"])
+
+		case "function_call":
+			msg := lastAssistant()
+			calls, _ := msg["tool_calls"].([]interface{})
+			msg["tool_calls"] = append(calls, map[string]interface{}{
+				"id":   item["call_id"],
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":      item["name"],
+					"arguments": item["arguments"],
+				},
+			})
+
+		case "function_call_output":
+			messages = append(messages, map[string]interface{}{
+				"role":         "tool",
+				"tool_call_id": item["call_id"],
+				"content":      textFromContent(item["output"]),
+			})
+		}
+	}
+	return messages
+}
+
+func responsesContentToChat(content interface{}) interface{} {
+	parts, ok := content.([]interface{})
+	if !ok {
+		return textFromContent(content)
+	}
+
+	var out []interface{}
+	for _, raw := range parts {
+		part, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch part["type"] {
+		case "input_text", "output_text", "text":
+			out = append(out, map[string]interface{}{"type": "text", "text": part["text"]})
+		case "input_image":
+			out = append(out, map[string]interface{}{
+				"type":      "image_url",
+				"image_url": map[string]interface{}{"url": imageURL(part)},
+			})
+		}
+	}
+	return out
+}
+
+func chatToResponses(chat map[string]interface{}, tiers []thinking.Tier) map[string]interface{} {
+	out := map[string]interface{}{}
+	copyFields(out, chat, "model", "stream", "temperature", "top_p",
+		"parallel_tool_calls", "metadata", "user")
+
+	if format := object(chat["response_format"]); format != nil {
+		out["text"] = map[string]interface{}{"format": responsesTextFormat(format)}
+	}
+
+	if maxTokens, ok := chat["max_completion_tokens"]; ok {
+		out["max_output_tokens"] = maxTokens
+	} else if maxTokens, ok := chat["max_tokens"]; ok {
+		out["max_output_tokens"] = maxTokens
+	}
+
+	effort, _ := chat["reasoning_effort"].(string)
+	if thinking, ok := chat["thinking"].(map[string]interface{}); ok && effort == "" {
+		effort = effortForBudget(intValue(thinking["budget_tokens"]), tiers)
+	}
+	if effort != "" {
+		out["reasoning"] = map[string]interface{}{"effort": effort, "summary": "auto"}
+	}
+
+	messages, _ := chat["messages"].([]interface{})
+	items, instructions := MessagesToInput(messages)
+	out["input"] = items
+	if instructions != "" {
+		out["instructions"] = instructions
+	}
+
+	if tools, ok := chat["tools"].([]interface{}); ok {
+		var responsesTools []interface{}
+		for _, raw := range tools {
+			tool, _ := raw.(map[string]interface{})
+			fn, ok := tool["function"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			responsesTools = append(responsesTools, map[string]interface{}{
+				"type":        "function",
+				"name":        fn["name"],
+				"description": fn["description"],
+				"parameters":  fn["parameters"],
+			})
+		}
+		out["tools"] = responsesTools
+	}
+
+	switch choice := chat["tool_choice"].(type) {
+	case string:
+		out["tool_choice"] = choice
+	case map[string]interface{}:
+		if fn, ok := choice["function"].(map[string]interface{}); ok {
+			out["tool_choice"] = map[string]interface{}{"type": "function", "name": fn["name"]}
+		}
+	}
+
+	return out
+}
+
+// chatResponseFormat converts a Responses text.format to a Chat Completions
+// response_format, which nests the JSON schema one level deeper.
+func chatResponseFormat(format map[string]interface{}) map[string]interface{} {
+	if format["type"] != "json_schema" {
+		return map[string]interface{}{"type": format["type"]}
+	}
+	schema := map[string]interface{}{}
+	copyFields(schema, format, "name", "description", "schema", "strict")
+	return map[string]interface{}{"type": "json_schema", "json_schema": schema}
+}
+
+// responsesTextFormat is the inverse of chatResponseFormat.
+func responsesTextFormat(format map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{"type": format["type"]}
+	if format["type"] == "json_schema" {
+		copyFields(out, object(format["json_schema"]), "name", "description", "schema", "strict")
+	}
+	return out
+}
+
+// MessagesToInput converts Chat Completions messages into Responses input
+// items. System and developer messages are returned as instructions.
+func MessagesToInput(messages []interface{}) ([]interface{}, string) {
+	items := make([]interface{}, 0, len(messages))
+	var instructions []string
+
+	for _, raw := range messages {
+		msg, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		role, _ := msg["role"].(string)
+
+		switch role {
+		case "system", "developer":
+			if text := textFromContent(msg["content"]); text != "" {
+				instructions = append(instructions, text)
+			}
+
+		case "tool":
+			callID, _ := msg["tool_call_id"].(string)
+			items = append(items, map[string]interface{}{
+				"type":    "function_call_output",
+				"call_id": callID,
+				"output":  toolOutput(msg),
+			})
+
+		case "assistant":
+			if parts := chatContentToResponses(msg["content"], "output_text"); len(parts) > 0 {
+				items = append(items, map[string]interface{}{
+					"type":    "message",
+					"role":    "assistant",
+					"content": parts,
+				})
+			}
+			toolCalls, _ := msg["tool_calls"].([]interface{})
+			for _, rawCall := range toolCalls {
+				call, ok := rawCall.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				fn, _ := call["function"].(map[string]interface{})
+				items = append(items, map[string]interface{}{
+					"type":      "function_call",
+					"call_id":   call["id"],
+					"name":      fn["name"],
+					"arguments": fn["arguments"],
+				})
+			}
+
+		default:
+			items = append(items, map[string]interface{}{
+				"type":    "message",
+				"role":    "user",
+				"content": chatContentToResponses(msg["content"], "input_text"),
+			})
+		}
+	}
+
+	return items, strings.Join(instructions, "\n\n")
+}
+
+// chatContentToResponses maps Chat Completions content (a string or a list
+// of parts) to Responses content parts.
+func chatContentToResponses(content interface{}, textType string) []interface{} {
+	switch c := content.(type) {
+	case string:
+		if c == "" {
+			return nil
+		}
+		return []interface{}{map[string]interface{}{"type": textType, "text": c}}
+	case []interface{}:
+		parts := make([]interface{}, 0, len(c))
+		for _, rawPart := range c {
+			part, ok := rawPart.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch part["type"] {
+			case "text":
+				parts = append(parts, map[string]interface{}{"type": textType, "text": part["text"]})
+			case "image_url":
+				parts = append(parts, map[string]interface{}{"type": "input_image", "image_url": imageURL(part)})
+			default:
+				parts = append(parts, part)
+			}
+		}
+		return parts
+	default:
+		return nil
+	}
+}
+
+// toolOutput flattens a tool message, marking failures in the text for
+// protocols without an is_error flag.
+func toolOutput(msg map[string]interface{}) string {
+	text := textFromContent(msg["content"])
+	if isError, _ := msg[fieldToolError].(bool); isError {
+		return toolErrorPrefix + text
+	}
+	return text
+}
+
+func stripPivotFields(chat map[string]interface{}) map[string]interface{} {
+	// thinking is carried so Anthropic targets keep the exact budget;
+	// Chat backends read reasoning_effort instead.
+	delete(chat, "thinking")
+	messages, _ := chat["messages"].([]interface{})
+	for _, raw := range messages {
+		msg, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		delete(msg, fieldReasoning)
+		delete(msg, fieldReasoningSignature)
+		delete(msg, fieldRedactedReasoning)
+		if _, ok := msg[fieldToolError]; ok {
+			msg["content"] = toolOutput(msg)
+			delete(msg, fieldToolError)
+		}
+	}
+	return chat
+}
+
+// TextFromContent flattens a string or a list of text parts into plain text.
+func TextFromContent(content interface{}) string {
+	return textFromContent(content)
+}
+
+func textFromContent(content interface{}) string {
+	switch c := content.(type) {
+	case string:
+		return c
+	case []interface{}:
+		var texts []string
+		for _, rawPart := range c {
+			if part, ok := rawPart.(map[string]interface{}); ok {
+				if text, ok := part["text"].(string); ok {
+					texts = append(texts, text)
+				}
+			}
+		}
+		return strings.Join(texts, "\n")
+	default:
+		return ""
+	}
+}
+
+func intValue(v interface{}) int {
+	switch n := v.(type) {
+	case float64:
+		return int(n)
+	case int:
+		return n
+	default:
+		return 0
+	}
+}
+
+// budgetForEffort maps an OpenAI reasoning effort to an Anthropic thinking
+// budget through the tier of the same name, as a -thinking-<tier> model
+// suffix would. "minimal" and "xhigh", unless tiers name them, take the
+// least and greatest budgets.
+func budgetForEffort(effort string, tiers []thinking.Tier) int {
+	if t, ok := thinking.Find(tiers, effort); ok {
+		budget, _ := t.Resolve(thinking.Range{})
+		return budget
+	}
+	switch effort {
+	case "minimal":
+		return thinking.MinBudget
+	case "xhigh":
+		return thinking.MaxBudget
+	}
+	return 0
+}
+
+// reasoningEfforts are the OpenAI efforts a thinking budget maps back to.
+var reasoningEfforts = []string{"low", "medium", "high"}
+
+// effortForBudget maps an Anthropic thinking budget to the effort whose
+// tier budget is nearest on the tiers' log scale, so the boundaries fall
+// halfway between adjacent tiers. Tiers naming none of the efforts fall
+// back to thinking.DefaultTiers.
+func effortForBudget(budget int, tiers []thinking.Tier) string {
+	if budget <= 0 {
+		return ""
+	}
+	for _, candidates := range [][]thinking.Tier{tiers, thinking.DefaultTiers} {
+		effort, best := "", math.Inf(1)
+		for _, name := range reasoningEfforts {
+			t, ok := thinking.Find(candidates, name)
+			if !ok {
+				continue
+			}
+			tokens, _ := t.Resolve(thinking.Range{})
+			if tokens == 0 {
+				continue
+			}
+			if d := math.Abs(math.Log(float64(budget) / float64(tokens))); d < best {
+				effort, best = name, d
+			}
+		}
+		if effort != "" {
+			return effort
+		}
+	}
+	return ""
+}
@@ -0,0 +1,207 @@
+package translate
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// Response converts a complete, non-streaming response body, applying
+// filters to the decoded events.
+func Response(from, to Protocol, body []byte, filters ...Filter) ([]byte, error) {
+	if from == to && !hasFilters(filters) {
+		return body, nil
+	}
+	events, err := decodeBody(from, body)
+	if err != nil {
+		return nil, err
+	}
+	return encodeBody(to, applyFilters(events, filters))
+}
+
+func hasFilters(filters []Filter) bool {
+	for _, f := range filters {
+		if f != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeBody(to Protocol, events []Event) ([]byte, error) {
+	for _, ev := range events {
+		if ev.Type == EventError {
+			return ErrorBody(to, ev.Text), nil
+		}
+	}
+
+	m := collect(events)
+	switch to {
+	case Anthropic:
+		return json.Marshal(encodeAnthropicBody(m))
+	case Responses:
+		return json.Marshal(encodeResponsesBody(m))
+	default:
+		return json.Marshal(encodeChatBody(m))
+	}
+}
+
+// ErrorBody renders an error message in the client protocol's error shape.
+func ErrorBody(to Protocol, message string) []byte {
+	var body map[string]interface{}
+	if to == Anthropic {
+		body = anthropicError(message)
+	} else {
+		body = openAIError(message)
+	}
+	data, _ := json.Marshal(body)
+	return data
+}
+
+// ErrorEvent renders an error as an SSE event in the client protocol, for
+// ending a stream that cannot complete.
+func ErrorEvent(to Protocol, message string) []byte {
+	return newEncoder(to).encode(Event{Type: EventError, Text: message})
+}
+
+// ErrorMessage extracts a human-readable message from an error body in any
+// of the supported protocols.
+func ErrorMessage(body []byte) string {
+	if msg, ok := ExtractErrorMessage(body); ok {
+		return msg
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// ExtractErrorMessage returns the message of a JSON error body in any of
+// the supported shapes, reporting false when there is none.
+func ExtractErrorMessage(body []byte) (string, bool) {
+	var data map[string]interface{}
+	if json.Unmarshal(body, &data) != nil {
+		return "", false
+	}
+	if errObj := object(data["error"]); errObj != nil {
+		if msg := str(errObj["message"]); msg != "" {
+			return msg, true
+		}
+	}
+	for _, key := range []string{"error", "detail", "message"} {
+		if msg := str(data[key]); msg != "" {
+			return msg, true
+		}
+	}
+	return "", false
+}
+
+// streamReader translates an SSE stream event by event as it is read, so
+// responses are never buffered whole.
+type streamReader struct {
+	src     *bufio.Reader
+	body    io.Closer
+	dec     decoder
+	enc     encoder
+	filters []Filter
+	buf     bytes.Buffer
+	err     error
+}
+
+// NewStreamReader wraps an SSE response body in protocol from and yields the
+// equivalent SSE stream in protocol to, applying filters to each event.
+func NewStreamReader(from, to Protocol, body io.ReadCloser, filters ...Filter) io.ReadCloser {
+	return &streamReader{
+		src:     bufio.NewReader(body),
+		body:    body,
+		dec:     newDecoder(from),
+		enc:     newEncoder(to),
+		filters: filters,
+	}
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	for s.buf.Len() == 0 && s.err == nil {
+		s.step()
+	}
+	if s.buf.Len() > 0 {
+		return s.buf.Read(p)
+	}
+	return 0, s.err
+}
+
+func (s *streamReader) Close() error {
+	return s.body.Close()
+}
+
+// step reads one SSE event from the source and encodes the result.
+func (s *streamReader) step() {
+	ev, err := readSSEEvent(s.src)
+	if ev != nil {
+		s.write(s.dec.decode(*ev))
+	}
+	if err != nil {
+		s.write(s.end(err))
+		s.err = err
+	}
+}
+
+// end closes the response at end of input. A stream that stops before the
+// backend's terminal event, or fails to read, is reported as an error so a
+// crashed backend never looks like a complete answer.
+func (s *streamReader) end(err error) []Event {
+	switch {
+	case err != io.EOF:
+		return []Event{{Type: EventError, Text: "backend stream failed: " + err.Error()}}
+	case !s.dec.complete():
+		return []Event{{Type: EventError, Text: "backend stream ended before the response completed"}}
+	default:
+		return s.dec.finish()
+	}
+}
+
+func (s *streamReader) write(events []Event) {
+	for _, out := range applyFilters(events, s.filters) {
+		s.buf.Write(s.enc.encode(out))
+	}
+}
+
+// readSSEEvent reads lines up to the next blank line. Comment lines are
+// skipped. It returns a nil event when only comments or blank lines were read.
+func readSSEEvent(r *bufio.Reader) (*sseEvent, error) {
+	var ev sseEvent
+	var data []string
+	seen := false
+
+	for {
+		line, err := r.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case line == "":
+			if seen && err == nil {
+				ev.data = strings.Join(data, "\n")
+				return &ev, nil
+			}
+		case strings.HasPrefix(line, ":"):
+		default:
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				ev.name = value
+				seen = true
+			case "data":
+				data = append(data, value)
+				seen = true
+			}
+		}
+
+		if err != nil {
+			if seen {
+				ev.data = strings.Join(data, "\n")
+				return &ev, err
+			}
+			return nil, err
+		}
+	}
+}
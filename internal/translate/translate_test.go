@@ -0,0 +1,521 @@
+package translate
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/theadriann/vibeproxyplus/internal/thinking"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata/golden")
+
+var protocols = []Protocol{Anthropic, Chat, Responses}
+
+func TestMain(m *testing.M) {
+	nowUnix = func() int64 { return 1770000000 }
+	os.Exit(m.Run())
+}
+
+// deterministicIDs makes generated IDs stable within a test.
+func deterministicIDs(t *testing.T) {
+	t.Helper()
+	n := 0
+	prev := newID
+	newID = func(prefix string) string {
+		n++
+		return fmt.Sprintf("%sgen%d", prefix, n)
+	}
+	t.Cleanup(func() { newID = prev })
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name)
+	if *update {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("missing golden file (run go test -update): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s mismatch\n--- got ---\n%s\n--- want ---\n%s", name, got, want)
+	}
+}
+
+func indentJSON(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// summary is the protocol-independent content of a response, used to check
+// that translation preserves meaning.
+type summary struct {
+	Text       string
+	Thinking   string
+	Tools      []string
+	StopReason string
+	Usage      Usage
+}
+
+func summarize(events []Event) summary {
+	m := collect(events)
+	s := summary{StopReason: m.stopReason, Usage: m.usage}
+	for _, b := range m.blocks {
+		switch b.kind {
+		case EventText:
+			s.Text += b.text
+		case EventThinking:
+			s.Thinking += b.text
+		case EventToolStart:
+			s.Tools = append(s.Tools, b.toolID+" "+b.toolName+" "+compactJSON(b.text))
+		}
+	}
+	return s
+}
+
+func compactJSON(s string) string {
+	var buf bytes.Buffer
+	if json.Compact(&buf, []byte(s)) != nil {
+		return s
+	}
+	return buf.String()
+}
+
+func decodeStream(t *testing.T, p Protocol, data []byte) []Event {
+	t.Helper()
+	r := bufio.NewReader(bytes.NewReader(data))
+	dec := newDecoder(p)
+	var events []Event
+	for {
+		ev, err := readSSEEvent(r)
+		if ev != nil {
+			events = append(events, dec.decode(*ev)...)
+		}
+		if err != nil {
+			return append(events, dec.finish()...)
+		}
+	}
+}
+
+func TestRequestConformance(t *testing.T) {
+	for _, from := range protocols {
+		for _, to := range protocols {
+			if from == to {
+				continue
+			}
+			name := fmt.Sprintf("%s_request.to_%s.json", from, to)
+			t.Run(name, func(t *testing.T) {
+				out, err := Request(from, to, readFixture(t, from.String()+"_request.json"), nil)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				checkGolden(t, name, indentJSON(t, out))
+			})
+		}
+	}
+}
+
+func TestStreamConformance(t *testing.T) {
+	for _, from := range protocols {
+		fixture := from.String() + "_stream.sse"
+		for _, to := range protocols {
+			name := fmt.Sprintf("%s_stream.to_%s.sse", from, to)
+			t.Run(name, func(t *testing.T) {
+				deterministicIDs(t)
+				input := readFixture(t, fixture)
+
+				r := NewStreamReader(from, to, io.NopCloser(bytes.NewReader(input)))
+				out, err := io.ReadAll(r)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				checkGolden(t, name, out)
+
+				want := summarize(decodeStream(t, from, input))
+				got := summarize(decodeStream(t, to, out))
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("translated stream changed meaning\n got: %+v\nwant: %+v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestResponseConformance(t *testing.T) {
+	for _, from := range protocols {
+		fixture := from.String() + "_response.json"
+		for _, to := range protocols {
+			if from == to {
+				continue
+			}
+			name := fmt.Sprintf("%s_response.to_%s.json", from, to)
+			t.Run(name, func(t *testing.T) {
+				deterministicIDs(t)
+				input := readFixture(t, fixture)
+
+				out, err := Response(from, to, input)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				checkGolden(t, name, indentJSON(t, out))
+
+				wantEvents, _ := decodeBody(from, input)
+				gotEvents, err := decodeBody(to, out)
+				if err != nil {
+					t.Fatalf("output does not decode: %v", err)
+				}
+				if got, want := summarize(gotEvents), summarize(wantEvents); !reflect.DeepEqual(got, want) {
+					t.Errorf("translated response changed meaning\n got: %+v\nwant: %+v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestRequestRoundTripPreservesThinkingSignature(t *testing.T) {
+	chat, err := Request(Anthropic, Chat, readFixture(t, "anthropic_request.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(chat), fieldReasoningSignature) {
+		t.Errorf("pivot-only fields leaked into Chat request: %s", chat)
+	}
+
+	var data map[string]interface{}
+	json.Unmarshal(readFixture(t, "anthropic_request.json"), &data)
+	back := chatToMessages(messagesToChat(data, thinking.DefaultTiers), thinking.DefaultTiers)
+	assistant := back["messages"].([]interface{})[1].(map[string]interface{})
+	first := assistant["content"].([]interface{})[0].(map[string]interface{})
+	if first["type"] != "thinking" || first["signature"] != "EqQBCkYIBxgCKkD" {
+		t.Errorf("thinking block lost in round trip: %v", first)
+	}
+}
+
+func TestRequestCarriesFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to Protocol
+		body     string
+		want     []string
+	}{
+		{
+			"tool error and images", Anthropic, Chat,
+			`{"model":"m","max_tokens":10,"messages":[{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","is_error":true,"content":[{"type":"text","text":"not found"},{"type":"image","source":{"type":"url","url":"https://x/y.png"}}]}]}]}`,
+			[]string{`"content":"Error: not found"`, `"image_url":{"url":"https://x/y.png"}`},
+		},
+		{
+			"tool error to responses", Anthropic, Responses,
+			`{"model":"m","max_tokens":10,"messages":[{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","is_error":true,"content":"boom"}]}]}`,
+			[]string{`"output":"Error: boom"`},
+		},
+		{
+			"cache control and parallel tools", Anthropic, Chat,
+			`{"model":"m","max_tokens":10,"system":[{"type":"text","text":"sys","cache_control":{"type":"ephemeral"}}],"tool_choice":{"type":"auto","disable_parallel_tool_use":true},"metadata":{"user_id":"u1"},"messages":[{"role":"user","content":[{"type":"text","text":"hi","cache_control":{"type":"ephemeral"}}]}]}`,
+			[]string{`"content":[{"cache_control":{"type":"ephemeral"},"text":"sys","type":"text"}]`, `{"cache_control":{"type":"ephemeral"},"text":"hi","type":"text"}`, `"parallel_tool_calls":false`, `"user":"u1"`},
+		},
+		{
+			"chat to anthropic", Chat, Anthropic,
+			`{"model":"m","parallel_tool_calls":false,"user":"u1","messages":[{"role":"system","content":[{"type":"text","text":"sys","cache_control":{"type":"ephemeral"}}]},{"role":"tool","tool_call_id":"t1","content":"boom","is_error":true}]}`,
+			[]string{`"disable_parallel_tool_use":true`, `"metadata":{"user_id":"u1"}`, `"system":[{"cache_control":{"type":"ephemeral"},"text":"sys","type":"text"}]`, `"is_error":true`},
+		},
+		{
+			"structured output responses to chat", Responses, Chat,
+			`{"model":"m","input":"hi","parallel_tool_calls":true,"metadata":{"k":"v"},"text":{"format":{"type":"json_schema","name":"out","schema":{"type":"object"},"strict":true}}}`,
+			[]string{`"response_format":{"json_schema":{"name":"out","schema":{"type":"object"},"strict":true},"type":"json_schema"}`, `"parallel_tool_calls":true`, `"metadata":{"k":"v"}`},
+		},
+		{
+			"structured output chat to responses", Chat, Responses,
+			`{"model":"m","messages":[{"role":"user","content":"hi"}],"response_format":{"type":"json_schema","json_schema":{"name":"out","schema":{"type":"object"}}}}`,
+			[]string{`"text":{"format":{"name":"out","schema":{"type":"object"},"type":"json_schema"}}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Request(tt.from, tt.to, []byte(tt.body), nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(out), want) {
+					t.Errorf("missing %s in %s", want, out)
+				}
+			}
+			if strings.Contains(string(out), fieldToolError) && tt.to != Anthropic {
+				t.Errorf("pivot-only is_error leaked: %s", out)
+			}
+		})
+	}
+}
+
+func TestRequestRedactedThinkingRoundTrip(t *testing.T) {
+	var data map[string]interface{}
+	json.Unmarshal([]byte(`{"model":"m","messages":[{"role":"assistant","content":[{"type":"redacted_thinking","data":"opaque"},{"type":"text","text":"ok"}]}]}`), &data)
+	back, _ := json.Marshal(chatToMessages(messagesToChat(data, thinking.DefaultTiers), thinking.DefaultTiers))
+	if !strings.Contains(string(back), `{"data":"opaque","type":"redacted_thinking"}`) {
+		t.Errorf("redacted_thinking lost in round trip: %s", back)
+	}
+	chat, _ := Request(Anthropic, Chat, []byte(`{"model":"m","messages":[{"role":"assistant","content":[{"type":"redacted_thinking","data":"opaque"}]}]}`), nil)
+	if strings.Contains(string(chat), "opaque") {
+		t.Errorf("redacted thinking leaked into Chat request: %s", chat)
+	}
+}
+
+func TestRequestRejectsUnsupportedFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to Protocol
+		body     string
+	}{
+		{"previous_response_id", Responses, Chat, `{"model":"m","input":"hi","previous_response_id":"resp_1"}`},
+		{"conversation", Responses, Anthropic, `{"model":"m","input":"hi","conversation":"conv_1"}`},
+		{"store", Responses, Chat, `{"model":"m","input":"hi","store":true}`},
+		{"json format to anthropic", Responses, Anthropic, `{"model":"m","input":"hi","text":{"format":{"type":"json_object"}}}`},
+		{"response_format to anthropic", Chat, Anthropic, `{"model":"m","messages":[],"response_format":{"type":"json_schema","json_schema":{}}}`},
+		{"metadata to anthropic", Chat, Anthropic, `{"model":"m","messages":[],"metadata":{"trace":"x"}}`},
+		{"web_search tool", Responses, Chat, `{"model":"m","input":"hi","tools":[{"type":"web_search"}]}`},
+		{"file_search tool", Responses, Anthropic, `{"model":"m","input":"hi","tools":[{"type":"file_search","vector_store_ids":["vs_1"]}]}`},
+		{"anthropic server tool", Anthropic, Chat, `{"model":"m","messages":[],"tools":[{"type":"web_search_20250305","name":"web_search"}]}`},
+		{"chat custom tool", Chat, Responses, `{"model":"m","messages":[],"tools":[{"type":"custom","custom":{"name":"x"}}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if out, err := Request(tt.from, tt.to, []byte(tt.body), nil); err == nil {
+				t.Errorf("expected error, got %s", out)
+			}
+		})
+	}
+
+	if _, err := Request(Responses, Chat, []byte(`{"model":"m","input":"hi","store":false}`), nil); err != nil {
+		t.Errorf("store:false must be accepted: %v", err)
+	}
+}
+
+func TestStreamReader_TruncatedStreamReportsError(t *testing.T) {
+	chunk := "data: {\"id\":\"c1\",\"model\":\"m\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hi\"}}]}\n\n"
+	tests := []struct {
+		name    string
+		body    io.Reader
+		to      Protocol
+		want    string
+		notWant string
+	}{
+		{"eof anthropic", strings.NewReader(chunk), Anthropic, "event: error\n", "message_stop"},
+		{"eof chat", strings.NewReader(chunk), Chat, `"error":{"message":"backend stream ended`, "[DONE]"},
+		{"eof responses", strings.NewReader(chunk), Responses, `"type":"error"`, "response.completed"},
+		{"read error", io.MultiReader(strings.NewReader(chunk), iotest.ErrReader(errors.New("connection reset"))), Anthropic, "connection reset", "message_stop"},
+		{"finish reason without done", strings.NewReader(chunk + "data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n"), Anthropic, "event: message_stop", "event: error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewStreamReader(Chat, tt.to, io.NopCloser(tt.body))
+			out, _ := io.ReadAll(r)
+			if !strings.Contains(string(out), tt.want) || strings.Contains(string(out), tt.notWant) {
+				t.Errorf("want %q and not %q in:\n%s", tt.want, tt.notWant, out)
+			}
+		})
+	}
+}
+
+func TestErrorBody(t *testing.T) {
+	msg := ErrorMessage([]byte(`{"detail":"Input must be a list"}`))
+	if msg != "Input must be a list" {
+		t.Fatalf("ErrorMessage = %q", msg)
+	}
+	if got := string(ErrorBody(Anthropic, msg)); got != `{"error":{"message":"Input must be a list","type":"api_error"},"type":"error"}` {
+		t.Errorf("anthropic error = %s", got)
+	}
+	if got := string(ErrorBody(Chat, msg)); got != `{"error":{"message":"Input must be a list","type":"api_error"}}` {
+		t.Errorf("openai error = %s", got)
+	}
+}
+
+// TestRecordedExchanges translates captures of real traffic in
+// testdata/recorded, named <protocol>_<request|response|stream>.<label>.
+// Unlike the goldens, the expectations come from the capture itself:
+// translated requests keep all of its text, and translated responses and
+// streams decode to the same events.
+func TestRecordedExchanges(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "recorded", "*_*.*"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no recorded exchanges: %v", err)
+	}
+	for _, path := range paths {
+		name := filepath.Base(path)
+		prefix, rest, _ := strings.Cut(name, "_")
+		kind, _, _ := strings.Cut(rest, ".")
+		var from Protocol = -1
+		for _, p := range protocols {
+			if p.String() == prefix {
+				from = p
+			}
+		}
+		if from < 0 {
+			t.Errorf("%s: unknown protocol %q", name, prefix)
+			continue
+		}
+		input, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, to := range protocols {
+			if to == from {
+				continue
+			}
+			t.Run(name+"/to_"+to.String(), func(t *testing.T) {
+				deterministicIDs(t)
+				switch kind {
+				case "request":
+					out, err := Request(from, to, input, nil)
+					if err != nil {
+						t.Fatal(err)
+					}
+					var in, got interface{}
+					json.Unmarshal(input, &in)
+					if err := json.Unmarshal(out, &got); err != nil {
+						t.Fatalf("output is not JSON: %v", err)
+					}
+					translated := strings.Join(texts(got, nil), "\n")
+					for _, text := range texts(in, nil) {
+						if !strings.Contains(translated, text) {
+							t.Errorf("text lost in translation: %q\n%s", text, out)
+						}
+					}
+				case "response":
+					out, err := Response(from, to, input)
+					if err != nil {
+						t.Fatal(err)
+					}
+					want, _ := decodeBody(from, input)
+					got, err := decodeBody(to, out)
+					if err != nil {
+						t.Fatalf("output does not decode: %v", err)
+					}
+					if !reflect.DeepEqual(summarize(got), summarize(want)) {
+						t.Errorf("translated response changed meaning\n got: %+v\nwant: %+v", summarize(got), summarize(want))
+					}
+				case "stream":
+					out, err := io.ReadAll(NewStreamReader(from, to, io.NopCloser(bytes.NewReader(input))))
+					if err != nil {
+						t.Fatal(err)
+					}
+					if got, want := summarize(decodeStream(t, to, out)), summarize(decodeStream(t, from, input)); !reflect.DeepEqual(got, want) {
+						t.Errorf("translated stream changed meaning\n got: %+v\nwant: %+v", got, want)
+					}
+				default:
+					t.Fatalf("unknown kind %q", kind)
+				}
+			})
+		}
+	}
+}
+
+// texts collects the prose in a decoded JSON body: strings with a space,
+// which leaves out IDs, models and enum values.
+func texts(v interface{}, acc []string) []string {
+	switch v := v.(type) {
+	case string:
+		if strings.Contains(v, " ") {
+			acc = append(acc, v)
+		}
+	case []interface{}:
+		for _, e := range v {
+			acc = texts(e, acc)
+		}
+	case map[string]interface{}:
+		for _, e := range v {
+			acc = texts(e, acc)
+		}
+	}
+	return acc
+}
+
+func TestReasoningEffortTiers(t *testing.T) {
+	custom := []thinking.Tier{{Name: "low", Level: 0}, {Name: "medium", Level: 0.2}, {Name: "high", Level: 0.4}}
+	tests := []struct {
+		name   string
+		tiers  []thinking.Tier
+		effort string
+		budget int
+	}{
+		{"default low", nil, "low", 3072},
+		{"default medium", nil, "medium", 6144},
+		{"default high", nil, "high", 13312},
+		{"minimal", nil, "minimal", thinking.MinBudget},
+		{"xhigh", nil, "xhigh", thinking.MaxBudget},
+		{"custom medium", custom, "medium", 2048},
+		{"custom high", custom, "high", 4096},
+		{"custom off", custom, "low", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"model":"m","messages":[{"role":"user","content":"hi"}],"reasoning_effort":"` + tt.effort + `"}`
+			out, err := Request(Chat, Anthropic, []byte(body), tt.tiers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var req struct {
+				Thinking struct {
+					BudgetTokens int `json:"budget_tokens"`
+				} `json:"thinking"`
+			}
+			json.Unmarshal(out, &req)
+			if req.Thinking.BudgetTokens != tt.budget {
+				t.Errorf("budget = %d, want %d", req.Thinking.BudgetTokens, tt.budget)
+			}
+		})
+	}
+}
+
+func TestEffortForBudget(t *testing.T) {
+	tests := []struct {
+		budget int
+		tiers  []thinking.Tier
+		want   string
+	}{
+		{0, thinking.DefaultTiers, ""},
+		{1024, thinking.DefaultTiers, "low"},
+		{4300, thinking.DefaultTiers, "low"},
+		{4400, thinking.DefaultTiers, "medium"},
+		{9000, thinking.DefaultTiers, "medium"},
+		{9100, thinking.DefaultTiers, "high"},
+		{32768, thinking.DefaultTiers, "high"},
+		{4096, []thinking.Tier{{Name: "medium", Level: 0.2}, {Name: "high", Level: 0.4}}, "high"},
+		{4096, []thinking.Tier{{Name: "deep", Level: 1}}, "low"},
+	}
+	for _, tt := range tests {
+		if got := effortForBudget(tt.budget, tt.tiers); got != tt.want {
+			t.Errorf("effortForBudget(%d, %v) = %q, want %q", tt.budget, tt.tiers, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+// Package updater downloads and installs CLIProxyAPIPlus releases. The
+// binary is replaced atomically, the previous one is kept for rollback and
+// the installed version is recorded in a manifest next to it.
+package updater
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultAPIBase = "https://api.github.com"
+	DefaultRepo    = "router-for-me/CLIProxyAPIPlus"
+
+	// checksumsAsset lists the SHA-256 of every archive in a release.
+	checksumsAsset = "checksums.txt"
+	binaryName     = "cli-proxy-api-plus"
+	manifestName   = "cli-proxy-api-plus.json"
+	previousSuffix = ".previous"
+)
+
+// Config selects the release and where it is installed. Empty fields take
+// the defaults; an empty or "latest" Version resolves the latest release.
+type Config struct {
+	APIBase string
+	Repo    string
+	Version string
+	Dir     string
+	GOOS    string
+	GOARCH  string
+	// SkipChecksum installs releases that publish no checksums.
+	SkipChecksum bool
+	Client       *http.Client
+}
+
+// Installed describes an installed release in the manifest.
+type Installed struct {
+	Version     string    `json:"version"`
+	Tag         string    `json:"tag"`
+	Asset       string    `json:"asset"`
+	SHA256      string    `json:"sha256,omitempty"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// Manifest records the installed release and the one kept for rollback.
+type Manifest struct {
+	Current  *Installed `json:"current,omitempty"`
+	Previous *Installed `json:"previous,omitempty"`
+}
+
+// Release is the part of the GitHub release API used here.
+type Release struct {
+	Tag    string  `json:"tag_name"`
+	Assets []Asset `json:"assets"`
+}
+
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+type Updater struct {
+	cfg Config
+}
+
+func New(cfg Config) *Updater {
+	if cfg.APIBase == "" {
+		cfg.APIBase = DefaultAPIBase
+	}
+	if cfg.Repo == "" {
+		cfg.Repo = DefaultRepo
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = "bin"
+	}
+	if cfg.GOOS == "" {
+		cfg.GOOS = runtime.GOOS
+	}
+	if cfg.GOARCH == "" {
+		cfg.GOARCH = runtime.GOARCH
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 5 * time.Minute}
+	}
+	cfg.APIBase = strings.TrimSuffix(cfg.APIBase, "/")
+	return &Updater{cfg: cfg}
+}
+
+// BinaryPath is where the backend binary is installed.
+func (u *Updater) BinaryPath() string {
+	name := binaryName
+	if u.cfg.GOOS == "windows" {
+		name += ".exe"
+	}
+	return filepath.Join(u.cfg.Dir, name)
+}
+
+func (u *Updater) manifestPath() string {
+	return filepath.Join(u.cfg.Dir, manifestName)
+}
+
+// Resolve fetches the configured release.
+func (u *Updater) Resolve() (*Release, error) {
+	url := u.cfg.APIBase + "/repos/" + u.cfg.Repo + "/releases/latest"
+	if v := u.cfg.Version; v != "" && v != "latest" {
+		url = u.cfg.APIBase + "/repos/" + u.cfg.Repo + "/releases/tags/v" + strings.TrimPrefix(v, "v")
+	}
+	data, err := u.get(url)
+	if err != nil {
+		return nil, fmt.Errorf("resolve release: %w", err)
+	}
+	var rel Release
+	if err := json.Unmarshal(data, &rel); err != nil {
+		return nil, fmt.Errorf("resolve release: invalid response from %s: %w", url, err)
+	}
+	if rel.Tag == "" {
+		return nil, fmt.Errorf("resolve release: no tag_name in response from %s", url)
+	}
+	return &rel, nil
+}
+
+// AssetName is the archive published for this platform, e.g.
+// CLIProxyAPIPlus_6.6.1-0_linux_amd64.tar.gz.
+func (u *Updater) AssetName(tag string) string {
+	ext := "tar.gz"
+	if u.cfg.GOOS == "windows" {
+		ext = "zip"
+	}
+	return fmt.Sprintf("CLIProxyAPIPlus_%s_%s_%s.%s", strings.TrimPrefix(tag, "v"), u.cfg.GOOS, u.cfg.GOARCH, ext)
+}
+
+// Installed reads the manifest; a missing manifest is an empty one.
+func (u *Updater) Installed() (Manifest, error) {
+	var m Manifest
+	data, err := os.ReadFile(u.manifestPath())
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("invalid manifest %s: %w", u.manifestPath(), err)
+	}
+	return m, nil
+}
+
+// UpToDate reports whether rel is already installed.
+func (u *Updater) UpToDate(rel *Release) bool {
+	m, err := u.Installed()
+	if err != nil || m.Current == nil || m.Current.Tag != rel.Tag {
+		return false
+	}
+	_, err = os.Stat(u.BinaryPath())
+	return err == nil
+}
+
+// Install downloads, verifies and installs rel. The binary it replaces is
+// kept for Rollback.
+func (u *Updater) Install(rel *Release) (*Installed, error) {
+	name := u.AssetName(rel.Tag)
+	asset, ok := findAsset(rel, name)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no asset %s for %s/%s", rel.Tag, name, u.cfg.GOOS, u.cfg.GOARCH)
+	}
+	archive, err := u.get(asset.URL)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", name, err)
+	}
+	sum, err := u.verify(rel, name, archive)
+	if err != nil {
+		return nil, err
+	}
+	binary, err := extractBinary(name, archive, filepath.Base(u.BinaryPath()))
+	if err != nil {
+		return nil, fmt.Errorf("extract %s: %w", name, err)
+	}
+
+	if err := os.MkdirAll(u.cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	m, err := u.Installed()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.replaceBinary(binary); err != nil {
+		return nil, err
+	}
+
+	installed := &Installed{
+		Version:     strings.TrimPrefix(rel.Tag, "v"),
+		Tag:         rel.Tag,
+		Asset:       name,
+		SHA256:      sum,
+		InstalledAt: time.Now().UTC(),
+	}
+	m.Previous, m.Current = m.Current, installed
+	if err := u.writeManifest(m); err != nil {
+		return nil, err
+	}
+	return installed, nil
+}
+
+// Rollback swaps the installed binary with the one kept by the last
+// Install.
+func (u *Updater) Rollback() (*Installed, error) {
+	current, previous := u.BinaryPath(), u.BinaryPath()+previousSuffix
+	if _, err := os.Stat(previous); err != nil {
+		return nil, fmt.Errorf("no previous version to roll back to")
+	}
+	m, err := u.Installed()
+	if err != nil {
+		return nil, err
+	}
+
+	// current stays in place until previous replaces it
+	swap := current + ".swap"
+	if err := linkOrCopy(current, swap); err != nil {
+		return nil, err
+	}
+	if err := replaceFile(previous, current); err != nil {
+		os.Remove(swap)
+		return nil, err
+	}
+	if err := os.Rename(swap, previous); err != nil {
+		return nil, err
+	}
+
+	m.Current, m.Previous = m.Previous, m.Current
+	if err := u.writeManifest(m); err != nil {
+		return nil, err
+	}
+	return m.Current, nil
+}
+
+// verify checks archive against the release's checksums file, returning
+// its SHA-256.
+func (u *Updater) verify(rel *Release, name string, archive []byte) (string, error) {
+	digest := sha256.Sum256(archive)
+	sum := hex.EncodeToString(digest[:])
+
+	asset, ok := findAsset(rel, checksumsAsset)
+	if !ok {
+		if u.cfg.SkipChecksum {
+			return sum, nil
+		}
+		return "", fmt.Errorf("release %s publishes no %s; use -skip-checksum to install anyway", rel.Tag, checksumsAsset)
+	}
+	data, err := u.get(asset.URL)
+	if err != nil {
+		return "", fmt.Errorf("download %s: %w", checksumsAsset, err)
+	}
+	want, ok := parseChecksums(data)[name]
+	if !ok {
+		return "", fmt.Errorf("%s has no entry for %s", checksumsAsset, name)
+	}
+	if !strings.EqualFold(want, sum) {
+		return "", fmt.Errorf("checksum mismatch for %s: got %s, want %s", name, sum, want)
+	}
+	return sum, nil
+}
+
+// parseChecksums reads "<sha256>  <file>" lines as written by sha256sum.
+func parseChecksums(data []byte) map[string]string {
+	sums := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			sums[strings.TrimPrefix(fields[1], "*")] = fields[0]
+		}
+	}
+	return sums
+}
+
+// replaceBinary writes binary next to the installed one and renames it over
+// it, after linking the old binary to .previous for rollback. A binary
+// exists at the path throughout.
+func (u *Updater) replaceBinary(binary []byte) error {
+	path := u.BinaryPath()
+	tmp, err := os.CreateTemp(u.cfg.Dir, "."+binaryName+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		if err := linkOrCopy(path, path+previousSuffix); err != nil {
+			return fmt.Errorf("keep previous version: %w", err)
+		}
+	}
+	return replaceFile(tmp.Name(), path)
+}
+
+// linkOrCopy makes dst a hard link to src, or a copy where links aren't
+// supported, replacing any existing dst.
+func linkOrCopy(src, dst string) error {
+	os.Remove(dst)
+	if os.Link(src, dst) == nil {
+		return nil
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	tmp := dst + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o755); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// replaceFile renames src over dst, which is atomic on POSIX. Windows
+// refuses to replace a running executable but lets it be moved aside, so
+// there dst is briefly missing instead.
+func replaceFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || runtime.GOOS != "windows" {
+		return err
+	}
+	old := dst + ".old"
+	os.Remove(old)
+	if err := os.Rename(dst, old); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		os.Rename(old, dst)
+		return err
+	}
+	// Still locked while the old binary runs; the next update retries
+	os.Remove(old)
+	return nil
+}
+
+func (u *Updater) writeManifest(m Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := u.manifestPath() + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, u.manifestPath())
+}
+
+func (u *Updater) get(url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(url, u.cfg.APIBase) {
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	resp, err := u.cfg.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s: %s", url, resp.Status, bytes.TrimSpace(data))
+	}
+	return data, nil
+}
+
+func findAsset(rel *Release, name string) (Asset, bool) {
+	for _, a := range rel.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
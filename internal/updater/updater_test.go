@@ -0,0 +1,290 @@
+package updater
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, body := range files {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(body)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(body))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range files {
+		w, _ := zw.Create(name)
+		w.Write([]byte(body))
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func sha(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// stubReleases serves a GitHub-style release API for the given tags, each
+// with linux/amd64 and windows/amd64 archives and a checksums file. The
+// latest release is the last tag.
+type stubReleases struct {
+	*httptest.Server
+	archives  map[string][]byte
+	checksums map[string]string
+	skipSums  bool
+}
+
+func newStubReleases(t *testing.T, tags ...string) *stubReleases {
+	s := &stubReleases{archives: map[string][]byte{}, checksums: map[string]string{}}
+	for _, tag := range tags {
+		ver := strings.TrimPrefix(tag, "v")
+		linux := fmt.Sprintf("CLIProxyAPIPlus_%s_linux_amd64.tar.gz", ver)
+		windows := fmt.Sprintf("CLIProxyAPIPlus_%s_windows_amd64.zip", ver)
+		s.archives[linux] = tarGz(t, map[string]string{"README.md": "readme", "cli-proxy-api-plus": "binary " + ver})
+		s.archives[windows] = zipArchive(t, map[string]string{"LICENSE": "mit", "cli-proxy-api-plus.exe": "exe " + ver})
+		var sums strings.Builder
+		for _, name := range []string{linux, windows} {
+			fmt.Fprintf(&sums, "%s  %s\n", sha(s.archives[name]), name)
+		}
+		s.checksums[tag] = sums.String()
+	}
+
+	release := func(tag string) Release {
+		ver := strings.TrimPrefix(tag, "v")
+		rel := Release{Tag: tag}
+		for _, name := range []string{
+			fmt.Sprintf("CLIProxyAPIPlus_%s_linux_amd64.tar.gz", ver),
+			fmt.Sprintf("CLIProxyAPIPlus_%s_windows_amd64.zip", ver),
+		} {
+			rel.Assets = append(rel.Assets, Asset{Name: name, URL: s.URL + "/download/" + name})
+		}
+		if !s.skipSums {
+			rel.Assets = append(rel.Assets, Asset{Name: checksumsAsset, URL: s.URL + "/sums/" + tag})
+		}
+		return rel
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/"+DefaultRepo+"/releases/latest":
+			json.NewEncoder(w).Encode(release(tags[len(tags)-1]))
+		case strings.HasPrefix(r.URL.Path, "/repos/"+DefaultRepo+"/releases/tags/"):
+			tag := strings.TrimPrefix(r.URL.Path, "/repos/"+DefaultRepo+"/releases/tags/")
+			if _, ok := s.checksums[tag]; !ok {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(release(tag))
+		case strings.HasPrefix(r.URL.Path, "/download/"):
+			w.Write(s.archives[strings.TrimPrefix(r.URL.Path, "/download/")])
+		case strings.HasPrefix(r.URL.Path, "/sums/"):
+			w.Write([]byte(s.checksums[strings.TrimPrefix(r.URL.Path, "/sums/")]))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func install(t *testing.T, u *Updater) *Installed {
+	t.Helper()
+	rel, err := u.Resolve()
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	installed, err := u.Install(rel)
+	if err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	return installed
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestInstall(t *testing.T) {
+	stub := newStubReleases(t, "v6.6.0-0", "v6.6.1-0")
+
+	tests := []struct {
+		name       string
+		version    string
+		goos       string
+		wantBinary string
+		wantFile   string
+	}{
+		{"latest tar.gz", "", "linux", "binary 6.6.1-0", "cli-proxy-api-plus"},
+		{"pinned tar.gz", "6.6.0-0", "linux", "binary 6.6.0-0", "cli-proxy-api-plus"},
+		{"pinned tag zip", "v6.6.0-0", "windows", "exe 6.6.0-0", "cli-proxy-api-plus.exe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			u := New(Config{APIBase: stub.URL, Version: tt.version, Dir: dir, GOOS: tt.goos, GOARCH: "amd64"})
+			installed := install(t, u)
+
+			if got := readFile(t, filepath.Join(dir, tt.wantFile)); got != tt.wantBinary {
+				t.Errorf("binary = %q, want %q", got, tt.wantBinary)
+			}
+			m, err := u.Installed()
+			if err != nil || m.Current == nil || m.Current.Tag != installed.Tag || m.Current.SHA256 == "" {
+				t.Errorf("manifest = %+v, %v", m.Current, err)
+			}
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != 2 {
+				t.Errorf("install dir holds %d files, want binary and manifest only", len(entries))
+			}
+		})
+	}
+}
+
+func TestInstall_KeepsPreviousForRollback(t *testing.T) {
+	stub := newStubReleases(t, "v6.6.0-0", "v6.6.1-0")
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "cli-proxy-api-plus")
+
+	install(t, New(Config{APIBase: stub.URL, Version: "6.6.0-0", Dir: dir, GOOS: "linux", GOARCH: "amd64"}))
+	u := New(Config{APIBase: stub.URL, Dir: dir, GOOS: "linux", GOARCH: "amd64"})
+	rel, _ := u.Resolve()
+	if u.UpToDate(rel) {
+		t.Fatal("6.6.0-0 reported up to date with 6.6.1-0")
+	}
+	install(t, u)
+	if !u.UpToDate(rel) {
+		t.Fatal("not up to date after installing the latest release")
+	}
+	if got := readFile(t, binary+previousSuffix); got != "binary 6.6.0-0" {
+		t.Errorf("previous binary = %q", got)
+	}
+
+	restored, err := u.Rollback()
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if restored.Version != "6.6.0-0" || readFile(t, binary) != "binary 6.6.0-0" {
+		t.Errorf("after rollback: version %s, binary %q", restored.Version, readFile(t, binary))
+	}
+	m, _ := u.Installed()
+	if m.Previous == nil || m.Previous.Version != "6.6.1-0" {
+		t.Errorf("manifest previous = %+v, want 6.6.1-0", m.Previous)
+	}
+}
+
+func TestInstall_RejectsBadChecksum(t *testing.T) {
+	stub := newStubReleases(t, "v6.6.1-0")
+	stub.checksums["v6.6.1-0"] = strings.Repeat("0", 64) + "  CLIProxyAPIPlus_6.6.1-0_linux_amd64.tar.gz\n"
+	dir := t.TempDir()
+	u := New(Config{APIBase: stub.URL, Dir: dir, GOOS: "linux", GOARCH: "amd64"})
+
+	rel, err := u.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := u.Install(rel); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("Install error = %v, want checksum mismatch", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("failed install left %d files behind", len(entries))
+	}
+}
+
+func TestInstall_MissingChecksums(t *testing.T) {
+	stub := newStubReleases(t, "v6.6.1-0")
+	stub.skipSums = true
+
+	u := New(Config{APIBase: stub.URL, Dir: t.TempDir(), GOOS: "linux", GOARCH: "amd64"})
+	rel, _ := u.Resolve()
+	if _, err := u.Install(rel); err == nil {
+		t.Fatal("installed a release without checksums")
+	}
+
+	u = New(Config{APIBase: stub.URL, Dir: t.TempDir(), GOOS: "linux", GOARCH: "amd64", SkipChecksum: true})
+	install(t, u)
+}
+
+func TestResolve_Errors(t *testing.T) {
+	stub := newStubReleases(t, "v6.6.1-0")
+
+	u := New(Config{APIBase: stub.URL, Version: "9.9.9", Dir: t.TempDir(), GOOS: "linux", GOARCH: "amd64"})
+	if _, err := u.Resolve(); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("unknown version: err = %v, want 404", err)
+	}
+
+	u = New(Config{APIBase: stub.URL, Dir: t.TempDir(), GOOS: "freebsd", GOARCH: "riscv64"})
+	rel, _ := u.Resolve()
+	if _, err := u.Install(rel); err == nil || !strings.Contains(err.Error(), "no asset") {
+		t.Errorf("unsupported platform: err = %v, want no asset", err)
+	}
+}
+
+// The binary is never missing while an update replaces it, so a crash or
+// a supervisor restart mid-install still finds one to run.
+func TestInstall_BinaryAlwaysPresent(t *testing.T) {
+	stub := newStubReleases(t, "v6.6.0-0", "v6.6.1-0")
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "cli-proxy-api-plus")
+	install(t, New(Config{APIBase: stub.URL, Version: "6.6.0-0", Dir: dir, GOOS: "linux", GOARCH: "amd64"}))
+
+	stop := make(chan struct{})
+	missing := make(chan struct{}, 1)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				close(missing)
+				return
+			default:
+			}
+			if _, err := os.Stat(binary); err != nil {
+				missing <- struct{}{}
+				<-stop
+				close(missing)
+				return
+			}
+		}
+	}()
+	u := New(Config{APIBase: stub.URL, Dir: dir, GOOS: "linux", GOARCH: "amd64"})
+	for i := 0; i < 20; i++ {
+		install(t, u)
+		if _, err := u.Rollback(); err != nil {
+			t.Fatalf("Rollback: %v", err)
+		}
+	}
+	close(stop)
+	if _, ok := <-missing; ok {
+		t.Error("binary was missing during an install or rollback")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 3 {
+		t.Errorf("install dir holds %d files, want binary, previous and manifest", len(entries))
+	}
+}
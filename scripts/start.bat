@@ -4,22 +4,19 @@ REM start.bat - Start both proxies on Windows
 cd /d "%~dp0\.."
 
 REM Check for binaries
-if not exist bin\thinking-proxy.exe (
-    echo Building thinking-proxy...
-    go build -o bin\thinking-proxy.exe .\cmd\thinking-proxy
+if not exist bin\vibeproxy.exe (
+    echo Building vibeproxy...
+    go build -o bin\vibeproxy.exe .\cmd\vibeproxy
 )
 
 if not exist bin\cli-proxy-api-plus.exe (
     echo Error: bin\cli-proxy-api-plus.exe not found
-    echo Download from https://github.com/router-for-me/CLIProxyAPIPlus/releases
+    echo Run "bin\vibeproxy.exe update" first
     exit /b 1
 )
 
-echo Starting CLIProxyAPIPlus on :8318...
-start /b bin\cli-proxy-api-plus.exe -config config\cliproxy.yaml
-
-timeout /t 1 /nobreak >nul
-
-echo Starting ThinkingProxy on :8317...
+REM ThinkingProxy launches CLIProxyAPIPlus, waits for it to be ready,
+REM restarts it if it crashes and stops it on Ctrl+C
+echo Starting CLIProxyAPIPlus on :8318 and ThinkingProxy on :8317...
 echo Press Ctrl+C to stop
-bin\thinking-proxy.exe
+bin\vibeproxy.exe run